@@ -2,21 +2,32 @@ package cli
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/intelligence"
 	"github.com/oluoyefeso/termiflow/internal/providers/llm"
 	"github.com/oluoyefeso/termiflow/internal/providers/search"
 	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
 var askSources int
 var askNoSearch bool
 var askSave bool
+var askContinue bool
+var askThread int64
+var askFromFeed bool
+var askNoFeed bool
+var askEnrich bool
 
 var askCmd = &cobra.Command{
 	Use:   "ask <question>",
@@ -26,7 +37,11 @@ var askCmd = &cobra.Command{
 Examples:
   termiflow ask "what are the latest advancements in 3nm chip fabrication?"
   termiflow ask "explain rust's borrow checker" --provider local
-  termiflow ask "compare TSMC N3 vs Intel 4" --sources 5`,
+  termiflow ask "compare TSMC N3 vs Intel 4" --sources 5
+  termiflow ask "what did semianalysis say about CoWoS?" --from-feed
+  termiflow ask "what changed in the EU chips act?" --enrich
+  termiflow ask --continue "how does that compare to Intel 18A?"
+  termiflow ask --thread 12 "what about power efficiency?"`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runAsk,
 }
@@ -34,91 +49,353 @@ Examples:
 func init() {
 	askCmd.Flags().IntVar(&askSources, "sources", 5, "number of sources to retrieve")
 	askCmd.Flags().BoolVar(&askNoSearch, "no-search", false, "answer from LLM knowledge only, don't search")
-	askCmd.Flags().BoolVar(&askSave, "save", false, "save this query to history")
+	askCmd.Flags().BoolVar(&askSave, "save", true, "save this query to history (use --save=false to skip)")
+	askCmd.Flags().BoolVarP(&askContinue, "continue", "c", false, "follow up on the most recent conversation")
+	askCmd.Flags().Int64Var(&askThread, "thread", 0, "follow up on the conversation containing this history id")
+	askCmd.Flags().BoolVar(&askFromFeed, "from-feed", false, "answer only from items already in your feed (works offline)")
+	askCmd.Flags().BoolVar(&askNoFeed, "no-feed", false, "don't use items from your feed, search the web only")
+	askCmd.Flags().BoolVar(&askEnrich, "enrich", false, "read the full article behind each web result (default from search.enrich.enabled)")
 }
 
 func runAsk(cmd *cobra.Command, args []string) error {
+	if !cmd.Flags().Changed("enrich") {
+		askEnrich = config.Get().Search.Enrich.Enabled
+	}
+
 	question := strings.Join(args, " ")
+	return askQuestion(question)
+}
+
+func askQuestion(question string) error {
 	cfg := config.Get()
 
+	// Load earlier turns when following up
+	turns, err := loadAskThread()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println(ui.Header("termiflow ask"))
 	fmt.Println()
 
+	if len(turns) > 0 {
+		fmt.Print(ui.Info("Thread", fmt.Sprintf("#%d (%d earlier turns)", turns[0].ThreadID, len(turns))))
+		fmt.Println()
+	}
+
 	var sources []search.SearchResult
-	var err error
 
 	// Search for sources unless --no-search is set
 	if !askNoSearch {
 		sp := ui.NewSpinner("Searching...")
 		sp.Start()
 
-		sources, err = fetchSources(question, askSources)
-		if err != nil {
+		var partial error
+		sources, partial, err = gatherSources(followUpSearchQuery(turns, question), askSources)
+		if err == nil && askEnrich {
+			sp.UpdateMessage("Reading full articles...")
+			newEnricher(cfg).Enrich(context.Background(), sources)
+		}
+
+		switch {
+		case err != nil:
 			sp.Error(fmt.Sprintf("Search failed: %v", err))
-			// Continue without sources
-		} else {
+			// Continue with whatever sources were found
+		case askFromFeed && len(sources) == 0:
+			sp.Error("No matching items in your feed archive")
+		default:
 			sp.Stop()
 		}
+		if partial != nil {
+			fmt.Print(ui.Warning(partial.Error()))
+		}
 	}
 
 	// Get LLM provider
-	providerName := getProvider()
-	llmProvider, err := llm.GetProvider(providerName, cfg)
+	providerNames := getProviders()
+	llmProvider, err := llm.GetChain(providerNames, cfg)
 	if err != nil {
 		return err
 	}
 
 	if !llmProvider.Available() {
-		fmt.Fprint(os.Stderr, formatAPIKeyError(providerName))
+		fmt.Fprint(os.Stderr, formatAPIKeyError(providerNames[0]))
 		return fmt.Errorf("provider not configured")
 	}
 
 	// Build prompt with sources
 	prompt := buildPrompt(question, sources)
 
+	messages := intelligence.BuildConversation(askSystemPrompt, turns, prompt, intelligence.DefaultHistoryBudget)
+
+	return answerQuestion(context.Background(), trackUsage(llmProvider, cfg), question, messages, turns, sources)
+}
+
+// answerQuestion streams the answer to question and, with --save, saves it
+// to history. An answer that stops part way is still saved, marked
+// incomplete, since the user has already read it and may follow it up.
+func answerQuestion(ctx context.Context, llmProvider llm.Provider, question string, messages []llm.Message, turns []*models.Query, sources []search.SearchResult) error {
+	start := time.Now()
+	answer, streamErr := streamAnswer(ctx, llmProvider, messages)
+	if streamErr != nil && answer.Content == "" {
+		return streamErr
+	}
+	latency := time.Since(start)
+
+	printSources(sources)
+
+	if askSave {
+		entry := &models.Query{
+			Query:            question,
+			Response:         answer.Content,
+			Provider:         answer.Provider,
+			Model:            answer.Model,
+			Sources:          toQuerySources(sources),
+			LatencyMs:        latency.Milliseconds(),
+			PromptTokens:     answer.Usage.PromptTokens,
+			CompletionTokens: answer.Usage.CompletionTokens,
+			Incomplete:       streamErr != nil,
+		}
+		if len(turns) > 0 {
+			entry.ThreadID = turns[0].ThreadID
+		}
+		switch err := db.CreateQuery(entry); {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: failed to save query to history: %v\n", err)
+		case streamErr != nil:
+			fmt.Println()
+			fmt.Print(ui.Warning(fmt.Sprintf("The answer stopped part way; saved as incomplete #%d", entry.ID)))
+			fmt.Print(ui.Tip(fmt.Sprintf("Ask again with %s", ui.TitleStyle.Render(fmt.Sprintf("termiflow history replay %d", entry.ID)))))
+		default:
+			fmt.Print(ui.Tip(fmt.Sprintf("Follow up with %s", ui.TitleStyle.Render("termiflow ask --continue \"...\""))))
+		}
+	}
+
+	fmt.Println()
+	return streamErr
+}
+
+// loadAskThread returns the earlier turns selected by --continue or --thread.
+func loadAskThread() ([]*models.Query, error) {
+	var threadID int64
+
+	switch {
+	case askThread > 0:
+		q, err := db.GetQuery(askThread)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no saved query #%d", askThread)
+		}
+		if err != nil {
+			return nil, err
+		}
+		threadID = q.ThreadID
+	case askContinue:
+		q, err := db.GetLatestQuery()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no previous question to continue - ask one first")
+		}
+		if err != nil {
+			return nil, err
+		}
+		threadID = q.ThreadID
+	default:
+		return nil, nil
+	}
+
+	return db.GetThread(threadID)
+}
+
+// followUpSearchQuery gives terse follow-ups ("and Safari?") the context of
+// the previous question so the web search stays on topic.
+func followUpSearchQuery(turns []*models.Query, question string) string {
+	if len(turns) == 0 {
+		return question
+	}
+	return turns[len(turns)-1].Query + " " + question
+}
+
+const askSystemPrompt = "You are a helpful assistant that provides accurate, well-researched answers. Use the provided sources to inform your response. Be concise but thorough."
+
+// streamAnswer streams a completion to stdout and returns the full answer,
+// who gave it, and token usage when the provider reports it. If a provider
+// chain fell over, it says who answered. On error the answer holds what
+// arrived before it.
+func streamAnswer(ctx context.Context, llmProvider llm.Provider, messages []llm.Message) (*llm.CompletionResponse, error) {
+	answer := &llm.CompletionResponse{Provider: llmProvider.Name(), Model: llmProvider.Model()}
+	primary := *answer
+
 	sp := ui.NewSpinner("Thinking...")
 	sp.Start()
 
-	// Stream the response
-	ctx := context.Background()
-	chunks, err := llmProvider.Stream(ctx, llm.CompletionRequest{
-		Messages: []llm.Message{
-			{Role: "system", Content: "You are a helpful assistant that provides accurate, well-researched answers. Use the provided sources to inform your response. Be concise but thorough."},
-			{Role: "user", Content: prompt},
-		},
+	chunks, err := llmProvider.Stream(llm.WithPurpose(ctx, llm.PurposeAsk), llm.CompletionRequest{
+		Messages:    messages,
 		MaxTokens:   2048,
 		Temperature: 0.7,
 		Stream:      true,
 	})
 	if err != nil {
 		sp.Error(fmt.Sprintf("Failed to get response: %v", err))
-		return err
+		return answer, err
 	}
 
 	sp.Stop()
 	fmt.Println()
 
-	// Stream output
+	var content strings.Builder
+
 	for chunk := range chunks {
+		if chunk.Restart {
+			fmt.Println()
+			fmt.Print(ui.Warning(fmt.Sprintf("%s stopped part way; %s is answering instead", answer.Provider, chunk.Provider)))
+			fmt.Println()
+			content.Reset()
+		}
+		if chunk.Provider != "" {
+			answer.Provider, answer.Model = chunk.Provider, chunk.Model
+		}
 		if chunk.Error != nil {
-			return chunk.Error
+			answer.Content = content.String()
+			return answer, chunk.Error
+		}
+		if chunk.Usage != nil {
+			answer.Usage = *chunk.Usage
 		}
 		fmt.Print(chunk.Content)
+		content.WriteString(chunk.Content)
 	}
 	fmt.Println()
 
-	// Print sources
-	if len(sources) > 0 {
+	if note := fallbackNote(&primary, answer); note != "" {
 		fmt.Println()
-		fmt.Println(ui.SmallDivider())
-		fmt.Println(ui.BoldStyle.Render(" Sources:"))
-		for i, src := range sources {
-			fmt.Printf("   [%d] %s - %s\n", i+1, ui.MutedStyle.Render(getDomain(src.URL)), src.Title)
-		}
+		fmt.Println(ui.MutedStyle.Render("   " + note))
+	}
+
+	answer.Content = content.String()
+	return answer, nil
+}
+
+// fallbackNote names the provider that answered when it isn't the one asked
+// first, or returns "".
+func fallbackNote(primary, answer *llm.CompletionResponse) string {
+	if answer.Provider == primary.Provider && answer.Model == primary.Model {
+		return ""
+	}
+	return fmt.Sprintf("Answered by %s (%s) instead of %s", answer.Provider, answer.Model, primary.Provider)
+}
+
+func printSources(sources []search.SearchResult) {
+	if len(sources) == 0 {
+		return
 	}
 
 	fmt.Println()
-	return nil
+	fmt.Println(ui.SmallDivider())
+	fmt.Println(ui.BoldStyle.Render(" Sources:"))
+	for i, src := range sources {
+		fmt.Printf("   [%d] %s - %s\n", i+1, ui.MutedStyle.Render(sourceLabel(src.ItemID, src.URL)), src.Title)
+	}
+}
+
+func sourceLabel(itemID int64, url string) string {
+	if itemID > 0 {
+		return fmt.Sprintf("feed #%d · %s", itemID, getDomain(url))
+	}
+	return getDomain(url)
+}
+
+func toQuerySources(sources []search.SearchResult) []models.QuerySource {
+	var result []models.QuerySource
+	for _, src := range sources {
+		result = append(result, models.QuerySource{
+			Title:   src.Title,
+			URL:     src.URL,
+			Snippet: src.Snippet,
+			ItemID:  src.ItemID,
+		})
+	}
+	return result
+}
+
+// gatherSources combines matching items from the local feed archive with web
+// search results, depending on --from-feed and --no-feed. Feed items come first.
+// When one of the two fails but there are still sources, its error is returned
+// as partial, to be shown as a warning.
+func gatherSources(query string, limit int) (sources []search.SearchResult, partial error, err error) {
+	if askFromFeed && askNoFeed {
+		return nil, nil, fmt.Errorf("--from-feed and --no-feed cannot be used together")
+	}
+
+	var feedSources []search.SearchResult
+	var feedErr error
+
+	if !askNoFeed {
+		feedSources, feedErr = fetchFeedSources(query, limit)
+		if askFromFeed {
+			return feedSources, nil, feedErr
+		}
+		if feedErr != nil {
+			feedErr = fmt.Errorf("feed archive search failed: %w", feedErr)
+		}
+	}
+
+	webSources, err := fetchSources(query, limit)
+	if err != nil && len(feedSources) == 0 {
+		return nil, feedErr, err
+	}
+
+	partial = feedErr
+	if err != nil {
+		partial = fmt.Errorf("web search failed: %w", err)
+	}
+	return mergeSources(feedSources, webSources, limit), partial, nil
+}
+
+// mergeSources appends web results not already among the feed items, keeping
+// at most limit sources.
+func mergeSources(feed, web []search.SearchResult, limit int) []search.SearchResult {
+	seen := make(map[string]bool)
+	var sources []search.SearchResult
+	for _, list := range [][]search.SearchResult{feed, web} {
+		for _, src := range list {
+			if !seen[src.URL] {
+				seen[src.URL] = true
+				sources = append(sources, src)
+			}
+		}
+	}
+
+	if limit > 0 && len(sources) > limit {
+		sources = sources[:limit]
+	}
+	return sources
+}
+
+// fetchFeedSources finds stored feed items relevant to the query.
+func fetchFeedSources(query string, limit int) ([]search.SearchResult, error) {
+	keywords := intelligence.Keywords(query)
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+
+	matches, err := db.SearchFeedItems(db.FeedSearchFilter{
+		Query:    strings.Join(keywords, " "),
+		MatchAny: true,
+		Limit:    50,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*models.FeedItem, len(matches))
+	for i, m := range matches {
+		candidates[i] = m.Item
+	}
+
+	var sources []search.SearchResult
+	for _, item := range intelligence.RankFeedItems(keywords, candidates, limit) {
+		sources = append(sources, intelligence.FeedItemToSource(item))
+	}
+	return sources, nil
 }
 
 func fetchSources(query string, limit int) ([]search.SearchResult, error) {
@@ -128,7 +405,7 @@ func fetchSources(query string, limit int) ([]search.SearchResult, error) {
 		return nil, fmt.Errorf("Tavily API key not configured")
 	}
 
-	tavily := search.NewTavilyProvider(cfg.Search.Tavily.APIKey)
+	tavily := newTavily(cfg)
 	return tavily.Search(context.Background(), search.SearchRequest{
 		Query:      query,
 		MaxResults: limit,
@@ -141,14 +418,26 @@ func buildPrompt(question string, sources []search.SearchResult) string {
 
 	if len(sources) > 0 {
 		sb.WriteString("Use the following sources to inform your answer:\n\n")
+		hasFeedItems := false
 		for i, src := range sources {
-			sb.WriteString(fmt.Sprintf("Source %d: %s\n", i+1, src.Title))
+			if src.ItemID > 0 {
+				hasFeedItems = true
+				sb.WriteString(fmt.Sprintf("Source %d (feed item #%d): %s\n", i+1, src.ItemID, src.Title))
+			} else {
+				sb.WriteString(fmt.Sprintf("Source %d: %s\n", i+1, src.Title))
+			}
 			sb.WriteString(fmt.Sprintf("URL: %s\n", src.URL))
-			if src.Snippet != "" {
+			// Full articles, when enriched or stored, beat search snippets
+			if src.Content != "" {
+				sb.WriteString(fmt.Sprintf("Content: %s\n", src.Content))
+			} else if src.Snippet != "" {
 				sb.WriteString(fmt.Sprintf("Content: %s\n", src.Snippet))
 			}
 			sb.WriteString("\n")
 		}
+		if hasFeedItems {
+			sb.WriteString("When you use a feed item, cite it by its ID, e.g. [feed #42].\n\n")
+		}
 		sb.WriteString("---\n\n")
 	}
 
@@ -169,6 +458,21 @@ func getDomain(url string) string {
 	return url
 }
 
+// describeProvider names a provider and its model, or every provider of a
+// chain in the order they're tried.
+func describeProvider(p llm.Provider) string {
+	chain, ok := p.(*llm.Chain)
+	if !ok {
+		return fmt.Sprintf("%s (%s)", p.Name(), p.Model())
+	}
+
+	var names []string
+	for _, p := range chain.Providers() {
+		names = append(names, fmt.Sprintf("%s (%s)", p.Name(), p.Model()))
+	}
+	return strings.Join(names, " → ")
+}
+
 func formatAPIKeyError(provider string) string {
 	return fmt.Sprintf(`
  %s API key not configured
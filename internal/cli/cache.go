@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/httpcache"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clear the response cache",
+	Long: `Inspect and clear the cache of search results, web pages and feeds.
+
+Responses are kept under general.cache_dir and reused until their TTL in the
+[cache] section runs out. With --offline, any command serves only what's in
+the cache, however old, and fails for anything that isn't.
+
+Examples:
+  termiflow cache stats        # Show what the cache holds
+  termiflow cache clear        # Remove every entry
+  termiflow cache clear pages  # Remove cached web pages only`,
+	RunE: runCacheStats,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show what the cache holds",
+	RunE:  runCacheStats,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:       "clear [search|pages|feeds]",
+	Short:     "Remove cached responses",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: httpcache.Namespaces,
+	RunE:      runCacheClear,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+
+	stats, err := openCache(cfg).Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	fmt.Println(ui.Header("termiflow cache"))
+	fmt.Println()
+	fmt.Print(ui.Info("Directory", stats.Dir))
+	limit := "unlimited"
+	if stats.MaxBytes > 0 {
+		limit = formatBytes(stats.MaxBytes)
+	}
+	fmt.Print(ui.Info("Size", fmt.Sprintf("%s of %s", formatBytes(stats.Total.Bytes), limit)))
+	if !cfg.Cache.Enabled {
+		fmt.Print(ui.Info("Status", "disabled"))
+	}
+	fmt.Println()
+
+	for _, ns := range stats.Namespaces {
+		line := fmt.Sprintf("   %-8s %6d entries %10s", ns.Name, ns.Entries, formatBytes(ns.Bytes))
+		if ns.Expired > 0 {
+			line += "  " + ui.MutedStyle.Render(fmt.Sprintf("%d expired", ns.Expired))
+		}
+		fmt.Println(line)
+	}
+
+	if stats.Total.Expired > 0 {
+		fmt.Print(ui.Tip("Expired entries are only served with --offline"))
+	}
+	fmt.Println()
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	namespace := ""
+	if len(args) == 1 {
+		namespace = strings.ToLower(args[0])
+		if !slices.Contains(httpcache.Namespaces, namespace) {
+			return fmt.Errorf("unknown cache %q (want %s)", args[0], strings.Join(httpcache.Namespaces, ", "))
+		}
+	}
+
+	removed, err := openCache(config.Get()).Clear(namespace)
+	if err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	what := "cached responses"
+	if namespace != "" {
+		what = "cached " + namespace
+	}
+	fmt.Print(ui.Success(fmt.Sprintf("Removed %d %s", removed, what)))
+	return nil
+}
+
+// formatBytes renders a size the way people read it: 512 B, 1.5 KB, 200 MB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	value := float64(n) / float64(div)
+	suffix := []string{"KB", "MB", "GB", "TB"}[exp]
+	if value >= 10 || value == float64(int64(value)) {
+		return fmt.Sprintf("%.0f %s", value, suffix)
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/intelligence"
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+var chatSources int
+var chatThread int64
+var chatEnrich bool
+
+var chatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "Start an interactive conversation in the terminal",
+	Long: `Start an interactive conversation in the terminal.
+
+The conversation is kept in memory between questions. Use slash commands
+to pull in fresh sources or change how answers are generated:
+
+` + chatHelp + `
+
+Examples:
+  termiflow chat
+  termiflow chat --provider anthropic
+  termiflow chat --thread 12          # Resume a saved conversation`,
+	RunE: runChat,
+}
+
+const chatHelp = `  /search [query]    search the web and use the results for the next question
+  /sources           list sources retrieved in this session
+  /provider [name]   show or switch the LLM provider
+  /save              save the conversation to history
+  /clear             forget the conversation and start over
+  /help              show this help
+  /exit              leave chat (Ctrl+D also works)`
+
+func init() {
+	chatCmd.Flags().IntVar(&chatSources, "sources", 5, "number of sources to retrieve with /search")
+	chatCmd.Flags().Int64Var(&chatThread, "thread", 0, "resume the conversation containing this history id")
+	chatCmd.Flags().BoolVar(&chatEnrich, "enrich", false, "read the full article behind each /search result (default from search.enrich.enabled)")
+}
+
+type chatSession struct {
+	cfg      *config.Config
+	provider llm.Provider
+	turns    []*models.Query
+	pending  []search.SearchResult
+	sources  []search.SearchResult
+	enrich   bool
+	threadID int64
+	saved    int
+}
+
+func runChat(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+
+	providerNames := getProviders()
+	llmProvider, err := llm.GetChain(providerNames, cfg)
+	if err != nil {
+		return err
+	}
+
+	if !llmProvider.Available() {
+		fmt.Fprint(os.Stderr, formatAPIKeyError(providerNames[0]))
+		return fmt.Errorf("provider not configured")
+	}
+
+	if !cmd.Flags().Changed("enrich") {
+		chatEnrich = cfg.Search.Enrich.Enabled
+	}
+
+	session := &chatSession{cfg: cfg, provider: llmProvider, enrich: chatEnrich}
+
+	if chatThread > 0 {
+		if err := session.resume(chatThread); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(ui.Header("termiflow chat"))
+	fmt.Println()
+	fmt.Print(ui.Info("Provider", describeProvider(llmProvider)))
+	if len(session.turns) > 0 {
+		fmt.Print(ui.Info("Thread", fmt.Sprintf("#%d (%d earlier turns)", session.threadID, len(session.turns))))
+	}
+	fmt.Print(ui.Tip(fmt.Sprintf("Type a question, %s for commands, or Ctrl+D to quit.", ui.TitleStyle.Render("/help"))))
+	fmt.Println()
+
+	return session.loop(os.Stdin)
+}
+
+func (s *chatSession) resume(id int64) error {
+	q, err := lookupQuery(fmt.Sprintf("%d", id))
+	if err != nil {
+		return err
+	}
+
+	turns, err := db.GetThread(q.ThreadID)
+	if err != nil {
+		return err
+	}
+
+	s.turns = turns
+	s.threadID = q.ThreadID
+	s.saved = len(turns)
+	return nil
+}
+
+func (s *chatSession) loop(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for {
+		fmt.Print(ui.TitleStyle.Render("› "))
+		if !scanner.Scan() {
+			fmt.Println()
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if command, arg, ok := parseChatCommand(line); ok {
+			if done := s.handleCommand(command, arg); done {
+				break
+			}
+			continue
+		}
+
+		s.ask(line)
+	}
+
+	s.warnUnsaved()
+	return scanner.Err()
+}
+
+// parseChatCommand splits "/search rust async" into ("search", "rust async").
+func parseChatCommand(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, "/") {
+		return "", "", false
+	}
+
+	fields := strings.SplitN(strings.TrimPrefix(line, "/"), " ", 2)
+	command := strings.ToLower(fields[0])
+	arg := ""
+	if len(fields) > 1 {
+		arg = strings.TrimSpace(fields[1])
+	}
+	return command, arg, command != ""
+}
+
+// handleCommand runs a slash command and reports whether the session should end.
+func (s *chatSession) handleCommand(command, arg string) bool {
+	switch command {
+	case "exit", "quit", "q":
+		return true
+	case "help", "?":
+		fmt.Println(chatHelp)
+	case "search":
+		s.search(arg)
+	case "sources":
+		s.listSources()
+	case "provider":
+		s.switchProvider(arg)
+	case "save":
+		s.save()
+	case "clear":
+		s.turns = nil
+		s.pending = nil
+		s.sources = nil
+		s.threadID = 0
+		s.saved = 0
+		fmt.Print(ui.Success("Conversation cleared"))
+	default:
+		fmt.Print(ui.Warning(fmt.Sprintf("Unknown command /%s - try /help", command)))
+	}
+	fmt.Println()
+	return false
+}
+
+func (s *chatSession) ask(question string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	prompt := buildPrompt(question, s.pending)
+	messages := intelligence.BuildConversation(askSystemPrompt, s.turns, prompt, intelligence.DefaultHistoryBudget)
+
+	start := time.Now()
+	answer, err := streamAnswer(ctx, trackUsage(s.provider, s.cfg), messages)
+	if ctx.Err() != nil {
+		fmt.Println()
+		fmt.Print(ui.Warning("Interrupted"))
+		if answer.Content == "" {
+			fmt.Println()
+			return
+		}
+	} else if err != nil {
+		fmt.Print(ui.Error(fmt.Sprintf("Failed to get response: %v", err)))
+		fmt.Println()
+		return
+	}
+
+	turn := &models.Query{
+		Query:            question,
+		Response:         answer.Content,
+		Provider:         answer.Provider,
+		Model:            answer.Model,
+		Sources:          toQuerySources(s.pending),
+		LatencyMs:        time.Since(start).Milliseconds(),
+		PromptTokens:     answer.Usage.PromptTokens,
+		CompletionTokens: answer.Usage.CompletionTokens,
+	}
+
+	s.turns = append(s.turns, turn)
+	printSources(s.pending)
+	s.pending = nil
+	fmt.Println()
+}
+
+func (s *chatSession) search(query string) {
+	if query == "" {
+		if len(s.turns) == 0 {
+			fmt.Print(ui.Warning("Usage: /search <query>"))
+			return
+		}
+		// Re-run the search for the last question
+		query = s.turns[len(s.turns)-1].Query
+	}
+
+	sp := ui.NewSpinner(fmt.Sprintf("Searching for %q...", query))
+	sp.Start()
+
+	results, err := fetchSources(query, chatSources)
+	if err != nil {
+		sp.Error(fmt.Sprintf("Search failed: %v", err))
+		return
+	}
+
+	if s.enrich {
+		sp.UpdateMessage("Reading full articles...")
+		newEnricher(s.cfg).Enrich(context.Background(), results)
+	}
+
+	sp.Success(fmt.Sprintf("Found %d sources - they'll be used for your next question", len(results)))
+	s.pending = results
+	s.sources = append(s.sources, results...)
+}
+
+func (s *chatSession) listSources() {
+	if len(s.sources) == 0 {
+		fmt.Println(ui.MutedStyle.Render("   No sources yet. Use /search <query> to find some."))
+		return
+	}
+
+	for i, src := range s.sources {
+		fmt.Printf("   [%d] %s - %s\n", i+1, ui.MutedStyle.Render(sourceLabel(src.ItemID, src.URL)), src.Title)
+	}
+}
+
+func (s *chatSession) switchProvider(name string) {
+	if name == "" {
+		fmt.Print(ui.Info("Provider", describeProvider(s.provider)))
+		return
+	}
+
+	p, err := llm.GetProvider(name, s.cfg)
+	if err != nil {
+		fmt.Print(ui.Error(err.Error()))
+		return
+	}
+	if !p.Available() {
+		fmt.Print(formatAPIKeyError(name))
+		return
+	}
+
+	s.provider = p
+	fmt.Print(ui.Success(fmt.Sprintf("Switched to %s (%s)", p.Name(), p.Model())))
+}
+
+func (s *chatSession) save() {
+	if s.saved == len(s.turns) {
+		fmt.Print(ui.Warning("Nothing new to save"))
+		return
+	}
+
+	for _, turn := range s.turns[s.saved:] {
+		turn.ThreadID = s.threadID
+		if err := db.CreateQuery(turn); err != nil {
+			fmt.Print(ui.Error(fmt.Sprintf("Failed to save: %v", err)))
+			return
+		}
+		s.threadID = turn.ThreadID
+		s.saved++
+	}
+
+	fmt.Print(ui.Success(fmt.Sprintf("Saved to history as thread #%d", s.threadID)))
+}
+
+func (s *chatSession) warnUnsaved() {
+	if unsaved := len(s.turns) - s.saved; unsaved > 0 {
+		fmt.Print(ui.Warning(fmt.Sprintf("%d unsaved turn(s) discarded - use /save next time to keep them", unsaved)))
+	}
+}
@@ -1,8 +1,23 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+	"github.com/oluoyefeso/termiflow/pkg/opml"
 )
 
 func TestSetVersionInfo(t *testing.T) {
@@ -44,6 +59,17 @@ func TestRootCmdHasSubcommands(t *testing.T) {
 		"unsubscribe",
 		"feed",
 		"topics",
+		"history",
+		"chat",
+		"search",
+		"db",
+		"daemon",
+		"schedule",
+		"feeds",
+		"import",
+		"export",
+		"cache",
+		"usage",
 	}
 
 	for _, expected := range expectedCommands {
@@ -177,7 +203,7 @@ func TestAskCmd(t *testing.T) {
 	}
 
 	// Check flags
-	flags := []string{"sources", "no-search", "save"}
+	flags := []string{"sources", "no-search", "save", "continue", "thread", "from-feed", "no-feed"}
 	for _, name := range flags {
 		if askCmd.Flags().Lookup(name) == nil {
 			t.Errorf("ask command missing flag %q", name)
@@ -195,7 +221,7 @@ func TestSubscribeCmd(t *testing.T) {
 	}
 
 	// Check flags
-	flags := []string{"hourly", "daily", "weekly", "sources"}
+	flags := []string{"hourly", "daily", "weekly", "sources", "cron", "feed", "enrich"}
 	for _, name := range flags {
 		if subscribeCmd.Flags().Lookup(name) == nil {
 			t.Errorf("subscribe command missing flag %q", name)
@@ -257,6 +283,202 @@ func TestTopicsCmd(t *testing.T) {
 	}
 }
 
+func TestHistoryCmd(t *testing.T) {
+	if historyCmd.Use != "history" {
+		t.Errorf("historyCmd.Use = %q, want %q", historyCmd.Use, "history")
+	}
+
+	subcommands := historyCmd.Commands()
+	expectedSubs := []string{"list", "show", "search", "delete", "replay"}
+
+	for _, expected := range expectedSubs {
+		found := false
+		for _, cmd := range subcommands {
+			if cmd.Name() == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected history subcommand %q not found", expected)
+		}
+	}
+
+	if historyDeleteCmd.Flags().Lookup("all") == nil {
+		t.Error("history delete command missing flag \"all\"")
+	}
+}
+
+func TestAskSaveDefault(t *testing.T) {
+	flag := askCmd.Flags().Lookup("save")
+	if flag == nil {
+		t.Fatal("ask command missing flag \"save\"")
+	}
+	if flag.DefValue != "true" {
+		t.Errorf("ask --save default = %q, want %q", flag.DefValue, "true")
+	}
+}
+
+func TestFollowUpSearchQuery(t *testing.T) {
+	if got := followUpSearchQuery(nil, "what is rust?"); got != "what is rust?" {
+		t.Errorf("followUpSearchQuery() without turns = %q, want question unchanged", got)
+	}
+
+	turns := []*models.Query{{Query: "what is WebGPU?"}}
+	if got := followUpSearchQuery(turns, "and Safari?"); got != "what is WebGPU? and Safari?" {
+		t.Errorf("followUpSearchQuery() = %q, want previous question prepended", got)
+	}
+}
+
+func TestParseChatCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		command string
+		arg     string
+		ok      bool
+	}{
+		{"/search rust async runtimes", "search", "rust async runtimes", true},
+		{"/sources", "sources", "", true},
+		{"/Provider  anthropic", "provider", "anthropic", true},
+		{"what is rust?", "", "", false},
+		{"/", "", "", false},
+	}
+
+	for _, tt := range tests {
+		command, arg, ok := parseChatCommand(tt.line)
+		if command != tt.command || arg != tt.arg || ok != tt.ok {
+			t.Errorf("parseChatCommand(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.line, command, arg, ok, tt.command, tt.arg, tt.ok)
+		}
+	}
+}
+
+func TestChatSessionCommands(t *testing.T) {
+	s := &chatSession{
+		turns:    []*models.Query{{Query: "q", Response: "a"}},
+		threadID: 4,
+		saved:    1,
+	}
+
+	if done := s.handleCommand("clear", ""); done {
+		t.Error("/clear should not end the session")
+	}
+	if len(s.turns) != 0 || s.threadID != 0 || s.saved != 0 {
+		t.Errorf("/clear should reset the conversation, got %+v", s)
+	}
+
+	if done := s.handleCommand("exit", ""); !done {
+		t.Error("/exit should end the session")
+	}
+}
+
+func TestBuildPromptCitesFeedItems(t *testing.T) {
+	sources := []search.SearchResult{
+		{Title: "Stored post", URL: "https://blog.example.com/a", Snippet: "From the feed", ItemID: 42},
+		{Title: "Web result", URL: "https://news.example.com/b", Snippet: "From the web"},
+	}
+
+	prompt := buildPrompt("what happened?", sources)
+
+	if !strings.Contains(prompt, "Source 1 (feed item #42): Stored post") {
+		t.Errorf("prompt should label feed items with their ID, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "Source 2: Web result") {
+		t.Errorf("prompt should list web results, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "[feed #42]") {
+		t.Errorf("prompt should explain how to cite feed items, got:\n%s", prompt)
+	}
+	if !strings.HasSuffix(prompt, "Question: what happened?") {
+		t.Errorf("prompt should end with the question, got:\n%s", prompt)
+	}
+}
+
+func TestBuildPromptPrefersContent(t *testing.T) {
+	sources := []search.SearchResult{
+		{Title: "Enriched", URL: "https://news.example.com/a", Snippet: "Short snippet", Content: "The full article text."},
+		{Title: "Snippet only", URL: "https://news.example.com/b", Snippet: "Only a snippet"},
+	}
+
+	prompt := buildPrompt("what happened?", sources)
+
+	if !strings.Contains(prompt, "Content: The full article text.") || strings.Contains(prompt, "Short snippet") {
+		t.Errorf("prompt should use the article instead of the snippet, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "Content: Only a snippet") {
+		t.Errorf("prompt should fall back to the snippet, got:\n%s", prompt)
+	}
+}
+
+func TestSourceLabel(t *testing.T) {
+	if got := sourceLabel(0, "https://www.example.com/post"); got != "example.com" {
+		t.Errorf("sourceLabel() = %q, want %q", got, "example.com")
+	}
+	if got := sourceLabel(7, "https://example.com/post"); got != "feed #7 · example.com" {
+		t.Errorf("sourceLabel() = %q, want %q", got, "feed #7 · example.com")
+	}
+}
+
+func TestFallbackNote(t *testing.T) {
+	primary := &llm.CompletionResponse{Provider: "anthropic", Model: "claude-sonnet-4-20250514"}
+
+	if got := fallbackNote(primary, &llm.CompletionResponse{Provider: "anthropic", Model: "claude-sonnet-4-20250514"}); got != "" {
+		t.Errorf("fallbackNote() = %q, want empty when the primary answered", got)
+	}
+	want := "Answered by openai (gpt-4o) instead of anthropic"
+	if got := fallbackNote(primary, &llm.CompletionResponse{Provider: "openai", Model: "gpt-4o"}); got != want {
+		t.Errorf("fallbackNote() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeSources(t *testing.T) {
+	feed := []search.SearchResult{{URL: "https://a.example/1", ItemID: 1}, {URL: "https://a.example/2", ItemID: 2}}
+	web := []search.SearchResult{{URL: "https://a.example/2"}, {URL: "https://b.example/1"}, {URL: "https://b.example/2"}}
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{3, []string{"https://a.example/1", "https://a.example/2", "https://b.example/1"}},
+		{10, []string{"https://a.example/1", "https://a.example/2", "https://b.example/1", "https://b.example/2"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, src := range mergeSources(feed, web, tt.limit) {
+			got = append(got, src.URL)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("mergeSources(limit %d) = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryID(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"12", 12, false},
+		{"#7", 7, false},
+		{"0", 0, true},
+		{"-3", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseQueryID(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseQueryID(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseQueryID(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestCommandDescriptions(t *testing.T) {
 	// All commands should have descriptions
 	for _, cmd := range rootCmd.Commands() {
@@ -295,3 +517,425 @@ func TestNoColorFlag(t *testing.T) {
 		t.Error("noColor flag should be settable to true")
 	}
 }
+
+func TestParseDateFlag(t *testing.T) {
+	now := time.Date(2024, 6, 15, 14, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"today", time.Date(2024, 6, 15, 0, 0, 0, 0, time.Local)},
+		{"yesterday", time.Date(2024, 6, 14, 0, 0, 0, 0, time.Local)},
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"36h", now.Add(-36 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDateFlag(tt.value, now)
+		if err != nil {
+			t.Errorf("parseDateFlag(%q) error = %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("parseDateFlag(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+
+	for _, bad := range []string{"", "soon", "7x", "06/01/2024"} {
+		if _, err := parseDateFlag(bad, now); err == nil {
+			t.Errorf("parseDateFlag(%q) should fail", bad)
+		}
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	ui.NoColor(true)
+
+	got := highlightSnippet("yields on " + db.HighlightStart + "tsmc" + db.HighlightEnd + " nodes")
+	if got != "yields on tsmc nodes" {
+		t.Errorf("highlightSnippet() = %q, want markers removed", got)
+	}
+
+	got = highlightSnippet("dangling " + db.HighlightStart + "marker")
+	if strings.Contains(got, db.HighlightStart) {
+		t.Errorf("highlightSnippet() = %q, should drop unmatched markers", got)
+	}
+}
+
+func TestDBCmdSubcommands(t *testing.T) {
+	for _, expected := range []string{"status", "migrate", "rollback"} {
+		found := false
+		for _, cmd := range dbCmd.Commands() {
+			if cmd.Name() == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected db subcommand %q not found", expected)
+		}
+	}
+
+	if !isDBCommand(dbRollbackCmd) || !isDBCommand(dbCmd) {
+		t.Error("db commands should skip automatic migrations")
+	}
+	if isDBCommand(askCmd) {
+		t.Error("ask should not be treated as a db command")
+	}
+}
+
+func TestFormatFrequency(t *testing.T) {
+	schedule := config.ScheduleConfig{DailyTime: "08:00", WeeklyDay: 1}
+
+	tests := []struct {
+		frequency string
+		cron      string
+		expected  string
+	}{
+		{"hourly", "", "Hourly"},
+		{"daily", "", "Daily at 08:00"},
+		{"weekly", "", "Weekly on Monday at 08:00"},
+		{"cron", "0 7,18 * * 1-5", "Cron 0 7,18 * * 1-5"},
+		{"custom", "", "custom"},
+	}
+
+	for _, tt := range tests {
+		sub := &models.Subscription{Frequency: tt.frequency, Cron: tt.cron}
+		got := formatFrequency(sub, schedule)
+		if got != tt.expected {
+			t.Errorf("formatFrequency(%q) = %q, want %q", tt.frequency, got, tt.expected)
+		}
+	}
+}
+
+func TestParseCronFlag(t *testing.T) {
+	if _, err := parseCronFlag("0 7,18 * * 1-5"); err != nil {
+		t.Errorf("parseCronFlag() error = %v", err)
+	}
+
+	for _, bad := range []string{"every morning", "0 25 * * *", "0 0 30 2 *"} {
+		if _, err := parseCronFlag(bad); err == nil {
+			t.Errorf("parseCronFlag(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseSourcesFlag(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Search.RSS.Named = map[string]string{"lobsters": "https://lobste.rs/rss"}
+	registry, err := newSourceRegistry(cfg)
+	if err != nil {
+		t.Fatalf("newSourceRegistry() error = %v", err)
+	}
+
+	got, err := parseSourcesFlag(" Tavily, lobsters,scrape,", registry)
+	if err != nil {
+		t.Fatalf("parseSourcesFlag() error = %v", err)
+	}
+	if want := "tavily,lobsters,scrape"; strings.Join(got, ",") != want {
+		t.Errorf("parseSourcesFlag() = %v, want %s", got, want)
+	}
+
+	for _, bad := range []string{"tavily,hackernews", " , ", "scrape", "scrape,scrape"} {
+		if _, err := parseSourcesFlag(bad, registry); err == nil {
+			t.Errorf("parseSourcesFlag(%q) should fail", bad)
+		}
+	}
+}
+
+func TestNewSourceRegistryRejectsTakenNames(t *testing.T) {
+	for _, name := range []string{"tavily", "RSS", "scrape"} {
+		cfg := &config.Config{}
+		cfg.Search.RSS.Named = map[string]string{name: "https://lobste.rs/rss"}
+		if _, err := newSourceRegistry(cfg); err == nil {
+			t.Errorf("newSourceRegistry() with a feed named %q should fail", name)
+		}
+	}
+}
+
+func TestParseFeedURLs(t *testing.T) {
+	got, err := parseFeedURLs([]string{" https://without.boats/index.xml", "http://example.com/feed"})
+	if err != nil {
+		t.Fatalf("parseFeedURLs() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != "https://without.boats/index.xml" {
+		t.Errorf("parseFeedURLs() = %v", got)
+	}
+
+	for _, bad := range []string{"without.boats/index.xml", "ftp://example.com/feed", "https://"} {
+		if _, err := parseFeedURLs([]string{bad}); err == nil {
+			t.Errorf("parseFeedURLs(%q) should fail", bad)
+		}
+	}
+}
+
+func TestUsesSource(t *testing.T) {
+	tests := []struct {
+		sources  []string
+		expected bool
+	}{
+		{nil, true},
+		{[]string{"tavily", "RSS"}, true},
+		{[]string{"tavily", "scrape"}, false},
+	}
+
+	for _, tt := range tests {
+		sub := &models.Subscription{Sources: tt.sources}
+		if got := usesSource(sub, "rss"); got != tt.expected {
+			t.Errorf("usesSource(%v, rss) = %v, want %v", tt.sources, got, tt.expected)
+		}
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	registry, err := newSourceRegistry(&config.Config{})
+	if err != nil {
+		t.Fatalf("newSourceRegistry() error = %v", err)
+	}
+
+	entries := []opml.Entry{
+		{Topic: "silicon-chips", Feeds: []string{"https://semianalysis.com/feed/", "https://chipsandcheese.com/feed/"}, Frequency: "weekly"},
+		{Topic: "Rust", Feeds: []string{"https://without.boats/index.xml", "not a url"}, Sources: []string{"tavily"}},
+		{Topic: "Rust", Feeds: []string{"https://without.boats/index.xml", "https://tokio.rs/blog/feed.xml"}},
+		{Topic: "export controls", Cron: "0 0 30 2 *", Sources: []string{"hackernews"}},
+	}
+
+	plans := planImport(entries, "daily", registry)
+	if len(plans) != 3 {
+		t.Fatalf("planImport() returned %d plans, want 3", len(plans))
+	}
+
+	chips := plans[0]
+	if chips.sub.Category != "silicon-chips" || chips.sub.Frequency != "weekly" {
+		t.Errorf("silicon-chips = %+v, want category and weekly frequency", chips.sub)
+	}
+	if len(chips.feeds) != 1 || chips.feeds[0] != "https://chipsandcheese.com/feed/" {
+		t.Errorf("silicon-chips feeds = %v, want only the non-default feed", chips.feeds)
+	}
+
+	rust := plans[1]
+	if len(rust.feeds) != 2 {
+		t.Errorf("Rust feeds = %v, want duplicates combined", rust.feeds)
+	}
+	if strings.Join(rust.sub.Sources, ",") != "tavily,rss" {
+		t.Errorf("Rust sources = %v, want rss added for its feeds", rust.sub.Sources)
+	}
+	if len(rust.warnings) != 1 {
+		t.Errorf("Rust warnings = %v, want one for the invalid URL", rust.warnings)
+	}
+
+	controls := plans[2]
+	if controls.sub.Frequency != "daily" || controls.sub.Cron != "" {
+		t.Errorf("export controls = %+v, want default frequency for a cron that never runs", controls.sub)
+	}
+	if strings.Join(controls.sub.Sources, ",") != "tavily,rss" || len(controls.warnings) != 2 {
+		t.Errorf("export controls sources = %v, warnings = %v", controls.sub.Sources, controls.warnings)
+	}
+}
+
+// openTestDB opens a fresh database for the test, closed when it ends.
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	if err := db.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+}
+
+func TestMergeFeeds(t *testing.T) {
+	openTestDB(t)
+
+	sub := &models.Subscription{Topic: "rust", Frequency: "daily", Sources: []string{"tavily"}, IsActive: true}
+	if err := db.CreateSubscriptionWithFeeds(sub, []string{"https://without.boats/index.xml"}); err != nil {
+		t.Fatalf("CreateSubscriptionWithFeeds() error = %v", err)
+	}
+
+	added, err := mergeFeeds(sub, []string{"https://without.boats/index.xml", "https://tokio.rs/blog/feed.xml"})
+	if err != nil {
+		t.Fatalf("mergeFeeds() error = %v", err)
+	}
+	if added != 1 {
+		t.Errorf("mergeFeeds() = %d, want 1 new feed", added)
+	}
+
+	got, err := db.GetSubscription("rust")
+	if err != nil {
+		t.Fatalf("GetSubscription() error = %v", err)
+	}
+	if strings.Join(got.Sources, ",") != "tavily,rss" {
+		t.Errorf("Sources = %v, want rss added for the merged feeds", got.Sources)
+	}
+	feeds, err := db.GetSubscriptionFeeds(sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscriptionFeeds() error = %v", err)
+	}
+	if len(feeds) != 2 {
+		t.Errorf("GetSubscriptionFeeds() returned %d feeds, want 2", len(feeds))
+	}
+}
+
+// brokenStream streams the first chunk of an answer and then fails.
+type brokenStream struct{}
+
+func (brokenStream) Name() string    { return "broken" }
+func (brokenStream) Model() string   { return "test" }
+func (brokenStream) Available() bool { return true }
+
+func (brokenStream) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (brokenStream) Stream(ctx context.Context, req llm.CompletionRequest) (<-chan llm.StreamChunk, error) {
+	chunks := make(chan llm.StreamChunk, 2)
+	chunks <- llm.StreamChunk{Content: "Rust 1.80 adds"}
+	chunks <- llm.StreamChunk{Error: errors.New("connection reset")}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestAnswerQuestionSavesIncomplete(t *testing.T) {
+	openTestDB(t)
+
+	err := answerQuestion(context.Background(), brokenStream{}, "what's new in rust?", nil, nil, nil)
+	if err == nil {
+		t.Fatal("answerQuestion() should fail when the stream does")
+	}
+
+	q, err := db.GetLatestQuery()
+	if err != nil {
+		t.Fatalf("GetLatestQuery() error = %v, want the partial answer saved", err)
+	}
+	if q.Query != "what's new in rust?" || q.Response != "Rust 1.80 adds" || !q.Incomplete {
+		t.Errorf("saved query = %+v, want the partial answer marked incomplete", q)
+	}
+}
+
+func TestFormatNextRun(t *testing.T) {
+	now := time.Date(2024, 6, 12, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		next     time.Time
+		expected string
+	}{
+		{now.Add(-time.Minute), "due now"},
+		{now, "due now"},
+		{now.Add(45 * time.Minute), "Wed 09:45 (in 45m)"},
+		{now.Add(23 * time.Hour), "Thu 08:00 (in 23h 0m)"},
+		{now.Add(7*24*time.Hour - time.Hour), "Wed Jun 19 08:00 (in 6d 23h)"},
+	}
+
+	for _, tt := range tests {
+		got := formatNextRun(tt.next, now)
+		if got != tt.expected {
+			t.Errorf("formatNextRun(%v) = %q, want %q", tt.next, got, tt.expected)
+		}
+	}
+}
+
+func TestChooseFeed(t *testing.T) {
+	feeds := []search.DiscoveredFeed{
+		{URL: "https://example.com/atom.xml", Title: "Atom", Type: "atom", Items: 3},
+		{URL: "https://example.com/feed", Title: "RSS", Type: "rss", Items: 1},
+	}
+
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"2\n", "https://example.com/feed"},
+		{"1\n", "https://example.com/atom.xml"},
+		{"\n", "https://example.com/atom.xml"},
+		{"", "https://example.com/atom.xml"},
+		{"7\n", "https://example.com/atom.xml"},
+		{"rss\n", "https://example.com/atom.xml"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got := chooseFeed(feeds, bufio.NewReader(strings.NewReader(tt.answer)), &out)
+		if got.URL != tt.want {
+			t.Errorf("chooseFeed(%q) = %q, want %q", tt.answer, got.URL, tt.want)
+		}
+		if !strings.Contains(out.String(), "https://example.com/feed") || !strings.Contains(out.String(), "[1-2, default 1]") {
+			t.Errorf("chooseFeed(%q) output = %q, want both feeds and the prompt", tt.answer, out.String())
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1024, "1 KB"},
+		{1536, "1.5 KB"},
+		{200 << 20, "200 MB"},
+		{3 << 30, "3 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	tests := []struct {
+		cost float64
+		want string
+	}{
+		{0, "$0.00"},
+		{0.00042, "$0.0004"},
+		{0.00001, "<$0.0001"},
+		{0.01, "$0.01"},
+		{12.345, "$12.35"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCost(tt.cost); got != tt.want {
+			t.Errorf("formatCost(%v) = %q, want %q", tt.cost, got, tt.want)
+		}
+	}
+}
+
+func TestUsagePrices(t *testing.T) {
+	cfg := &config.Config{Usage: config.UsageConfig{Prices: []config.PriceConfig{
+		{Model: "gpt-4o", Prompt: 1, Completion: 1},
+		{Model: "llama3", Prompt: 0.1, Completion: 0.1},
+	}}}
+	prices := usagePrices(cfg)
+
+	if got, _ := prices.Lookup("gpt-4o-2024-08-06"); got.Prompt != 1 {
+		t.Errorf("Lookup(gpt-4o) = %+v, want the configured price", got)
+	}
+	if got, _ := prices.Lookup("gpt-4o-mini"); got.Prompt != 0.15 {
+		t.Errorf("Lookup(gpt-4o-mini) = %+v, want the built-in price", got)
+	}
+	if _, ok := prices.Lookup("llama3:8b"); !ok {
+		t.Error("Lookup(llama3:8b) found no price, want the configured one")
+	}
+}
+
+func TestFormatBudget(t *testing.T) {
+	tests := []struct {
+		budget models.Budget
+		want   string
+	}{
+		{models.Budget{}, "none"},
+		{models.Budget{DailyCost: 0.25}, "$0.25/day"},
+		{models.Budget{DailyCost: 1, MonthlyTokens: 2000000}, "$1.00/day, 2000000 tokens/month"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBudget(tt.budget); got != tt.want {
+			t.Errorf("formatBudget(%+v) = %q, want %q", tt.budget, got, tt.want)
+		}
+	}
+}
@@ -104,6 +104,7 @@ func showConfig(cmd *cobra.Command, args []string) {
 	fmt.Println(ui.BoldStyle.Render(" Providers"))
 	printProviderStatus("OpenAI", cfg.Providers.OpenAI.APIKey != "", cfg.Providers.OpenAI.Model)
 	printProviderStatus("Anthropic", cfg.Providers.Anthropic.APIKey != "", cfg.Providers.Anthropic.Model)
+	printProviderStatus("Gemini", cfg.Providers.Gemini.APIKey != "", cfg.Providers.Gemini.Model)
 	printProviderStatus("Local", cfg.Providers.Local.BaseURL != "", cfg.Providers.Local.Model)
 	fmt.Println()
 
@@ -163,6 +164,15 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 		fmt.Print(ui.Success("Anthropic configured"))
 	}
 
+	// Gemini API key
+	fmt.Print("Enter your Gemini API key (or press Enter to skip): ")
+	geminiKey, _ := reader.ReadString('\n')
+	geminiKey = strings.TrimSpace(geminiKey)
+	if geminiKey != "" {
+		config.Set("providers.gemini.api_key", geminiKey)
+		fmt.Print(ui.Success("Gemini configured"))
+	}
+
 	// Tavily API key
 	fmt.Print("Enter your Tavily API key (or press Enter to skip): ")
 	tavilyKey, _ := reader.ReadString('\n')
@@ -177,6 +187,8 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 		config.Set("general.default_provider", "openai")
 	} else if anthropicKey != "" {
 		config.Set("general.default_provider", "anthropic")
+	} else if geminiKey != "" {
+		config.Set("general.default_provider", "gemini")
 	}
 
 	// Save config
@@ -186,7 +198,7 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	if err := writeDefaultConfig(configPath, openaiKey, anthropicKey, tavilyKey); err != nil {
+	if err := writeDefaultConfig(configPath, openaiKey, anthropicKey, geminiKey, tavilyKey); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -196,11 +208,11 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func writeDefaultConfig(path, openaiKey, anthropicKey, tavilyKey string) error {
+func writeDefaultConfig(path, openaiKey, anthropicKey, geminiKey, tavilyKey string) error {
 	content := fmt.Sprintf(`# Termflow Configuration
 
 [general]
-# Default LLM provider: "openai", "anthropic", "local"
+# Default LLM provider: "openai", "anthropic", "gemini", "local"
 default_provider = "%s"
 
 # Output style: "pretty", "minimal", "plain"
@@ -212,53 +224,123 @@ cache_dir = "~/.cache/termiflow"
 # How many feed items to show by default
 feed_limit = 20
 
+[providers]
+# Providers to try in order when one is down or not configured, such as
+# ["anthropic", "openai", "local"]; empty uses default_provider alone
+chain = []
+
 [providers.openai]
 api_key = "%s"
 model = "gpt-4o"
 base_url = "https://api.openai.com/v1"
+requests_per_minute = 500   # 0 = unlimited
 
 [providers.anthropic]
 api_key = "%s"
 model = "claude-sonnet-4-20250514"
+requests_per_minute = 50
+
+[providers.gemini]
+api_key = "%s"
+model = "gemini-2.5-flash"
+base_url = "https://generativelanguage.googleapis.com/v1beta"
+requests_per_minute = 60
 
 [providers.local]
 # OpenAI-compatible local server (Ollama, llama.cpp, LM Studio, etc.)
 base_url = "http://localhost:11434/v1"
 model = "llama3"
 
+[providers.retry]
+# Rate limits, overloaded servers and dropped connections are retried with
+# exponential backoff, or after the wait the provider asks for
+max_attempts = 4         # including the first; 1 = no retries
+base_delay_ms = 1000     # doubles after each retry
+max_delay_ms = 30000     # longer Retry-After waits fail the call instead
+
 [search.tavily]
 api_key = "%s"
+requests_per_minute = 100
 
 [search.rss]
-# Global RSS feeds to include
+# Feeds read for every subscription; only items mentioning its topic are kept
 feeds = []
 
+[search.rss.named]
+# Feeds a subscription can select by name: termiflow subscribe rust --sources tavily,lobsters
+# lobsters = "https://lobste.rs/rss"
+
 [search.scraper]
-user_agent = "termiflow/1.0"
+user_agent = "termiflow/1.0"   # also the name matched against robots.txt rules
 timeout = 30
-respect_robots = true
+respect_robots = true          # skip pages robots.txt disallows and honor Crawl-delay
+domain_delay_ms = 500          # least time between requests to one site, for pages and feeds
+
+[search.enrich]
+# Fetch the full article behind search results before they're summarized.
+# Subscriptions opt in with the scrape source; ask and chat use enabled.
+enabled = false
+concurrency = 4      # pages fetched at once
+per_domain = 2       # pages fetched at once from the same site
+timeout = 10         # seconds per page
+max_content = 4000   # bytes of article text kept per result
 
 [schedule]
 default_frequency = "daily"
-daily_time = "08:00"
-weekly_day = 1
+daily_time = "08:00"   # local time for daily and weekly refreshes
+weekly_day = 1         # 0 = Sunday ... 6 = Saturday
+
+[refresh]
+concurrency = 3             # subscriptions refreshed in parallel
+curation_concurrency = 4    # search results curated in parallel per subscription
+curation_batch_size = 10    # results curated per LLM request, 1 = one at a time
+
+[cache]
+enabled = true        # keep searches, pages and feeds in cache_dir
+max_size_mb = 200     # least recently used entries go first
+search_ttl = 60       # minutes before a search is repeated
+page_ttl = 1440       # minutes before a page is fetched again
+feed_ttl = 15         # minutes before a feed is fetched again
+
+# Every LLM call is recorded for 'termiflow usage'. Costs use built-in list
+# prices; add a price (US dollars per million tokens) for other models or
+# to override one. Models match by prefix.
+# [[usage.prices]]
+# model = "gpt-4o"
+# prompt = 2.50
+# completion = 10.00
+
+[usage.budget]
+# Caps on what LLM calls may spend per day and per calendar month, in US
+# dollars or tokens; 0 = no limit. Subscriptions can add their own with
+# 'termiflow subscribe <topic> --daily-cost ...'
+daily_cost = 0.0
+monthly_cost = 0.0
+daily_tokens = 0
+monthly_tokens = 0
+on_exceed = "skip_summaries"  # or "cheaper_model" or "stop"
+cheap_model = ""              # e.g. "gpt-4o-mini", for cheaper_model
 `,
-		getDefaultProvider(openaiKey, anthropicKey),
+		getDefaultProvider(openaiKey, anthropicKey, geminiKey),
 		openaiKey,
 		anthropicKey,
+		geminiKey,
 		tavilyKey,
 	)
 
 	return os.WriteFile(path, []byte(content), 0600)
 }
 
-func getDefaultProvider(openaiKey, anthropicKey string) string {
+func getDefaultProvider(openaiKey, anthropicKey, geminiKey string) string {
 	if openaiKey != "" {
 		return "openai"
 	}
 	if anthropicKey != "" {
 		return "anthropic"
 	}
+	if geminiKey != "" {
+		return "gemini"
+	}
 	return "openai"
 }
 
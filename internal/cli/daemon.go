@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/daemon"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+)
+
+var daemonInterval time.Duration
+var daemonLogFile string
+var daemonOnce bool
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Refresh subscriptions in the background",
+	Long: `Refresh subscriptions in the background.
+
+The daemon checks every --interval for subscriptions that are due and
+refreshes them, so your feed is up to date when you open it. It runs in the
+foreground until interrupted; use your service manager, tmux or
+docker compose to keep it running. Only one daemon can run at a time.
+
+Examples:
+  termiflow daemon                          # Check every 5 minutes
+  termiflow daemon --interval 15m
+  termiflow daemon --log-file ~/termiflow.log
+  termiflow daemon --once                   # Single pass, e.g. from cron
+  termiflow daemon status
+  termiflow daemon stop`,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running",
+	RunE:  runDaemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	RunE:  runDaemonStop,
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 5*time.Minute, "how often to check for due subscriptions")
+	daemonCmd.Flags().StringVar(&daemonLogFile, "log-file", "", "append logs to this file instead of stdout")
+	daemonCmd.Flags().BoolVar(&daemonOnce, "once", false, "run a single refresh pass and exit")
+
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+}
+
+func daemonPIDPath() string {
+	return filepath.Join(config.GetDataDir(), "daemon.pid")
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	if daemonInterval < time.Minute {
+		return fmt.Errorf("--interval must be at least 1m")
+	}
+
+	sched, err := newScheduler(config.Get(), false)
+	if err != nil {
+		return err
+	}
+
+	lock, err := daemon.AcquireLock(daemonPIDPath())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	var out io.Writer = os.Stdout
+	if daemonLogFile != "" {
+		f, err := os.OpenFile(daemonLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := &daemon.Daemon{
+		Interval: daemonInterval,
+		Refresh:  sched.RefreshAllSubscriptions,
+		Logger:   log.New(out, "termiflow: ", log.LstdFlags),
+	}
+
+	if daemonOnce {
+		d.RunOnce(ctx)
+		return nil
+	}
+
+	return d.Run(ctx)
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	pid, err := daemon.ReadPID(daemonPIDPath())
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Print(ui.Warning("Daemon is not running"))
+		fmt.Print(ui.Tip(fmt.Sprintf("Start it with %s", ui.TitleStyle.Render("termiflow daemon"))))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(ui.Success(fmt.Sprintf("Daemon is running (pid %d)", pid)))
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	pid, err := daemon.ReadPID(daemonPIDPath())
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Print(ui.Warning("Daemon is not running"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := daemon.Stop(pid); err != nil {
+		return fmt.Errorf("failed to stop daemon (pid %d): %w", pid, err)
+	}
+
+	fmt.Print(ui.Success(fmt.Sprintf("Sent stop signal to daemon (pid %d)", pid)))
+	return nil
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+)
+
+var dbRollbackTo int
+var dbRollbackForce bool
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Inspect and manage the database schema",
+	Long: `Inspect and manage the database schema.
+
+Pending migrations are applied automatically whenever termiflow opens the
+database. These commands exist for checking the schema and for downgrading
+before switching to an older termiflow release.
+
+Examples:
+  termiflow db status          # Show applied and pending migrations
+  termiflow db migrate         # Apply pending migrations
+  termiflow db rollback        # Revert the most recent migration
+  termiflow db rollback --to 1 # Revert to the initial schema`,
+	RunE: runDBStatus,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	RunE:  runDBStatus,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending migrations",
+	RunE:  runDBMigrate,
+}
+
+var dbRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Revert applied migrations",
+	RunE:  runDBRollback,
+}
+
+func init() {
+	dbRollbackCmd.Flags().IntVar(&dbRollbackTo, "to", -1, "schema version to roll back to (default: one step)")
+	dbRollbackCmd.Flags().BoolVar(&dbRollbackForce, "force", false, "skip confirmation prompt")
+
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbRollbackCmd)
+}
+
+func isDBCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == dbCmd {
+			return true
+		}
+	}
+	return false
+}
+
+func connectDB() error {
+	if err := config.EnsureDirectories(); err != nil {
+		return err
+	}
+	if err := db.Connect(db.Path()); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	return nil
+}
+
+func runDBStatus(cmd *cobra.Command, args []string) error {
+	if err := connectDB(); err != nil {
+		return err
+	}
+
+	statuses, err := db.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Header("termiflow db"))
+	fmt.Println()
+	fmt.Print(ui.Info("Database", db.Path()))
+	fmt.Print(ui.Info("Schema", fmt.Sprintf("version %d (latest %d)", version, db.LatestVersion())))
+	fmt.Println()
+
+	pending := 0
+	for _, st := range statuses {
+		if st.AppliedAt != nil {
+			fmt.Printf("   %s %03d %-28s %s\n",
+				ui.SuccessStyle.Render("✓"),
+				st.Version,
+				st.Name,
+				ui.MutedStyle.Render("applied "+st.AppliedAt.Local().Format("Jan 2, 2006 · 15:04")),
+			)
+		} else {
+			pending++
+			fmt.Printf("   %s %03d %-28s %s\n",
+				ui.MutedStyle.Render("○"),
+				st.Version,
+				st.Name,
+				ui.WarningStyle.Render("pending"),
+			)
+		}
+	}
+
+	if version > db.LatestVersion() {
+		fmt.Print(ui.Warning("The database was migrated by a newer termiflow release"))
+	} else if pending > 0 {
+		fmt.Print(ui.Tip(fmt.Sprintf("Apply pending migrations with %s", ui.TitleStyle.Render("termiflow db migrate"))))
+	}
+	fmt.Println()
+	return nil
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) error {
+	if err := connectDB(); err != nil {
+		return err
+	}
+
+	applied, err := db.Migrate()
+	for _, m := range applied {
+		fmt.Print(ui.Success(fmt.Sprintf("Applied %03d %s", m.Version, m.Name)))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Print(ui.Success(fmt.Sprintf("Schema is up to date (version %d)", db.LatestVersion())))
+	}
+	return nil
+}
+
+func runDBRollback(cmd *cobra.Command, args []string) error {
+	if err := connectDB(); err != nil {
+		return err
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	target := dbRollbackTo
+	if target < 0 {
+		target = version - 1
+	}
+
+	if version == 0 || target >= version {
+		fmt.Print(ui.Warning(fmt.Sprintf("Nothing to roll back (schema is at version %d)", version)))
+		return nil
+	}
+
+	if !dbRollbackForce {
+		prompt := fmt.Sprintf("Roll back the schema from version %d to %d?", version, target)
+		if target == 0 {
+			prompt = "Rolling back to version 0 deletes all termiflow data. Continue?"
+		}
+		fmt.Printf("%s [y/N] ", prompt)
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Canceled.")
+			return nil
+		}
+	}
+
+	reverted, err := db.Rollback(target)
+	for _, m := range reverted {
+		fmt.Print(ui.Success(fmt.Sprintf("Reverted %03d %s", m.Version, m.Name)))
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(ui.Tip("Any other termiflow command will migrate the schema forward again"))
+	return nil
+}
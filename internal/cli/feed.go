@@ -3,12 +3,17 @@ package cli
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/oluoyefeso/termiflow/internal/config"
 	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/httpcache"
 	"github.com/oluoyefeso/termiflow/internal/providers/llm"
 	"github.com/oluoyefeso/termiflow/internal/providers/search"
 	"github.com/oluoyefeso/termiflow/internal/scheduler"
@@ -24,6 +29,7 @@ var feedRefresh bool
 var feedAll bool
 var feedMarkRead bool
 var feedCleanup bool
+var feedEnrich bool
 
 var feedCmd = &cobra.Command{
 	Use:   "feed",
@@ -35,7 +41,8 @@ Examples:
   termiflow feed --topic silicon-chips     # Filter by topic
   termiflow feed --today                   # Today's items only
   termiflow feed --limit 10                # Limit number of items
-  termiflow feed --refresh                 # Fetch new items first`,
+  termiflow feed --refresh                 # Fetch new items first
+  termiflow feed --refresh --enrich        # Read full articles for every topic`,
 	RunE: runFeed,
 }
 
@@ -48,6 +55,7 @@ func init() {
 	feedCmd.Flags().BoolVar(&feedAll, "all", false, "include already-read items")
 	feedCmd.Flags().BoolVar(&feedMarkRead, "mark-read", true, "mark displayed items as read")
 	feedCmd.Flags().BoolVar(&feedCleanup, "cleanup", false, "remove items older than 30 days")
+	feedCmd.Flags().BoolVar(&feedEnrich, "enrich", false, "with --refresh, fetch the full article behind every result, not only for subscriptions using the scrape source")
 }
 
 func runFeed(cmd *cobra.Command, args []string) error {
@@ -224,47 +232,209 @@ func refreshFeeds(cfg *config.Config, topicFilter string) error {
 		}
 	}
 
+	sched, err := newScheduler(cfg, feedEnrich)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(subs))
+	index := make(map[int64]int, len(subs))
+	for i, sub := range subs {
+		names[i] = sub.Topic
+		index[sub.ID] = i
+	}
+
+	progress := ui.NewProgress(names)
+	progress.Start()
+
+	results := sched.RefreshSubscriptions(context.Background(), subs, func(sub *models.Subscription, result *scheduler.RefreshResult) {
+		i := index[sub.ID]
+		switch {
+		case result == nil:
+			progress.Begin(i)
+		case result.Err != nil:
+			progress.Fail(i, result.Err.Error())
+		default:
+			progress.Done(i, fmt.Sprintf("%d new item(s) %s", result.NewItems, ui.MutedStyle.Render(fmt.Sprintf("(%.1fs)", result.Duration.Seconds()))))
+		}
+	})
+
+	progress.Stop()
+
+	totalNewItems := 0
+	failed := 0
+	var throttled []*scheduler.RefreshResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			continue
+		}
+		totalNewItems += r.NewItems
+		// Stopped refreshes already said why they failed
+		if r.Throttle != nil {
+			throttled = append(throttled, r)
+		}
+	}
+
+	if len(throttled) > 0 {
+		fmt.Println()
+		for _, r := range throttled {
+			fmt.Print(ui.Warning(fmt.Sprintf("%s: %s", r.Subscription.Topic, r.Throttle)))
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case failed > 0:
+		fmt.Print(ui.Warning(fmt.Sprintf("Fetched %d new item(s), %d subscription(s) failed", totalNewItems, failed)))
+	case totalNewItems > 0:
+		fmt.Print(ui.Success(fmt.Sprintf("Fetched %d new item(s)", totalNewItems)))
+	default:
+		fmt.Print(ui.Success("No new items found"))
+	}
+
+	return nil
+}
+
+// newScheduler wires the configured LLM and search providers into a scheduler.
+// With enrichAll every subscription's results are enriched with their full
+// articles; otherwise only those of subscriptions using the scrape source.
+func newScheduler(cfg *config.Config, enrichAll bool) (*scheduler.Scheduler, error) {
 	// Initialize LLM provider
-	providerName := getProvider()
-	llmProvider, err := llm.GetProvider(providerName, cfg)
+	providerNames := getProviders()
+	llmProvider, err := llm.GetChain(providerNames, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM provider: %w", err)
+		return nil, fmt.Errorf("failed to initialize LLM provider: %w", err)
 	}
 
 	if !llmProvider.Available() {
-		return fmt.Errorf("LLM provider '%s' not configured - run 'termiflow config init'", providerName)
+		return nil, fmt.Errorf("LLM provider '%s' not configured - run 'termiflow config init'", strings.Join(providerNames, "', '"))
 	}
 
-	// Initialize search provider (Tavily)
-	var searchProvider search.Provider
-	if cfg.Search.Tavily.APIKey != "" {
-		searchProvider = search.NewTavilyProvider(cfg.Search.Tavily.APIKey)
+	schedule, err := scheduler.NewSchedule(cfg.Schedule)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create scheduler
-	sched := scheduler.New(llmProvider, searchProvider)
+	budget, err := newBudget(cfg, providerNames)
+	if err != nil {
+		return nil, err
+	}
 
-	// Show spinner
-	sp := ui.NewSpinner(fmt.Sprintf("Fetching updates for %d subscription(s)...", len(subs)))
-	sp.Start()
+	sources, err := newSourceRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
-	totalNewItems := 0
+	return scheduler.New(trackUsage(llmProvider, cfg), sources, scheduler.Options{
+		Schedule:            schedule,
+		Concurrency:         cfg.Refresh.Concurrency,
+		CurationConcurrency: cfg.Refresh.CurationConcurrency,
+		CurationBatchSize:   cfg.Refresh.CurationBatchSize,
+		Enricher:            newEnricher(cfg),
+		EnrichAll:           enrichAll,
+		Budget:              budget,
+	}), nil
+}
 
-	for _, sub := range subs {
-		items, err := sched.RefreshSubscription(ctx, sub)
-		if err != nil {
-			// Log error but continue with other subscriptions
-			continue
+func newScraper(cfg *config.Config) *search.Scraper {
+	scraper := search.NewScraper(cfg.Search.Scraper.UserAgent, cfg.Search.Scraper.Timeout, sharedPoliteness(cfg))
+	scraper.UseCache(sharedCache(cfg), cachePolicy(httpcache.Pages, cfg.Cache.PageTTL))
+	return scraper
+}
+
+func newTavily(cfg *config.Config) *search.TavilyProvider {
+	tavily := search.NewTavilyProvider(cfg.Search.Tavily.APIKey)
+	tavily.UseCache(sharedCache(cfg), cachePolicy(httpcache.Search, cfg.Cache.SearchTTL))
+	return tavily
+}
+
+func newRSSProvider(cfg *config.Config) *search.RSSProvider {
+	rss := search.NewRSSProvider(cfg.Search.Scraper.UserAgent, cfg.Search.Scraper.Timeout, sharedPoliteness(cfg))
+	rss.UseCache(sharedCache(cfg), cachePolicy(httpcache.Feeds, cfg.Cache.FeedTTL))
+	return rss
+}
+
+var responseCache struct {
+	once sync.Once
+	c    *httpcache.Cache
+}
+
+// sharedCache returns the response cache used by every search, scraper and
+// feed reader in the process, or nil when caching is turned off.
+func sharedCache(cfg *config.Config) *httpcache.Cache {
+	responseCache.once.Do(func() {
+		if cfg.Cache.Enabled {
+			responseCache.c = openCache(cfg)
 		}
-		totalNewItems += len(items)
+	})
+	return responseCache.c
+}
+
+// openCache opens the response cache under general.cache_dir.
+func openCache(cfg *config.Config) *httpcache.Cache {
+	return httpcache.Open(filepath.Join(config.GetCacheDir(), "http"), int64(cfg.Cache.MaxSizeMB)<<20)
+}
+
+// cachePolicy keeps responses for ttl minutes, or serves only cached ones
+// under --offline.
+func cachePolicy(namespace string, ttl int) httpcache.Policy {
+	return httpcache.Policy{
+		Namespace: namespace,
+		TTL:       time.Duration(ttl) * time.Minute,
+		Offline:   offline,
 	}
+}
 
-	if totalNewItems > 0 {
-		sp.Success(fmt.Sprintf("Fetched %d new item(s)", totalNewItems))
-	} else {
-		sp.Success("No new items found")
+var politeness struct {
+	once sync.Once
+	p    *search.Politeness
+}
+
+// sharedPoliteness returns the robots.txt checks and per-site spacing used by
+// every scraper and feed reader in the process, so requests to a site stay
+// spaced out across all of them.
+func sharedPoliteness(cfg *config.Config) *search.Politeness {
+	politeness.once.Do(func() {
+		sc := cfg.Search.Scraper
+		politeness.p = search.NewPoliteness(sc.UserAgent, sc.Timeout, sc.RespectRobots, time.Duration(sc.DomainDelayMs)*time.Millisecond)
+	})
+	return politeness.p
+}
+
+func newEnricher(cfg *config.Config) *search.Enricher {
+	return search.NewEnricher(newScraper(cfg), search.EnrichOptions{
+		Concurrency: cfg.Search.Enrich.Concurrency,
+		PerDomain:   cfg.Search.Enrich.PerDomain,
+		Timeout:     time.Duration(cfg.Search.Enrich.Timeout) * time.Second,
+		MaxContent:  cfg.Search.Enrich.MaxContent,
+	})
+}
+
+// newSourceRegistry registers the sources a subscription can select: Tavily
+// search, its RSS feeds and every feed named in search.rss.named. A named
+// feed can't take the name of another source.
+func newSourceRegistry(cfg *config.Config) (*scheduler.SourceRegistry, error) {
+	rss := newRSSProvider(cfg)
+	sources := scheduler.NewSourceRegistry()
+
+	if err := sources.Register(scheduler.NewSearchSource(search.WithRateLimit(newTavily(cfg), cfg.Search.Tavily.RequestsPerMinute))); err != nil {
+		return nil, err
+	}
+	if err := sources.Register(scheduler.NewRSSSource(rss, cfg.Search.RSS.Feeds)); err != nil {
+		return nil, err
 	}
 
-	return nil
+	names := make([]string, 0, len(cfg.Search.RSS.Named))
+	for name := range cfg.Search.RSS.Named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := sources.Register(scheduler.NewFeedSource(name, cfg.Search.RSS.Named[name], rss)); err != nil {
+			return nil, fmt.Errorf("invalid search.rss.named: %w", err)
+		}
+	}
+
+	return sources, nil
 }
@@ -0,0 +1,383 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+var feedsCmd = &cobra.Command{
+	Use:   "feeds",
+	Short: "Manage the RSS and Atom feeds of your subscriptions",
+	Long: `Manage the RSS and Atom feeds of your subscriptions.
+
+Feeds added to a subscription are read by its rss source on every refresh,
+alongside the feeds of its category. Global feeds in search.rss.feeds are read
+for every subscription, keeping only the items that mention its topic.
+
+A website's address works in place of a feed URL: termiflow looks for the
+feeds the site links to and at common paths like /feed and /rss.xml, and asks
+which one to use when it finds several.
+
+Examples:
+  termiflow feeds                                           # List all feeds
+  termiflow feeds list "rust async ecosystem"               # One subscription's feeds
+  termiflow feeds add "rust async ecosystem" https://without.boats/index.xml
+  termiflow feeds add "rust async ecosystem" https://without.boats
+  termiflow feeds discover https://blog.rust-lang.org
+  termiflow feeds remove "rust async ecosystem" https://without.boats/index.xml`,
+	RunE: runFeedsList,
+}
+
+var feedsListCmd = &cobra.Command{
+	Use:   "list [topic]",
+	Short: "List feeds, optionally for one subscription",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runFeedsList,
+}
+
+var feedsAddCmd = &cobra.Command{
+	Use:   "add <topic> <url>...",
+	Short: "Add feeds to a subscription",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runFeedsAdd,
+}
+
+var feedsRemoveCmd = &cobra.Command{
+	Use:   "remove <topic> <url>...",
+	Short: "Remove feeds from a subscription",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runFeedsRemove,
+}
+
+var feedsDiscoverCmd = &cobra.Command{
+	Use:   "discover <url>",
+	Short: "Find the feeds a website publishes",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runFeedsDiscover,
+}
+
+func init() {
+	feedsCmd.AddCommand(feedsListCmd)
+	feedsCmd.AddCommand(feedsAddCmd)
+	feedsCmd.AddCommand(feedsRemoveCmd)
+	feedsCmd.AddCommand(feedsDiscoverCmd)
+}
+
+func runFeedsList(cmd *cobra.Command, args []string) error {
+	var subs []*models.Subscription
+	if len(args) == 1 {
+		sub, err := findSubscription(args[0])
+		if err != nil {
+			return err
+		}
+		subs = []*models.Subscription{sub}
+	} else {
+		var err error
+		subs, err = db.GetAllSubscriptions()
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(ui.Header("termiflow feeds"))
+	fmt.Println()
+
+	listed := 0
+	for _, sub := range subs {
+		feeds, err := db.GetSubscriptionFeeds(sub.ID)
+		if err != nil {
+			return err
+		}
+
+		var urls []string
+		if category := models.GetCategoryByName(sub.Topic); category != nil {
+			urls = append(urls, category.DefaultRSS...)
+		}
+		defaults := len(urls)
+		for _, feed := range feeds {
+			urls = append(urls, feed.URL)
+		}
+
+		if len(urls) == 0 && len(args) == 0 {
+			continue
+		}
+
+		fmt.Printf("   %s\n", ui.BoldStyle.Render(sub.Topic))
+		if len(urls) == 0 {
+			fmt.Println(ui.MutedStyle.Render("      No feeds yet."))
+		}
+		for i, u := range urls {
+			note := ""
+			if i < defaults {
+				note = ui.MutedStyle.Render(" (category default)")
+			}
+			fmt.Printf("      %s%s\n", u, note)
+		}
+		if len(feeds) > 0 && !usesSource(sub, "rss") {
+			fmt.Println(ui.WarningStyle.Render("      Not refreshed: the rss source isn't selected for this subscription."))
+		}
+		fmt.Println()
+		listed++
+	}
+
+	if len(args) == 0 {
+		listed += printConfiguredFeeds(config.Get())
+	}
+
+	if listed == 0 {
+		fmt.Println(ui.MutedStyle.Render("   No feeds yet."))
+		fmt.Printf("\n   Add one with %s\n\n", ui.TitleStyle.Render("termiflow feeds add <topic> <url>"))
+	}
+
+	return nil
+}
+
+// printConfiguredFeeds lists the global and named feeds from the config file
+// and returns how many sections it printed.
+func printConfiguredFeeds(cfg *config.Config) int {
+	printed := 0
+
+	if len(cfg.Search.RSS.Feeds) > 0 {
+		fmt.Printf("   %s\n", ui.BoldStyle.Render("All subscriptions"))
+		fmt.Println(ui.MutedStyle.Render("      search.rss.feeds, filtered by topic"))
+		for _, u := range cfg.Search.RSS.Feeds {
+			fmt.Printf("      %s\n", u)
+		}
+		fmt.Println()
+		printed++
+	}
+
+	if len(cfg.Search.RSS.Named) > 0 {
+		names := make([]string, 0, len(cfg.Search.RSS.Named))
+		for name := range cfg.Search.RSS.Named {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("   %s\n", ui.BoldStyle.Render("Named sources"))
+		fmt.Println(ui.MutedStyle.Render("      search.rss.named, selected with --sources"))
+		for _, name := range names {
+			fmt.Printf("      %-16s %s\n", name, cfg.Search.RSS.Named[name])
+		}
+		fmt.Println()
+		printed++
+	}
+
+	return printed
+}
+
+func runFeedsAdd(cmd *cobra.Command, args []string) error {
+	sub, err := findSubscription(args[0])
+	if err != nil {
+		return err
+	}
+
+	urls, err := parseFeedURLs(args[1:])
+	if err != nil {
+		return err
+	}
+
+	urls, err = resolveFeedURLs(context.Background(), urls)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range urls {
+		err := db.AddSubscriptionFeed(&models.SubscriptionFeed{SubscriptionID: sub.ID, URL: u})
+		switch {
+		case errors.Is(err, db.ErrFeedExists):
+			fmt.Print(ui.Warning(fmt.Sprintf("%s already has %s", sub.Topic, u)))
+		case err != nil:
+			return fmt.Errorf("failed to add feed: %w", err)
+		default:
+			fmt.Print(ui.Success(fmt.Sprintf("Added %s to %s", u, sub.Topic)))
+		}
+	}
+
+	if !usesSource(sub, "rss") {
+		fmt.Print(ui.Tip(fmt.Sprintf("%s doesn't use the rss source, so its feeds aren't refreshed. Resubscribe with --sources including rss.", sub.Topic)))
+	}
+
+	return nil
+}
+
+func runFeedsRemove(cmd *cobra.Command, args []string) error {
+	sub, err := findSubscription(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, u := range args[1:] {
+		removed, err := db.RemoveSubscriptionFeed(sub.ID, strings.TrimSpace(u))
+		if err != nil {
+			return fmt.Errorf("failed to remove feed: %w", err)
+		}
+
+		if removed {
+			fmt.Print(ui.Success(fmt.Sprintf("Removed %s from %s", u, sub.Topic)))
+		} else {
+			fmt.Print(ui.Warning(fmt.Sprintf("%s has no feed %s", sub.Topic, u)))
+		}
+	}
+
+	return nil
+}
+
+func runFeedsDiscover(cmd *cobra.Command, args []string) error {
+	urls, err := parseFeedURLs(args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Header("termiflow feeds discover"))
+	fmt.Println()
+
+	feeds, err := newScraper(config.Get()).DiscoverFeeds(context.Background(), urls[0])
+	if err != nil {
+		return fmt.Errorf("couldn't look for feeds: %w", err)
+	}
+
+	if len(feeds) == 0 {
+		fmt.Println(ui.MutedStyle.Render("   No feeds found at " + urls[0]))
+		fmt.Println()
+		return nil
+	}
+
+	for i, feed := range feeds {
+		printDiscoveredFeed(os.Stdout, i+1, feed)
+	}
+
+	fmt.Print(ui.Tip("Add one with: termiflow feeds add <topic> <url>"))
+	fmt.Println()
+	return nil
+}
+
+// resolveFeedURLs replaces website addresses with the feeds they publish.
+// Feed URLs are kept as they are. When a site has several feeds the user picks
+// one.
+func resolveFeedURLs(ctx context.Context, urls []string) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	scraper := newScraper(config.Get())
+	stdin := bufio.NewReader(os.Stdin)
+
+	resolved := make([]string, 0, len(urls))
+	for _, u := range urls {
+		feeds, err := scraper.DiscoverFeeds(ctx, u)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't look for feeds: %w", err)
+		}
+
+		var feed search.DiscoveredFeed
+		switch {
+		case len(feeds) == 0:
+			return nil, fmt.Errorf("no RSS or Atom feed found at %s", u)
+		case len(feeds) == 1:
+			feed = feeds[0]
+			if feed.URL != u {
+				fmt.Print(ui.Info("Found", fmt.Sprintf("%s %s", feed.URL, ui.MutedStyle.Render(describeDiscoveredFeed(feed)))))
+			}
+		default:
+			fmt.Printf("   Found %d feeds at %s:\n", len(feeds), u)
+			feed = chooseFeed(feeds, stdin, os.Stdout)
+		}
+
+		resolved = append(resolved, feed.URL)
+	}
+
+	return resolved, nil
+}
+
+// chooseFeed lists feeds and reads the number of the one to use. Anything
+// other than a listed number, including no answer, picks the first.
+func chooseFeed(feeds []search.DiscoveredFeed, in *bufio.Reader, out io.Writer) search.DiscoveredFeed {
+	for i, feed := range feeds {
+		printDiscoveredFeed(out, i+1, feed)
+	}
+	fmt.Fprintf(out, "   Which feed? [1-%d, default 1] ", len(feeds))
+
+	answer, _ := in.ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || n < 1 || n > len(feeds) {
+		n = 1
+	}
+	fmt.Fprintln(out)
+
+	return feeds[n-1]
+}
+
+func printDiscoveredFeed(w io.Writer, n int, feed search.DiscoveredFeed) {
+	title := feed.Title
+	if title == "" {
+		title = "Untitled feed"
+	}
+	fmt.Fprintf(w, "   %d. %s %s\n", n, ui.BoldStyle.Render(title), ui.MutedStyle.Render(describeDiscoveredFeed(feed)))
+	fmt.Fprintf(w, "      %s\n", feed.URL)
+}
+
+func describeDiscoveredFeed(feed search.DiscoveredFeed) string {
+	items := "1 item"
+	if feed.Items != 1 {
+		items = fmt.Sprintf("%d items", feed.Items)
+	}
+	if feed.Type == "" {
+		return "(" + items + ")"
+	}
+	return fmt.Sprintf("(%s, %s)", strings.ToUpper(feed.Type), items)
+}
+
+func findSubscription(topic string) (*models.Subscription, error) {
+	sub, err := db.GetSubscription(topic)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("not subscribed to %q", topic)
+	}
+	return sub, err
+}
+
+func usesSource(sub *models.Subscription, name string) bool {
+	sources := sub.Sources
+	if len(sources) == 0 {
+		sources = models.DefaultSources
+	}
+	for _, s := range sources {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFeedURLs checks that every value is an absolute http(s) URL.
+func parseFeedURLs(values []string) ([]string, error) {
+	var urls []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid feed URL %q: must start with http:// or https://", value)
+		}
+
+		urls = append(urls, u.String())
+	}
+	return urls, nil
+}
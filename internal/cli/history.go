@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+var historyLimit int
+var historyDeleteAll bool
+var historyDeleteForce bool
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Browse previously asked questions and their answers",
+	Long: `Browse previously asked questions and their answers.
+
+Every 'termiflow ask' is recorded unless --save=false is passed.
+
+Examples:
+  termiflow history                     # List recent queries
+  termiflow history show 12             # Show a full answer with sources
+  termiflow history search "borrow"     # Search questions and answers
+  termiflow history replay 12           # Ask the same question again
+  termiflow ask --thread 12 "..."       # Follow up on a saved conversation
+  termiflow history delete 12 13        # Remove entries`,
+	RunE: runHistoryList,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recent queries",
+	RunE:  runHistoryList,
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a saved answer with its sources",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHistoryShow,
+}
+
+var historySearchCmd = &cobra.Command{
+	Use:   "search <text>",
+	Short: "Search saved questions and answers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runHistorySearch,
+}
+
+var historyDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete saved queries",
+	RunE:  runHistoryDelete,
+}
+
+var historyReplayCmd = &cobra.Command{
+	Use:   "replay <id>",
+	Short: "Ask a saved question again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHistoryReplay,
+}
+
+func init() {
+	historyCmd.PersistentFlags().IntVar(&historyLimit, "limit", 20, "maximum entries to display")
+	historyDeleteCmd.Flags().BoolVar(&historyDeleteAll, "all", false, "delete the entire history")
+	historyDeleteCmd.Flags().BoolVar(&historyDeleteForce, "force", false, "skip confirmation prompt")
+
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historySearchCmd)
+	historyCmd.AddCommand(historyDeleteCmd)
+	historyCmd.AddCommand(historyReplayCmd)
+}
+
+func runHistoryList(cmd *cobra.Command, args []string) error {
+	queries, err := db.GetQueries(db.QueryFilter{Limit: historyLimit})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Header("termiflow history"))
+	fmt.Println()
+
+	printQueryList(queries, "No saved queries yet.")
+	return nil
+}
+
+func runHistorySearch(cmd *cobra.Command, args []string) error {
+	text := strings.Join(args, " ")
+
+	queries, err := db.GetQueries(db.QueryFilter{Search: text, Limit: historyLimit})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Header("termiflow history"))
+	fmt.Println()
+
+	printQueryList(queries, fmt.Sprintf("No saved queries match %q.", text))
+	return nil
+}
+
+func printQueryList(queries []*models.Query, emptyMessage string) {
+	if len(queries) == 0 {
+		fmt.Println(ui.MutedStyle.Render("   " + emptyMessage))
+		fmt.Println()
+		return
+	}
+
+	for _, q := range queries {
+		fmt.Print(ui.HistoryRow(q.ID, truncate(singleLine(q.Query), 44), q.Provider, q.TimeAgo()))
+	}
+
+	fmt.Print(ui.Tip(fmt.Sprintf("Show an answer with %s", ui.TitleStyle.Render("termiflow history show <id>"))))
+	fmt.Println()
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) error {
+	q, err := lookupQuery(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Header(fmt.Sprintf("termiflow history #%d", q.ID)))
+	fmt.Println()
+	fmt.Println(ui.BoldStyle.Render(" " + q.Query))
+	fmt.Println()
+
+	model := q.Provider
+	if q.Model != "" {
+		model = fmt.Sprintf("%s (%s)", q.Provider, q.Model)
+	}
+	fmt.Print(ui.Info("Asked", q.CreatedAt.Local().Format("Jan 2, 2006 · 15:04")))
+	fmt.Print(ui.Info("Provider", model))
+	if q.Incomplete {
+		fmt.Print(ui.Info("Answer", "incomplete, it stopped part way"))
+	}
+	if q.LatencyMs > 0 {
+		fmt.Print(ui.Info("Latency", (time.Duration(q.LatencyMs) * time.Millisecond).String()))
+	}
+	if q.TotalTokens() > 0 {
+		fmt.Print(ui.Info("Tokens", fmt.Sprintf("%d prompt + %d completion", q.PromptTokens, q.CompletionTokens)))
+	}
+	if thread, err := db.GetThread(q.ThreadID); err == nil && len(thread) > 1 {
+		turn := 1
+		for i, t := range thread {
+			if t.ID == q.ID {
+				turn = i + 1
+			}
+		}
+		fmt.Print(ui.Info("Thread", fmt.Sprintf("#%d · turn %d of %d", q.ThreadID, turn, len(thread))))
+	}
+	fmt.Println()
+
+	fmt.Println(q.Response)
+
+	if len(q.Sources) > 0 {
+		fmt.Println()
+		fmt.Println(ui.SmallDivider())
+		fmt.Println(ui.BoldStyle.Render(" Sources:"))
+		for i, src := range q.Sources {
+			fmt.Printf("   [%d] %s - %s\n", i+1, ui.MutedStyle.Render(sourceLabel(src.ItemID, src.URL)), src.Title)
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func runHistoryDelete(cmd *cobra.Command, args []string) error {
+	if historyDeleteAll {
+		if !historyDeleteForce {
+			fmt.Print("This will remove your entire query history. Are you sure? [y/N] ")
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+			if response != "y" && response != "yes" {
+				fmt.Println("Canceled.")
+				return nil
+			}
+		}
+
+		count, err := db.DeleteAllQueries()
+		if err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+		fmt.Print(ui.Success(fmt.Sprintf("Removed %d saved queries", count)))
+		return nil
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("please specify one or more ids or use --all")
+	}
+
+	for _, arg := range args {
+		id, err := parseQueryID(arg)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := db.DeleteQuery(id)
+		if err != nil {
+			return fmt.Errorf("failed to delete #%d: %w", id, err)
+		}
+		if deleted {
+			fmt.Print(ui.Success(fmt.Sprintf("Deleted #%d", id)))
+		} else {
+			fmt.Print(ui.Warning(fmt.Sprintf("No saved query #%d", id)))
+		}
+	}
+
+	return nil
+}
+
+func runHistoryReplay(cmd *cobra.Command, args []string) error {
+	q, err := lookupQuery(args[0])
+	if err != nil {
+		return err
+	}
+
+	return askQuestion(q.Query)
+}
+
+func lookupQuery(arg string) (*models.Query, error) {
+	id, err := parseQueryID(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := db.GetQuery(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no saved query #%d", id)
+	}
+	return q, err
+}
+
+func parseQueryID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid history id: %s", arg)
+	}
+	return id, nil
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
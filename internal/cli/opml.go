@@ -0,0 +1,385 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/scheduler"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/cron"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+	"github.com/oluoyefeso/termiflow/pkg/opml"
+)
+
+var importDryRun bool
+var importOnConflict string
+var exportOutput string
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import subscriptions from other tools",
+}
+
+var importOPMLCmd = &cobra.Command{
+	Use:   "opml <file>",
+	Short: "Import subscriptions and feeds from an OPML file",
+	Long: `Import subscriptions and feeds from an OPML file.
+
+Each folder of feeds becomes a subscription named after the folder, with the
+folder's feeds attached. A feed outside any folder becomes a subscription of
+its own. Files written by 'termiflow export opml' restore each subscription's
+frequency and sources as well.
+
+Topics you're already subscribed to are skipped unless --on-conflict merge is
+given, which adds the file's feeds to the existing subscription, and the rss
+source if it doesn't read feeds yet.
+
+Examples:
+  termiflow import opml feeds.opml --dry-run
+  termiflow import opml feeds.opml
+  termiflow import opml team.opml --on-conflict merge`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportOPML,
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export subscriptions for other tools",
+}
+
+var exportOPMLCmd = &cobra.Command{
+	Use:   "opml",
+	Short: "Export subscriptions and feeds as OPML",
+	Long: `Export subscriptions and their feeds as OPML, grouped by category.
+
+Examples:
+  termiflow export opml > termiflow.opml
+  termiflow export opml --output ~/shared/reading-list.opml`,
+	Args: cobra.NoArgs,
+	RunE: runExportOPML,
+}
+
+func init() {
+	importOPMLCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "show what would be imported without changing anything")
+	importOPMLCmd.Flags().StringVar(&importOnConflict, "on-conflict", "skip", "what to do with topics you're already subscribed to: skip or merge")
+	exportOPMLCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "write to a file instead of stdout")
+
+	importCmd.AddCommand(importOPMLCmd)
+	exportCmd.AddCommand(exportOPMLCmd)
+}
+
+// importPlan is a subscription read from an OPML file and what importing it
+// will do.
+type importPlan struct {
+	sub      *models.Subscription
+	feeds    []string
+	warnings []string
+}
+
+func runImportOPML(cmd *cobra.Command, args []string) error {
+	if importOnConflict != "skip" && importOnConflict != "merge" {
+		return fmt.Errorf("invalid --on-conflict %q: use skip or merge", importOnConflict)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	doc, err := opml.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Get()
+	registry, err := newSourceRegistry(cfg)
+	if err != nil {
+		return err
+	}
+	plans := planImport(doc.Entries(), cfg.Schedule.DefaultFrequency, registry)
+
+	fmt.Println(ui.Header("termiflow import"))
+	fmt.Println()
+
+	if len(plans) == 0 {
+		fmt.Println(ui.MutedStyle.Render("   No subscriptions found in " + args[0]))
+		fmt.Println()
+		return nil
+	}
+
+	created, merged, skipped := 0, 0, 0
+	for _, plan := range plans {
+		existing, err := db.GetSubscription(plan.sub.Topic)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		switch {
+		case existing == nil:
+			if !importDryRun {
+				if err := db.CreateSubscriptionWithFeeds(plan.sub, plan.feeds); err != nil {
+					return fmt.Errorf("failed to create subscription %q: %w", plan.sub.Topic, err)
+				}
+			}
+			fmt.Print(ui.Success(fmt.Sprintf("%s %s", plan.sub.Topic, ui.MutedStyle.Render(describeFeeds(len(plan.feeds))))))
+			created++
+
+		case importOnConflict == "merge":
+			addRSS := len(plan.feeds) > 0 && !usesSource(existing, "rss")
+			var added int
+			if importDryRun {
+				added, err = countNewFeeds(existing.ID, plan.feeds)
+			} else {
+				added, err = mergeFeeds(existing, plan.feeds)
+			}
+			if err != nil {
+				return err
+			}
+			note := fmt.Sprintf("merged, %s", describeFeeds(added))
+			if addRSS {
+				note += ", added the rss source"
+			}
+			fmt.Printf(" %s %s %s\n", ui.TitleStyle.Render("~"), plan.sub.Topic, ui.MutedStyle.Render(note))
+			merged++
+
+		default:
+			fmt.Printf(" %s %s %s\n", ui.MutedStyle.Render("-"), plan.sub.Topic, ui.MutedStyle.Render("already subscribed, skipped"))
+			skipped++
+		}
+
+		for _, w := range plan.warnings {
+			fmt.Printf("     %s\n", ui.WarningStyle.Render(w))
+		}
+	}
+
+	summary := fmt.Sprintf("%d subscribed · %d merged · %d skipped", created, merged, skipped)
+	if importDryRun {
+		summary += " · dry run, nothing was changed"
+	}
+	fmt.Printf("\n %s\n %s\n\n", ui.SmallDivider(), ui.MutedStyle.Render(" "+summary))
+
+	return nil
+}
+
+// planImport turns OPML entries into subscriptions. Topics that appear more
+// than once are combined, unusable settings fall back to the defaults with a
+// warning, and feeds a category already reads are dropped.
+func planImport(entries []opml.Entry, defaultFrequency string, registry *scheduler.SourceRegistry) []*importPlan {
+	var plans []*importPlan
+	byTopic := make(map[string]*importPlan)
+
+	for _, e := range entries {
+		plan, ok := byTopic[e.Topic]
+		if !ok {
+			plan = &importPlan{sub: &models.Subscription{
+				Topic:     e.Topic,
+				Frequency: defaultFrequency,
+				Sources:   models.DefaultSources,
+				IsActive:  true,
+			}}
+			if category := models.GetCategoryByName(e.Topic); category != nil {
+				plan.sub.Category = category.Name
+			}
+			applyImportSettings(plan, e, registry)
+
+			byTopic[e.Topic] = plan
+			plans = append(plans, plan)
+		}
+
+		for _, feed := range e.Feeds {
+			urls, err := parseFeedURLs([]string{feed})
+			if err != nil {
+				plan.warnings = append(plan.warnings, err.Error())
+				continue
+			}
+			if !containsString(plan.feeds, urls[0]) && !isCategoryFeed(plan.sub.Topic, urls[0]) {
+				plan.feeds = append(plan.feeds, urls[0])
+			}
+		}
+	}
+
+	for _, plan := range plans {
+		if len(plan.feeds) > 0 && !usesSource(plan.sub, "rss") {
+			plan.sub.Sources = append(plan.sub.Sources, "rss")
+		}
+	}
+
+	return plans
+}
+
+func applyImportSettings(plan *importPlan, e opml.Entry, registry *scheduler.SourceRegistry) {
+	switch {
+	case e.Cron != "":
+		if expr, err := cron.Parse(e.Cron); err != nil || expr.Next(time.Now()).IsZero() {
+			plan.warnings = append(plan.warnings, fmt.Sprintf("unusable cron %q; using the default frequency", e.Cron))
+		} else {
+			plan.sub.Frequency = "cron"
+			plan.sub.Cron = e.Cron
+		}
+	case e.Frequency == "hourly" || e.Frequency == "daily" || e.Frequency == "weekly":
+		plan.sub.Frequency = e.Frequency
+	case e.Frequency != "":
+		plan.warnings = append(plan.warnings, fmt.Sprintf("unknown frequency %q; using the default", e.Frequency))
+	}
+
+	if len(e.Sources) > 0 {
+		sources, err := parseSourcesFlag(strings.Join(e.Sources, ","), registry)
+		if err != nil {
+			plan.warnings = append(plan.warnings, err.Error()+"; using the default sources")
+		} else {
+			plan.sub.Sources = sources
+		}
+	}
+}
+
+// mergeFeeds attaches feeds to an existing subscription and returns how many
+// were new. A subscription that didn't read feeds gets the rss source added,
+// so the feeds are read.
+func mergeFeeds(sub *models.Subscription, feeds []string) (int, error) {
+	if len(feeds) > 0 && !usesSource(sub, "rss") {
+		sub.Sources = append(sub.Sources, "rss")
+		if err := db.UpdateSubscription(sub); err != nil {
+			return 0, fmt.Errorf("failed to add the rss source: %w", err)
+		}
+	}
+	return addFeeds(sub.ID, feeds)
+}
+
+// addFeeds attaches feeds to a subscription and returns how many were new.
+func addFeeds(subID int64, feeds []string) (int, error) {
+	added := 0
+	for _, u := range feeds {
+		err := db.AddSubscriptionFeed(&models.SubscriptionFeed{SubscriptionID: subID, URL: u})
+		if errors.Is(err, db.ErrFeedExists) {
+			continue
+		}
+		if err != nil {
+			return added, fmt.Errorf("failed to add feed: %w", err)
+		}
+		added++
+	}
+	return added, nil
+}
+
+// countNewFeeds returns how many of feeds the subscription doesn't have yet.
+func countNewFeeds(subID int64, feeds []string) (int, error) {
+	current, err := db.GetSubscriptionFeeds(subID)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, u := range feeds {
+		found := false
+		for _, feed := range current {
+			if feed.URL == u {
+				found = true
+				break
+			}
+		}
+		if !found {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func describeFeeds(n int) string {
+	if n == 1 {
+		return "1 feed"
+	}
+	return fmt.Sprintf("%d feeds", n)
+}
+
+func isCategoryFeed(topic, url string) bool {
+	category := models.GetCategoryByName(topic)
+	return category != nil && containsString(category.DefaultRSS, url)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func runExportOPML(cmd *cobra.Command, args []string) error {
+	subs, err := db.GetAllSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	entries, err := exportEntries(subs)
+	if err != nil {
+		return err
+	}
+
+	doc := opml.FromEntries("termiflow subscriptions", entries, time.Now())
+
+	var w io.Writer = cmd.OutOrStdout()
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := doc.Write(w); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+
+	if exportOutput != "" {
+		fmt.Print(ui.Success(fmt.Sprintf("Exported %d subscription(s) to %s", len(entries), exportOutput)))
+	}
+	return nil
+}
+
+// exportEntries lists subscriptions by topic, grouping predefined categories
+// under their display names. Feeds include the category defaults so other
+// readers get the complete list.
+func exportEntries(subs []*models.Subscription) ([]opml.Entry, error) {
+	sorted := append([]*models.Subscription(nil), subs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Topic < sorted[j].Topic })
+
+	var entries []opml.Entry
+	for _, sub := range sorted {
+		entry := opml.Entry{
+			Topic:     sub.Topic,
+			Frequency: sub.Frequency,
+			Cron:      sub.Cron,
+			Sources:   sub.Sources,
+		}
+
+		if category := models.GetCategoryByName(sub.Topic); category != nil {
+			entry.Group = category.DisplayName
+			entry.Feeds = append(entry.Feeds, category.DefaultRSS...)
+		}
+
+		feeds, err := db.GetSubscriptionFeeds(sub.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, feed := range feeds {
+			entry.Feeds = append(entry.Feeds, feed.URL)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -16,6 +16,7 @@ var (
 	quiet    bool
 	debug    bool
 	noColor  bool
+	offline  bool
 
 	version string
 	commit  string
@@ -54,6 +55,15 @@ No browser switching, no context loss, no noise. Just signal.`,
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
+		if offline && !config.Get().Cache.Enabled {
+			return fmt.Errorf("--offline reads from the cache, which is disabled (set cache.enabled = true)")
+		}
+
+		// Schema commands open the database without migrating it
+		if isDBCommand(cmd) {
+			return nil
+		}
+
 		// Initialize database
 		if err := db.Init(); err != nil {
 			return fmt.Errorf("failed to initialize database: %w", err)
@@ -78,10 +88,11 @@ func SetVersionInfo(v, c, d string) {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ~/.config/termiflow/config.toml)")
-	rootCmd.PersistentFlags().StringVar(&provider, "provider", "", "override LLM provider (openai, anthropic, local)")
+	rootCmd.PersistentFlags().StringVar(&provider, "provider", "", "override LLM provider (openai, anthropic, gemini, local)")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "suppress non-essential output")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug logging")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "serve searches, pages and feeds only from the cache")
 
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
@@ -90,6 +101,17 @@ func init() {
 	rootCmd.AddCommand(unsubscribeCmd)
 	rootCmd.AddCommand(feedCmd)
 	rootCmd.AddCommand(topicsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(chatCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(feedsCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(usageCmd)
 }
 
 func getProvider() string {
@@ -98,3 +120,14 @@ func getProvider() string {
 	}
 	return config.Get().General.DefaultProvider
 }
+
+// getProviders returns the LLM providers to try in order: the one named by
+// --provider, or else providers.chain, or else the default provider.
+func getProviders() []string {
+	if provider == "" {
+		if chain := config.Get().Providers.Chain; len(chain) > 0 {
+			return chain
+		}
+	}
+	return []string{getProvider()}
+}
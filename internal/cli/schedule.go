@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/daemon"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/scheduler"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Show when each subscription is next refreshed",
+	Long: `Show when each subscription is next refreshed.
+
+Daily subscriptions refresh at schedule.daily_time and weekly ones on
+schedule.weekly_day at that time, in your local timezone. Subscriptions
+created with --cron follow their own expression. Refreshes happen
+while 'termiflow daemon' is running or when you run 'termiflow feed --refresh'.
+
+Examples:
+  termiflow schedule
+  termiflow config set schedule.daily_time 07:30`,
+	RunE: runSchedule,
+}
+
+func runSchedule(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+
+	schedule, err := scheduler.NewSchedule(cfg.Schedule)
+	if err != nil {
+		return err
+	}
+
+	subs, err := db.GetActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Header("termiflow schedule"))
+	fmt.Println()
+
+	if len(subs) == 0 {
+		fmt.Println(ui.MutedStyle.Render("   No active subscriptions."))
+		fmt.Println()
+		return nil
+	}
+
+	now := time.Now()
+	for _, sub := range subs {
+		last := "never"
+		if sub.LastFetchedAt != nil {
+			last = sub.LastFetchedAt.Local().Format("Jan 2 15:04")
+		}
+
+		fmt.Print(ui.ScheduleRow(
+			sub.Topic,
+			formatFrequency(sub, cfg.Schedule),
+			last,
+			formatNextRun(schedule.NextRun(sub, now), now),
+		))
+	}
+
+	fmt.Println()
+	if pid, err := daemon.ReadPID(daemonPIDPath()); err == nil {
+		fmt.Print(ui.Info("Daemon", fmt.Sprintf("running (pid %d)", pid)))
+	} else if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Print(ui.Info("Daemon", "not running"))
+		fmt.Print(ui.Tip(fmt.Sprintf("Start %s to refresh on schedule", ui.TitleStyle.Render("termiflow daemon"))))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func formatNextRun(next, now time.Time) string {
+	if !next.After(now) {
+		return "due now"
+	}
+
+	next = next.Local()
+	when := next.Format("Mon 15:04")
+	if next.Sub(now) >= 6*24*time.Hour {
+		when = next.Format("Mon Jan 2 15:04")
+	}
+
+	return fmt.Sprintf("%s (in %s)", when, formatDuration(next.Sub(now)))
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
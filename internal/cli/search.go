@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+)
+
+var searchTopic string
+var searchTag string
+var searchSince string
+var searchUntil string
+var searchLimit int
+var searchAny bool
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search stored feed items",
+	Long: `Search the titles, summaries, content and tags of stored feed items.
+
+Words are matched in any form ("closures" finds "closure"), and a trailing *
+matches prefixes. All words must appear unless --any is passed.
+
+Examples:
+  termiflow search "tsmc yields"
+  termiflow search webgpu --topic web-dev
+  termiflow search rust* --tag async --since 7d
+  termiflow search "chiplet packaging" --since 2024-06-01 --until 2024-07-01`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSearch,
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchTopic, "topic", "", "filter by subscription topic")
+	searchCmd.Flags().StringVar(&searchTag, "tag", "", "filter by tag")
+	searchCmd.Flags().StringVar(&searchSince, "since", "", "only items fetched after this date or age (e.g. 2024-06-01, 7d, 2w)")
+	searchCmd.Flags().StringVar(&searchUntil, "until", "", "only items fetched before this date or age")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "maximum results to display")
+	searchCmd.Flags().BoolVar(&searchAny, "any", false, "match items containing any of the words")
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+
+	filter := db.FeedSearchFilter{
+		Query:    query,
+		MatchAny: searchAny,
+		Topic:    searchTopic,
+		Tag:      strings.TrimPrefix(searchTag, "#"),
+		Limit:    searchLimit,
+	}
+
+	now := time.Now()
+	if searchSince != "" {
+		since, err := parseDateFlag(searchSince, now)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		filter.Since = &since
+	}
+	if searchUntil != "" {
+		until, err := parseDateFlag(searchUntil, now)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		filter.Until = &until
+	}
+
+	matches, err := db.SearchFeedItems(filter)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	fmt.Println(ui.Header("termiflow search"))
+	fmt.Println()
+
+	if len(matches) == 0 {
+		fmt.Println(ui.MutedStyle.Render(fmt.Sprintf("   No feed items match %q.", query)))
+		fmt.Println()
+		return nil
+	}
+
+	for i, m := range matches {
+		snippet := m.Snippet
+		if snippet == "" {
+			snippet = truncate(m.Item.Summary, 120)
+		}
+
+		fmt.Print(ui.SearchResultRow(
+			m.Item.ID,
+			m.Item.Title,
+			m.Item.SourceName,
+			m.Topic,
+			m.Item.TimeAgo(),
+			highlightSnippet(singleLine(snippet)),
+		))
+
+		if i < len(matches)-1 {
+			fmt.Println()
+		}
+	}
+
+	fmt.Printf("\n %s\n %s\n\n", ui.SmallDivider(), ui.MutedStyle.Render(fmt.Sprintf(" %d matches · %d topics", len(matches), countTopics(matches))))
+	return nil
+}
+
+// highlightSnippet renders the match markers from db.SearchFeedItems.
+func highlightSnippet(snippet string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(snippet, db.HighlightStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(snippet[start:], db.HighlightEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(snippet[:start])
+		b.WriteString(ui.TitleStyle.Render(snippet[start+len(db.HighlightStart) : end]))
+		snippet = snippet[end+len(db.HighlightEnd):]
+	}
+	b.WriteString(snippet)
+
+	return strings.NewReplacer(db.HighlightStart, "", db.HighlightEnd, "").Replace(b.String())
+}
+
+func countTopics(matches []*db.FeedItemMatch) int {
+	topics := make(map[string]bool)
+	for _, m := range matches {
+		topics[m.Topic] = true
+	}
+	return len(topics)
+}
+
+// parseDateFlag accepts a calendar date ("2024-06-01"), "today", "yesterday",
+// an age in days or weeks ("7d", "2w") or a Go duration ("36h").
+func parseDateFlag(value string, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch value {
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	if len(value) > 1 {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02) or age (7d, 2w, 36h)", value)
+}
@@ -1,14 +1,19 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/oluoyefeso/termiflow/internal/config"
 	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/scheduler"
 	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/cron"
 	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
@@ -16,6 +21,10 @@ var subHourly bool
 var subDaily bool
 var subWeekly bool
 var subSources string
+var subCron string
+var subFeeds []string
+var subEnrich bool
+var subBudget models.Budget
 
 var subscribeCmd = &cobra.Command{
 	Use:   "subscribe <topic>",
@@ -27,7 +36,18 @@ You can subscribe to predefined categories or free-form topics:
   termiflow subscribe "silicon-chips"                    # Predefined category
   termiflow subscribe "RISC-V adoption in automotive"    # Free-form topic
   termiflow subscribe "rust async ecosystem" --hourly
-  termiflow subscribe "quantum error correction" --weekly`,
+  termiflow subscribe "quantum error correction" --weekly
+  termiflow subscribe "chip export controls" --cron "0 7,18 * * 1-5"
+  termiflow subscribe "rust async ecosystem" --feed https://without.boats/index.xml
+  termiflow subscribe "chip export controls" --enrich     # Summarize full articles
+  termiflow subscribe "rust async ecosystem" --daily-cost 0.25
+
+Cron expressions use the standard five fields (minute hour day month
+weekday) and are evaluated in your local timezone.
+
+Budgets cap what refreshing the topic may spend on LLM calls, on top of
+usage.budget in the config. Give budget flags for a topic you already follow
+to change its budget; 0 removes a limit.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSubscribe,
 }
@@ -36,9 +56,18 @@ func init() {
 	subscribeCmd.Flags().BoolVar(&subHourly, "hourly", false, "get updates every hour")
 	subscribeCmd.Flags().BoolVar(&subDaily, "daily", false, "get updates once per day (default)")
 	subscribeCmd.Flags().BoolVar(&subWeekly, "weekly", false, "get updates once per week")
-	subscribeCmd.Flags().StringVar(&subSources, "sources", "", "comma-separated source preferences (tavily,rss,scrape)")
+	subscribeCmd.Flags().StringVar(&subCron, "cron", "", "refresh on a cron schedule, e.g. \"0 7,18 * * 1-5\"")
+	subscribeCmd.Flags().StringArrayVar(&subFeeds, "feed", nil, "RSS or Atom feed, or a website to find one on, to read for this topic (repeatable)")
+	subscribeCmd.Flags().BoolVar(&subEnrich, "enrich", false, "read the full article behind each result before summarizing (adds the scrape source)")
+	subscribeCmd.Flags().StringVar(&subSources, "sources", "", "comma-separated sources: tavily, rss, scrape or a feed named in search.rss.named")
+	subscribeCmd.Flags().Float64Var(&subBudget.DailyCost, "daily-cost", 0, "most to spend on LLM calls per day, in US dollars")
+	subscribeCmd.Flags().Float64Var(&subBudget.MonthlyCost, "monthly-cost", 0, "most to spend on LLM calls per calendar month, in US dollars")
+	subscribeCmd.Flags().Int64Var(&subBudget.DailyTokens, "daily-tokens", 0, "most LLM tokens to use per day")
+	subscribeCmd.Flags().Int64Var(&subBudget.MonthlyTokens, "monthly-tokens", 0, "most LLM tokens to use per calendar month")
 }
 
+var budgetFlags = []string{"daily-cost", "monthly-cost", "daily-tokens", "monthly-tokens"}
+
 func runSubscribe(cmd *cobra.Command, args []string) error {
 	topic := args[0]
 	cfg := config.Get()
@@ -53,9 +82,26 @@ func runSubscribe(cmd *cobra.Command, args []string) error {
 		frequency = "weekly"
 	}
 
+	if subCron != "" {
+		if subHourly || subDaily || subWeekly {
+			return fmt.Errorf("--cron can't be combined with --hourly, --daily or --weekly")
+		}
+		if _, err := parseCronFlag(subCron); err != nil {
+			return err
+		}
+		frequency = "cron"
+	}
+
+	if subBudget.DailyCost < 0 || subBudget.MonthlyCost < 0 || subBudget.DailyTokens < 0 || subBudget.MonthlyTokens < 0 {
+		return fmt.Errorf("budgets can't be negative")
+	}
+
 	// Check if already subscribed
 	existing, err := db.GetSubscription(topic)
 	if err == nil && existing != nil {
+		if changedAny(cmd, budgetFlags) {
+			return updateBudget(cmd, existing)
+		}
 		fmt.Print(ui.Warning(fmt.Sprintf("Already subscribed to %s", topic)))
 		return nil
 	}
@@ -64,26 +110,53 @@ func runSubscribe(cmd *cobra.Command, args []string) error {
 	category := models.GetCategoryByName(topic)
 
 	// Parse sources
-	var sources []string
+	sources := models.DefaultSources
 	if subSources != "" {
-		sources = strings.Split(subSources, ",")
-	} else {
-		sources = []string{"tavily", "rss"}
+		registry, err := newSourceRegistry(cfg)
+		if err != nil {
+			return err
+		}
+		sources, err = parseSourcesFlag(subSources, registry)
+		if err != nil {
+			return err
+		}
+	}
+	if subEnrich && !containsString(sources, scheduler.ScrapeSource) {
+		sources = append(append([]string(nil), sources...), scheduler.ScrapeSource)
+	}
+
+	feedURLs, err := parseFeedURLs(subFeeds)
+	if err != nil {
+		return err
 	}
 
 	// Create subscription
 	sub := &models.Subscription{
 		Topic:     topic,
 		Frequency: frequency,
+		Cron:      subCron,
 		Sources:   sources,
 		IsActive:  true,
 	}
+	if !subBudget.IsZero() {
+		budget := subBudget
+		sub.Budget = &budget
+	}
 
 	if category != nil {
 		sub.Category = category.Name
 	}
 
-	if err := db.CreateSubscription(sub); err != nil {
+	if len(feedURLs) > 0 && !usesSource(sub, "rss") {
+		return fmt.Errorf("--feed needs the rss source; add it to --sources")
+	}
+
+	feedURLs, err = resolveFeedURLs(context.Background(), feedURLs)
+	if err != nil {
+		return err
+	}
+
+	if err := db.CreateSubscriptionWithFeeds(sub, feedURLs); err != nil {
 		return fmt.Errorf("failed to create subscription: %w", err)
 	}
 
@@ -100,8 +173,14 @@ func runSubscribe(cmd *cobra.Command, args []string) error {
 		fmt.Print(ui.Info("Keywords", topic))
 	}
 
-	fmt.Print(ui.Info("Frequency", formatFrequency(frequency, cfg.Schedule.DailyTime)))
+	fmt.Print(ui.Info("Frequency", formatFrequency(sub, cfg.Schedule)))
 	fmt.Print(ui.Info("Sources", formatSources(sources)))
+	for _, u := range feedURLs {
+		fmt.Print(ui.Info("Feed", u))
+	}
+	if sub.Budget != nil {
+		fmt.Print(ui.Info("Budget", formatBudget(*sub.Budget)))
+	}
 
 	fmt.Println()
 	fmt.Printf("   Run %s to see your updates.\n", ui.TitleStyle.Render("termiflow feed"))
@@ -110,16 +189,63 @@ func runSubscribe(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func formatFrequency(frequency, dailyTime string) string {
-	switch frequency {
+// parseCronFlag validates a cron expression given on the command line.
+func parseCronFlag(value string) (*cron.Expression, error) {
+	expr, err := cron.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --cron: %w", err)
+	}
+	if expr.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid --cron: %q never runs", value)
+	}
+	return expr, nil
+}
+
+// parseSourcesFlag splits --sources and checks every name is a registered
+// source or "scrape". Scrape only reads the articles behind other sources'
+// results, so it can't be the only one.
+func parseSourcesFlag(value string, registry *scheduler.SourceRegistry) ([]string, error) {
+	var sources []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if _, ok := registry.Lookup(name); !ok && name != scheduler.ScrapeSource {
+			available := append(registry.Names(), scheduler.ScrapeSource)
+			return nil, fmt.Errorf("unknown source %q (available: %s)", name, strings.Join(available, ", "))
+		}
+
+		sources = append(sources, name)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("--sources needs at least one source")
+	}
+	if !slices.ContainsFunc(sources, func(name string) bool { return name != scheduler.ScrapeSource }) {
+		return nil, fmt.Errorf("%q only reads the articles other sources find; add one of: %s", scheduler.ScrapeSource, strings.Join(registry.Names(), ", "))
+	}
+	return sources, nil
+}
+
+func formatFrequency(sub *models.Subscription, schedule config.ScheduleConfig) string {
+	if sub.Cron != "" {
+		return fmt.Sprintf("Cron %s", sub.Cron)
+	}
+
+	switch sub.Frequency {
 	case "hourly":
 		return "Hourly"
 	case "daily":
-		return fmt.Sprintf("Daily at %s", dailyTime)
+		return fmt.Sprintf("Daily at %s", schedule.DailyTime)
 	case "weekly":
-		return "Weekly"
+		if schedule.WeeklyDay < 0 || schedule.WeeklyDay > 6 {
+			return "Weekly"
+		}
+		return fmt.Sprintf("Weekly on %s at %s", time.Weekday(schedule.WeeklyDay), schedule.DailyTime)
 	default:
-		return frequency
+		return sub.Frequency
 	}
 }
 
@@ -131,11 +257,79 @@ func formatSources(sources []string) string {
 			parts = append(parts, "Tavily search")
 		case "rss":
 			parts = append(parts, "RSS feeds")
-		case "scrape":
-			parts = append(parts, "Web scraping")
+		case scheduler.ScrapeSource:
+			parts = append(parts, "Full articles")
 		default:
 			parts = append(parts, s)
 		}
 	}
 	return strings.Join(parts, ", ")
 }
+
+// updateBudget changes the limits given on the command line for a topic
+// already subscribed to, keeping the others.
+func updateBudget(cmd *cobra.Command, sub *models.Subscription) error {
+	budget := models.Budget{}
+	if sub.Budget != nil {
+		budget = *sub.Budget
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("daily-cost") {
+		budget.DailyCost = subBudget.DailyCost
+	}
+	if flags.Changed("monthly-cost") {
+		budget.MonthlyCost = subBudget.MonthlyCost
+	}
+	if flags.Changed("daily-tokens") {
+		budget.DailyTokens = subBudget.DailyTokens
+	}
+	if flags.Changed("monthly-tokens") {
+		budget.MonthlyTokens = subBudget.MonthlyTokens
+	}
+
+	sub.Budget = &budget
+	if budget.IsZero() {
+		sub.Budget = nil
+	}
+	if err := db.UpdateSubscription(sub); err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	if sub.Budget == nil {
+		fmt.Print(ui.Success(fmt.Sprintf("Removed the budget for %s", sub.Topic)))
+		return nil
+	}
+	fmt.Print(ui.Success(fmt.Sprintf("Budget for %s is now %s", sub.Topic, formatBudget(budget))))
+	return nil
+}
+
+func changedAny(cmd *cobra.Command, names []string) bool {
+	for _, name := range names {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBudget lists a budget's limits, e.g. "$0.25/day, 2000000 tokens/month".
+func formatBudget(b models.Budget) string {
+	var parts []string
+	if b.DailyCost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f/day", b.DailyCost))
+	}
+	if b.DailyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens/day", b.DailyTokens))
+	}
+	if b.MonthlyCost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f/month", b.MonthlyCost))
+	}
+	if b.MonthlyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens/month", b.MonthlyTokens))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
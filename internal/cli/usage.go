@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/scheduler"
+	"github.com/oluoyefeso/termiflow/internal/ui"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+var usageBy string
+var usageSince string
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show what LLM calls have cost",
+	Long: `Show the tokens and estimated cost of LLM calls, grouped by day, topic,
+provider, model or purpose (ask, score, summarize, tags or curate).
+
+Costs are priced from built-in list prices and any [[usage.prices]] in the
+config. Where a provider doesn't report token counts they're estimated from
+the text, and local models cost nothing.
+
+Examples:
+  termiflow usage                    # Daily totals for the last 30 days
+  termiflow usage --by topic         # What each subscription's refreshes cost
+  termiflow usage --by provider --since 7d
+  termiflow usage --by purpose --since 2024-06-01`,
+	RunE: runUsage,
+}
+
+func init() {
+	usageCmd.Flags().StringVar(&usageBy, "by", "day", "group by day, topic, provider, model or purpose")
+	usageCmd.Flags().StringVar(&usageSince, "since", "30d", "only calls after this date or age (e.g. 2024-06-01, 7d, 2w)")
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	by := strings.ToLower(usageBy)
+	if !slices.Contains(db.UsageGroups, by) {
+		return fmt.Errorf("invalid --by %q (want %s)", usageBy, strings.Join(db.UsageGroups, ", "))
+	}
+
+	since, err := parseDateFlag(usageSince, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	summaries, err := db.SummarizeUsage(by, since)
+	if err != nil {
+		return fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	fmt.Println(ui.Header("termiflow usage"))
+	fmt.Println()
+
+	if len(summaries) == 0 {
+		fmt.Println(ui.MutedStyle.Render(fmt.Sprintf("   No LLM calls since %s", since.Format("2006-01-02"))))
+		fmt.Println()
+		return nil
+	}
+
+	width := len(by)
+	for _, s := range summaries {
+		width = max(width, len(usageLabel(by, s.Key)))
+	}
+
+	fmt.Println(ui.MutedStyle.Render(fmt.Sprintf("   %-*s %7s %12s %12s %10s", width, by, "calls", "prompt", "completion", "cost")))
+
+	var total models.UsageSummary
+	for _, s := range summaries {
+		fmt.Printf("   %-*s %7d %12d %12d %10s\n", width, usageLabel(by, s.Key), s.Calls, s.PromptTokens, s.CompletionTokens, formatCost(s.Cost))
+		total.Calls += s.Calls
+		total.PromptTokens += s.PromptTokens
+		total.CompletionTokens += s.CompletionTokens
+		total.Cost += s.Cost
+	}
+
+	fmt.Println(ui.TitleStyle.Render(fmt.Sprintf("   %-*s %7d %12d %12d %10s", width, "total", total.Calls, total.PromptTokens, total.CompletionTokens, formatCost(total.Cost))))
+
+	if err := printBudgets(config.Get()); err != nil {
+		return err
+	}
+	fmt.Print(ui.Tip(fmt.Sprintf("Costs are estimates from list prices, since %s", since.Format("2006-01-02"))))
+	fmt.Println()
+	return nil
+}
+
+// printBudgets shows what has been spent against the global budget and
+// those of subscriptions.
+func printBudgets(cfg *config.Config) error {
+	type entry struct {
+		label  string
+		topic  string
+		budget models.Budget
+	}
+
+	b := cfg.Usage.Budget
+	budgets := []entry{
+		{"Budget", "", models.Budget{DailyCost: b.DailyCost, MonthlyCost: b.MonthlyCost, DailyTokens: b.DailyTokens, MonthlyTokens: b.MonthlyTokens}},
+	}
+
+	subs, err := db.GetActiveSubscriptions()
+	if err != nil {
+		return fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		if sub.Budget != nil {
+			budgets = append(budgets, entry{sub.Topic, sub.Topic, *sub.Budget})
+		}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	printed := false
+	for _, entry := range budgets {
+		if entry.budget.IsZero() {
+			continue
+		}
+		day, err := db.UsageSince(entry.topic, today)
+		if err != nil {
+			return fmt.Errorf("failed to read usage: %w", err)
+		}
+		thisMonth, err := db.UsageSince(entry.topic, month)
+		if err != nil {
+			return fmt.Errorf("failed to read usage: %w", err)
+		}
+
+		if !printed {
+			fmt.Println()
+			printed = true
+		}
+		fmt.Print(ui.Info(entry.label, formatBudgetSpent(entry.budget, day, thisMonth)))
+	}
+	return nil
+}
+
+// formatBudgetSpent shows each limit of a budget with what today and this
+// month have used of it.
+func formatBudgetSpent(b models.Budget, day, month *models.UsageSummary) string {
+	var parts []string
+	if b.DailyCost > 0 {
+		parts = append(parts, fmt.Sprintf("%s of $%.2f today", formatCost(day.Cost), b.DailyCost))
+	}
+	if b.DailyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d tokens today", day.TotalTokens(), b.DailyTokens))
+	}
+	if b.MonthlyCost > 0 {
+		parts = append(parts, fmt.Sprintf("%s of $%.2f this month", formatCost(month.Cost), b.MonthlyCost))
+	}
+	if b.MonthlyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d tokens this month", month.TotalTokens(), b.MonthlyTokens))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// usageLabel names a group in the report. Calls outside a subscription, such
+// as ask and chat, have no topic.
+func usageLabel(by, key string) string {
+	if key == "" && by == "topic" {
+		return "(no topic)"
+	}
+	return key
+}
+
+// formatCost renders US dollars, with more places for amounts under a cent.
+func formatCost(cost float64) string {
+	if cost > 0 && cost < 0.0001 {
+		return "<$0.0001"
+	}
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// trackUsage wraps p so its calls are recorded in the usage ledger, priced
+// from usage.prices and the built-in list prices.
+func trackUsage(p llm.Provider, cfg *config.Config) llm.Provider {
+	return llm.WithUsage(p, db.UsageLedger{}, usagePrices(cfg))
+}
+
+// usagePrices puts configured prices ahead of the built-in ones, so they win
+// for the same model.
+func usagePrices(cfg *config.Config) llm.PriceTable {
+	var prices llm.PriceTable
+	for _, p := range cfg.Usage.Prices {
+		prices = append(prices, llm.Price{Model: p.Model, Prompt: p.Prompt, Completion: p.Completion})
+	}
+	return append(prices, llm.DefaultPrices...)
+}
+
+// newBudget sets out the usage.budget limits for refreshing subscriptions.
+// Under cheaper_model the first of providerNames curates with cheap_model.
+func newBudget(cfg *config.Config, providerNames []string) (scheduler.BudgetOptions, error) {
+	b := cfg.Usage.Budget
+	opts := scheduler.BudgetOptions{
+		Global: models.Budget{
+			DailyCost:     b.DailyCost,
+			MonthlyCost:   b.MonthlyCost,
+			DailyTokens:   b.DailyTokens,
+			MonthlyTokens: b.MonthlyTokens,
+		},
+		OnExceed: b.OnExceed,
+		Prices:   usagePrices(cfg),
+	}
+
+	switch b.OnExceed {
+	case scheduler.BudgetSkipSummaries, scheduler.BudgetStop:
+	case scheduler.BudgetCheaperModel:
+		if b.CheapModel == "" {
+			return opts, fmt.Errorf("usage.budget.on_exceed = %q needs usage.budget.cheap_model", b.OnExceed)
+		}
+		name := providerNames[0]
+		cheap, err := llm.GetProvider(name, withModel(cfg, name, b.CheapModel))
+		if err != nil {
+			return opts, err
+		}
+		opts.Cheap = trackUsage(cheap, cfg)
+	default:
+		return opts, fmt.Errorf("invalid usage.budget.on_exceed %q (want %s, %s or %s)",
+			b.OnExceed, scheduler.BudgetSkipSummaries, scheduler.BudgetCheaperModel, scheduler.BudgetStop)
+	}
+
+	return opts, nil
+}
+
+// withModel returns a copy of cfg with the named provider using model.
+func withModel(cfg *config.Config, name, model string) *config.Config {
+	c := *cfg
+	switch name {
+	case "openai":
+		c.Providers.OpenAI.Model = model
+	case "anthropic":
+		c.Providers.Anthropic.Model = model
+	case "gemini":
+		c.Providers.Gemini.Model = model
+	case "local":
+		c.Providers.Local.Model = model
+	}
+	return &c
+}
@@ -14,6 +14,9 @@ type Config struct {
 	Providers ProvidersConfig `mapstructure:"providers"`
 	Search    SearchConfig    `mapstructure:"search"`
 	Schedule  ScheduleConfig  `mapstructure:"schedule"`
+	Refresh   RefreshConfig   `mapstructure:"refresh"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Usage     UsageConfig     `mapstructure:"usage"`
 }
 
 type GeneralConfig struct {
@@ -26,43 +29,82 @@ type GeneralConfig struct {
 type ProvidersConfig struct {
 	OpenAI    OpenAIConfig    `mapstructure:"openai"`
 	Anthropic AnthropicConfig `mapstructure:"anthropic"`
+	Gemini    GeminiConfig    `mapstructure:"gemini"`
 	Local     LocalConfig     `mapstructure:"local"`
+	Retry     RetryConfig     `mapstructure:"retry"`
+	// Chain lists providers to try in order, falling over to the next when
+	// one is down or not configured. Empty means general.default_provider.
+	Chain []string `mapstructure:"chain"`
 }
 
 type OpenAIConfig struct {
-	APIKey  string `mapstructure:"api_key"`
-	Model   string `mapstructure:"model"`
-	BaseURL string `mapstructure:"base_url"`
+	APIKey            string `mapstructure:"api_key"`
+	Model             string `mapstructure:"model"`
+	BaseURL           string `mapstructure:"base_url"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
 }
 
 type AnthropicConfig struct {
-	APIKey string `mapstructure:"api_key"`
-	Model  string `mapstructure:"model"`
+	APIKey            string `mapstructure:"api_key"`
+	Model             string `mapstructure:"model"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
+}
+
+type GeminiConfig struct {
+	APIKey            string `mapstructure:"api_key"`
+	Model             string `mapstructure:"model"`
+	BaseURL           string `mapstructure:"base_url"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
 }
 
 type LocalConfig struct {
-	BaseURL string `mapstructure:"base_url"`
-	Model   string `mapstructure:"model"`
+	BaseURL           string `mapstructure:"base_url"`
+	Model             string `mapstructure:"model"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
+}
+
+// RetryConfig controls retrying LLM calls that hit rate limits, overloaded
+// servers or dropped connections.
+type RetryConfig struct {
+	// MaxAttempts counts the first try; 1 turns retries off
+	MaxAttempts int `mapstructure:"max_attempts"`
+	BaseDelayMs int `mapstructure:"base_delay_ms"`
+	MaxDelayMs  int `mapstructure:"max_delay_ms"`
 }
 
 type SearchConfig struct {
 	Tavily  TavilyConfig  `mapstructure:"tavily"`
 	RSS     RSSConfig     `mapstructure:"rss"`
 	Scraper ScraperConfig `mapstructure:"scraper"`
+	Enrich  EnrichConfig  `mapstructure:"enrich"`
 }
 
 type TavilyConfig struct {
-	APIKey string `mapstructure:"api_key"`
+	APIKey            string `mapstructure:"api_key"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
 }
 
 type RSSConfig struct {
-	Feeds []string `mapstructure:"feeds"`
+	Feeds []string          `mapstructure:"feeds"`
+	Named map[string]string `mapstructure:"named"`
 }
 
 type ScraperConfig struct {
 	UserAgent     string `mapstructure:"user_agent"`
 	Timeout       int    `mapstructure:"timeout"`
 	RespectRobots bool   `mapstructure:"respect_robots"`
+	// DomainDelayMs is the least time between requests to one site
+	DomainDelayMs int `mapstructure:"domain_delay_ms"`
+}
+
+// EnrichConfig controls fetching the full article behind search results.
+type EnrichConfig struct {
+	// Enabled turns enrichment on for ask and chat by default
+	Enabled     bool `mapstructure:"enabled"`
+	Concurrency int  `mapstructure:"concurrency"`
+	PerDomain   int  `mapstructure:"per_domain"`
+	Timeout     int  `mapstructure:"timeout"`
+	MaxContent  int  `mapstructure:"max_content"`
 }
 
 type ScheduleConfig struct {
@@ -71,6 +113,52 @@ type ScheduleConfig struct {
 	WeeklyDay        int    `mapstructure:"weekly_day"`
 }
 
+type RefreshConfig struct {
+	Concurrency         int `mapstructure:"concurrency"`
+	CurationConcurrency int `mapstructure:"curation_concurrency"`
+	CurationBatchSize   int `mapstructure:"curation_batch_size"`
+}
+
+// CacheConfig controls the on-disk cache of search results, pages and feeds
+// under general.cache_dir. TTLs are in minutes.
+type CacheConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	MaxSizeMB int  `mapstructure:"max_size_mb"`
+	SearchTTL int  `mapstructure:"search_ttl"`
+	PageTTL   int  `mapstructure:"page_ttl"`
+	FeedTTL   int  `mapstructure:"feed_ttl"`
+}
+
+// UsageConfig controls how recorded LLM usage is priced and limited.
+type UsageConfig struct {
+	// Prices add to and override the built-in price table
+	Prices []PriceConfig `mapstructure:"prices"`
+	Budget BudgetConfig  `mapstructure:"budget"`
+}
+
+// PriceConfig is what a model costs in US dollars per million tokens. Model
+// matches by prefix.
+type PriceConfig struct {
+	Model      string  `mapstructure:"model"`
+	Prompt     float64 `mapstructure:"prompt"`
+	Completion float64 `mapstructure:"completion"`
+}
+
+// BudgetConfig caps what LLM calls may spend per local calendar day and
+// month, in US dollars or tokens. Zero means no limit.
+type BudgetConfig struct {
+	DailyCost     float64 `mapstructure:"daily_cost"`
+	MonthlyCost   float64 `mapstructure:"monthly_cost"`
+	DailyTokens   int64   `mapstructure:"daily_tokens"`
+	MonthlyTokens int64   `mapstructure:"monthly_tokens"`
+	// OnExceed is what a refresh that would go over does: skip_summaries,
+	// cheaper_model or stop
+	OnExceed string `mapstructure:"on_exceed"`
+	// CheapModel is the first provider's model to curate with under
+	// cheaper_model
+	CheapModel string `mapstructure:"cheap_model"`
+}
+
 var cfg *Config
 
 func Get() *Config {
@@ -101,6 +189,7 @@ func Load(configPath string) (*Config, error) {
 	// Map environment variables
 	_ = viper.BindEnv("providers.openai.api_key", "TERMFLOW_OPENAI_API_KEY")
 	_ = viper.BindEnv("providers.anthropic.api_key", "TERMFLOW_ANTHROPIC_API_KEY")
+	_ = viper.BindEnv("providers.gemini.api_key", "TERMFLOW_GEMINI_API_KEY")
 	_ = viper.BindEnv("search.tavily.api_key", "TERMFLOW_TAVILY_API_KEY")
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -126,16 +215,45 @@ func setDefaults() {
 	viper.SetDefault("providers.openai.model", DefaultOpenAIModel)
 	viper.SetDefault("providers.openai.base_url", DefaultOpenAIBaseURL)
 	viper.SetDefault("providers.anthropic.model", DefaultAnthropicModel)
+	viper.SetDefault("providers.gemini.model", DefaultGeminiModel)
+	viper.SetDefault("providers.gemini.base_url", DefaultGeminiBaseURL)
 	viper.SetDefault("providers.local.base_url", DefaultLocalBaseURL)
 	viper.SetDefault("providers.local.model", DefaultLocalModel)
+	viper.SetDefault("providers.openai.requests_per_minute", DefaultOpenAIRequestsPerMinute)
+	viper.SetDefault("providers.anthropic.requests_per_minute", DefaultAnthropicRequestsPerMinute)
+	viper.SetDefault("providers.gemini.requests_per_minute", DefaultGeminiRequestsPerMinute)
+	viper.SetDefault("providers.retry.max_attempts", DefaultRetryMaxAttempts)
+	viper.SetDefault("providers.retry.base_delay_ms", DefaultRetryBaseDelayMs)
+	viper.SetDefault("providers.retry.max_delay_ms", DefaultRetryMaxDelayMs)
+
+	viper.SetDefault("search.tavily.requests_per_minute", DefaultTavilyRequestsPerMinute)
 
 	viper.SetDefault("search.scraper.user_agent", DefaultScraperUserAgent)
 	viper.SetDefault("search.scraper.timeout", DefaultScraperTimeout)
 	viper.SetDefault("search.scraper.respect_robots", DefaultRespectRobots)
+	viper.SetDefault("search.scraper.domain_delay_ms", DefaultScraperDomainDelayMs)
+
+	viper.SetDefault("search.enrich.enabled", DefaultEnrichEnabled)
+	viper.SetDefault("search.enrich.concurrency", DefaultEnrichConcurrency)
+	viper.SetDefault("search.enrich.per_domain", DefaultEnrichPerDomain)
+	viper.SetDefault("search.enrich.timeout", DefaultEnrichTimeout)
+	viper.SetDefault("search.enrich.max_content", DefaultEnrichMaxContent)
 
 	viper.SetDefault("schedule.default_frequency", DefaultFrequency)
 	viper.SetDefault("schedule.daily_time", DefaultDailyTime)
 	viper.SetDefault("schedule.weekly_day", DefaultWeeklyDay)
+
+	viper.SetDefault("refresh.concurrency", DefaultRefreshConcurrency)
+	viper.SetDefault("refresh.curation_concurrency", DefaultCurationConcurrency)
+	viper.SetDefault("refresh.curation_batch_size", DefaultCurationBatchSize)
+
+	viper.SetDefault("cache.enabled", DefaultCacheEnabled)
+	viper.SetDefault("cache.max_size_mb", DefaultCacheMaxSizeMB)
+	viper.SetDefault("cache.search_ttl", DefaultCacheSearchTTL)
+	viper.SetDefault("cache.page_ttl", DefaultCachePageTTL)
+	viper.SetDefault("cache.feed_ttl", DefaultCacheFeedTTL)
+
+	viper.SetDefault("usage.budget.on_exceed", DefaultBudgetOnExceed)
 }
 
 func GetConfigPath() string {
@@ -91,6 +91,33 @@ func TestLoadWithDefaults(t *testing.T) {
 	if c.Schedule.DefaultFrequency != DefaultFrequency {
 		t.Errorf("DefaultFrequency = %q, want %q", c.Schedule.DefaultFrequency, DefaultFrequency)
 	}
+	if c.Refresh.Concurrency != DefaultRefreshConcurrency {
+		t.Errorf("Refresh.Concurrency = %d, want %d", c.Refresh.Concurrency, DefaultRefreshConcurrency)
+	}
+	if c.Refresh.CurationConcurrency != DefaultCurationConcurrency {
+		t.Errorf("Refresh.CurationConcurrency = %d, want %d", c.Refresh.CurationConcurrency, DefaultCurationConcurrency)
+	}
+	if c.Refresh.CurationBatchSize != DefaultCurationBatchSize {
+		t.Errorf("Refresh.CurationBatchSize = %d, want %d", c.Refresh.CurationBatchSize, DefaultCurationBatchSize)
+	}
+	if c.Providers.Anthropic.RequestsPerMinute != DefaultAnthropicRequestsPerMinute {
+		t.Errorf("Anthropic.RequestsPerMinute = %d, want %d", c.Providers.Anthropic.RequestsPerMinute, DefaultAnthropicRequestsPerMinute)
+	}
+	if c.Providers.Gemini.Model != DefaultGeminiModel || c.Providers.Gemini.BaseURL != DefaultGeminiBaseURL {
+		t.Errorf("Providers.Gemini = %+v, want model %q at %q", c.Providers.Gemini, DefaultGeminiModel, DefaultGeminiBaseURL)
+	}
+	if c.Providers.Retry.MaxAttempts != DefaultRetryMaxAttempts || c.Providers.Retry.MaxDelayMs != DefaultRetryMaxDelayMs {
+		t.Errorf("Providers.Retry = %+v, want max_attempts %d and max_delay_ms %d", c.Providers.Retry, DefaultRetryMaxAttempts, DefaultRetryMaxDelayMs)
+	}
+	if c.Search.Enrich.Enabled || c.Search.Enrich.PerDomain != DefaultEnrichPerDomain || c.Search.Enrich.MaxContent != DefaultEnrichMaxContent {
+		t.Errorf("Search.Enrich = %+v, want disabled with per_domain %d and max_content %d", c.Search.Enrich, DefaultEnrichPerDomain, DefaultEnrichMaxContent)
+	}
+	if !c.Cache.Enabled || c.Cache.MaxSizeMB != DefaultCacheMaxSizeMB || c.Cache.PageTTL != DefaultCachePageTTL {
+		t.Errorf("Cache = %+v, want enabled with max_size_mb %d and page_ttl %d", c.Cache, DefaultCacheMaxSizeMB, DefaultCachePageTTL)
+	}
+	if c.Usage.Budget.OnExceed != DefaultBudgetOnExceed || c.Usage.Budget.DailyCost != 0 {
+		t.Errorf("Usage.Budget = %+v, want no limits and on_exceed %q", c.Usage.Budget, DefaultBudgetOnExceed)
+	}
 }
 
 func TestLoadWithValues(t *testing.T) {
@@ -165,10 +192,12 @@ func TestLoadWithEnvOverrides(t *testing.T) {
 	// Set environment variables
 	os.Setenv("TERMFLOW_OPENAI_API_KEY", "env-openai-key")
 	os.Setenv("TERMFLOW_ANTHROPIC_API_KEY", "env-anthropic-key")
+	os.Setenv("TERMFLOW_GEMINI_API_KEY", "env-gemini-key")
 	os.Setenv("TERMFLOW_TAVILY_API_KEY", "env-tavily-key")
 	defer func() {
 		os.Unsetenv("TERMFLOW_OPENAI_API_KEY")
 		os.Unsetenv("TERMFLOW_ANTHROPIC_API_KEY")
+		os.Unsetenv("TERMFLOW_GEMINI_API_KEY")
 		os.Unsetenv("TERMFLOW_TAVILY_API_KEY")
 	}()
 
@@ -183,6 +212,9 @@ func TestLoadWithEnvOverrides(t *testing.T) {
 	if c.Providers.Anthropic.APIKey != "env-anthropic-key" {
 		t.Errorf("Anthropic.APIKey = %q, want %q", c.Providers.Anthropic.APIKey, "env-anthropic-key")
 	}
+	if c.Providers.Gemini.APIKey != "env-gemini-key" {
+		t.Errorf("Gemini.APIKey = %q, want %q", c.Providers.Gemini.APIKey, "env-gemini-key")
+	}
 	if c.Search.Tavily.APIKey != "env-tavily-key" {
 		t.Errorf("Tavily.APIKey = %q, want %q", c.Search.Tavily.APIKey, "env-tavily-key")
 	}
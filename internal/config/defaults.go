@@ -11,12 +11,44 @@ const (
 	DefaultOpenAIModel    = "gpt-4o"
 	DefaultOpenAIBaseURL  = "https://api.openai.com/v1"
 	DefaultAnthropicModel = "claude-sonnet-4-20250514"
+	DefaultGeminiModel    = "gemini-2.5-flash"
+	DefaultGeminiBaseURL  = "https://generativelanguage.googleapis.com/v1beta"
 	DefaultLocalBaseURL   = "http://localhost:11434/v1"
 	DefaultLocalModel     = "llama3"
 
-	DefaultScraperUserAgent = "termiflow/1.0"
-	DefaultScraperTimeout   = 30
-	DefaultRespectRobots    = true
+	// Requests per minute; 0 means unlimited
+	DefaultOpenAIRequestsPerMinute    = 500
+	DefaultAnthropicRequestsPerMinute = 50
+	DefaultGeminiRequestsPerMinute    = 60
+	DefaultTavilyRequestsPerMinute    = 100
+
+	DefaultRetryMaxAttempts = 4
+	DefaultRetryBaseDelayMs = 1000
+	DefaultRetryMaxDelayMs  = 30000
+
+	DefaultRefreshConcurrency  = 3
+	DefaultCurationConcurrency = 4
+	DefaultCurationBatchSize   = 10
+
+	DefaultScraperUserAgent     = "termiflow/1.0"
+	DefaultScraperTimeout       = 30
+	DefaultRespectRobots        = true
+	DefaultScraperDomainDelayMs = 500
+
+	DefaultEnrichEnabled     = false
+	DefaultEnrichConcurrency = 4
+	DefaultEnrichPerDomain   = 2
+	DefaultEnrichTimeout     = 10
+	DefaultEnrichMaxContent  = 4000
+
+	// Cache TTLs are in minutes
+	DefaultCacheEnabled   = true
+	DefaultCacheMaxSizeMB = 200
+	DefaultCacheSearchTTL = 60
+	DefaultCachePageTTL   = 1440
+	DefaultCacheFeedTTL   = 15
+
+	DefaultBudgetOnExceed = "skip_summaries"
 )
 
 func DefaultConfigDir() string {
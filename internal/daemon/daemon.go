@@ -0,0 +1,74 @@
+// Package daemon runs subscription refreshes in the background.
+package daemon
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/scheduler"
+)
+
+// RefreshFunc refreshes the subscriptions that are due.
+type RefreshFunc func(ctx context.Context) ([]*scheduler.RefreshResult, error)
+
+// Daemon runs a refresh pass immediately and then once per Interval until its
+// context is cancelled. A pass in progress is allowed to observe cancellation
+// through ctx; Run returns once it has stopped.
+type Daemon struct {
+	Interval time.Duration
+	Refresh  RefreshFunc
+	Logger   *log.Logger
+}
+
+func (d *Daemon) Run(ctx context.Context) error {
+	d.Logger.Printf("daemon started, checking every %s", d.Interval)
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			d.Logger.Printf("daemon stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single refresh pass and logs the outcome.
+func (d *Daemon) RunOnce(ctx context.Context) {
+	start := time.Now()
+
+	results, err := d.Refresh(ctx)
+
+	newItems, failed := 0, 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			d.Logger.Printf("refresh %q failed after %s: %v", r.Subscription.Topic, r.Duration.Round(time.Millisecond), r.Err)
+			continue
+		}
+		newItems += r.NewItems
+		d.Logger.Printf("refresh %q: %d new item(s) in %s", r.Subscription.Topic, r.NewItems, r.Duration.Round(time.Millisecond))
+		if r.Throttle != nil {
+			d.Logger.Printf("refresh %q %s", r.Subscription.Topic, r.Throttle)
+		}
+	}
+
+	if err != nil && ctx.Err() == nil {
+		d.Logger.Printf("refresh pass failed: %v", err)
+		return
+	}
+
+	if len(results) == 0 {
+		d.Logger.Printf("no subscriptions due")
+		return
+	}
+
+	d.Logger.Printf("refreshed %d subscription(s): %d new item(s), %d failed, took %s",
+		len(results), newItems, failed, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/scheduler"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	pid, err := ReadPID(path)
+	if err != nil {
+		t.Fatalf("ReadPID() error = %v", err)
+	}
+	if pid != os.Getpid() {
+		t.Errorf("ReadPID() = %d, want %d", pid, os.Getpid())
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := ReadPID(path); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("ReadPID() after release error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestAcquireLockReplacesStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+
+	// Our own PID shows up when a container restarts with the same PID
+	for _, contents := range []string{"garbage\n", fmt.Sprintf("%d\n", deadPID()), fmt.Sprintf("%d\n", os.Getpid())} {
+		os.WriteFile(path, []byte(contents), 0644)
+
+		lock, err := AcquireLock(path)
+		if err != nil {
+			t.Fatalf("AcquireLock() with stale file %q error = %v", contents, err)
+		}
+		lock.Release()
+	}
+}
+
+func TestAcquireLockHeldByOtherProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+	os.WriteFile(path, []byte(fmt.Sprintf("%d\n", os.Getppid())), 0644)
+
+	_, err := AcquireLock(path)
+	var running *RunningError
+	if !errors.As(err, &running) || running.PID != os.Getppid() {
+		t.Errorf("AcquireLock() error = %v, want RunningError for pid %d", err, os.Getppid())
+	}
+}
+
+// deadPID returns a PID that is very unlikely to belong to a live process.
+func deadPID() int {
+	return 1<<22 - 1
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDaemonRunUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+
+	passes := 0
+	d := &Daemon{
+		Interval: 10 * time.Millisecond,
+		Logger:   log.New(&out, "", 0),
+		Refresh: func(ctx context.Context) ([]*scheduler.RefreshResult, error) {
+			passes++
+			if passes == 3 {
+				cancel()
+			}
+			return []*scheduler.RefreshResult{
+				{Subscription: &models.Subscription{Topic: "rust"}, NewItems: 2},
+				{Subscription: &models.Subscription{Topic: "chips"}, Err: errors.New("tavily down")},
+			}, nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() did not stop after cancellation")
+	}
+
+	if passes != 3 {
+		t.Errorf("passes = %d, want 3", passes)
+	}
+
+	logged := out.String()
+	for _, want := range []string{
+		`refresh "rust": 2 new item(s)`,
+		`refresh "chips" failed`,
+		"tavily down",
+		"refreshed 2 subscription(s): 2 new item(s), 1 failed",
+		"daemon stopped",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log missing %q:\n%s", want, logged)
+		}
+	}
+}
+
+func TestDaemonRunOnceNothingDue(t *testing.T) {
+	var out syncBuffer
+	d := &Daemon{
+		Logger: log.New(&out, "", 0),
+		Refresh: func(ctx context.Context) ([]*scheduler.RefreshResult, error) {
+			return nil, nil
+		},
+	}
+
+	d.RunOnce(context.Background())
+
+	if !strings.Contains(out.String(), "no subscriptions due") {
+		t.Errorf("log = %q, want nothing-due message", out.String())
+	}
+}
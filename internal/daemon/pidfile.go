@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotRunning is returned when no live daemon owns the PID file.
+var ErrNotRunning = errors.New("daemon is not running")
+
+// RunningError is returned by AcquireLock when another daemon holds the lock.
+type RunningError struct {
+	PID int
+}
+
+func (e *RunningError) Error() string {
+	return fmt.Sprintf("daemon already running (pid %d)", e.PID)
+}
+
+// Lock is an exclusive PID file. Only one daemon can hold it at a time.
+type Lock struct {
+	path string
+}
+
+// AcquireLock creates the PID file at path. A file left behind by a daemon
+// that is no longer running is replaced.
+func AcquireLock(path string) (*Lock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		// In a restarted container the previous daemon may have had our PID
+		pid, err := ReadPID(path)
+		if err == nil && pid != os.Getpid() {
+			return nil, &RunningError{PID: pid}
+		}
+		if err == nil {
+			err = ErrNotRunning
+		}
+		if !errors.Is(err, ErrNotRunning) {
+			return nil, err
+		}
+
+		// Stale lock from a daemon that crashed or was killed
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("could not acquire lock %s", path)
+}
+
+// Release removes the PID file.
+func (l *Lock) Release() error {
+	err := os.Remove(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ReadPID returns the PID of the daemon recorded at path, or ErrNotRunning
+// when the file is missing, unreadable or names a process that has exited.
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, ErrNotRunning
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, ErrNotRunning
+	}
+
+	if !processAlive(pid) {
+		return 0, ErrNotRunning
+	}
+	return pid, nil
+}
@@ -17,20 +17,18 @@ func Init() error {
 		return err
 	}
 
-	dbPath := filepath.Join(config.GetDataDir(), "termiflow.db")
-	return Open(dbPath)
+	return Open(Path())
 }
 
-func Open(dbPath string) error {
-	var err error
-	db, err = sql.Open("sqlite", dbPath)
-	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
-	}
+// Path returns the location of the termiflow database.
+func Path() string {
+	return filepath.Join(config.GetDataDir(), "termiflow.db")
+}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return fmt.Errorf("failed to enable foreign keys: %w", err)
+// Open connects to the database and brings its schema up to date.
+func Open(dbPath string) error {
+	if err := Connect(dbPath); err != nil {
+		return err
 	}
 
 	// Run migrations
@@ -41,6 +39,25 @@ func Open(dbPath string) error {
 	return nil
 }
 
+// Connect opens the database without running migrations.
+func Connect(dbPath string) error {
+	// Pragmas in the DSN apply to every pooled connection. WAL and a busy
+	// timeout let the daemon and interactive commands share the file.
+	dsn := dbPath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+	var err error
+	db, err = sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return nil
+}
+
 func Get() *sql.DB {
 	return db
 }
@@ -1,9 +1,14 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/oluoyefeso/termiflow/pkg/models"
 )
@@ -44,6 +49,27 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+func TestOpenEnablesForeignKeys(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sub := &models.Subscription{Topic: "cascade", Frequency: "daily", IsActive: true}
+	CreateSubscription(sub)
+	CreateFeedItem(&models.FeedItem{SubscriptionID: sub.ID, Title: "child"})
+
+	// Use several connections so the pragma must apply to each of them
+	db.SetMaxIdleConns(0)
+	if _, err := db.Exec(`DELETE FROM subscriptions WHERE id = ?`, sub.ID); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM feed_items`).Scan(&count)
+	if count != 0 {
+		t.Errorf("feed_items count = %d, want items removed by cascade", count)
+	}
+}
+
 func TestGet(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -349,3 +375,862 @@ func TestSubscriptionWithSources(t *testing.T) {
 		t.Errorf("Sources length = %d, want 3", len(retrieved.Sources))
 	}
 }
+
+func TestSubscriptionWithCron(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sub := &models.Subscription{
+		Topic:     "cron-test",
+		Frequency: "cron",
+		Cron:      "0 7,18 * * 1-5",
+		IsActive:  true,
+	}
+	CreateSubscription(sub)
+
+	retrieved, err := GetSubscription("cron-test")
+	if err != nil {
+		t.Fatalf("GetSubscription() error = %v", err)
+	}
+	if retrieved.Cron != "0 7,18 * * 1-5" {
+		t.Errorf("Cron = %q, want %q", retrieved.Cron, "0 7,18 * * 1-5")
+	}
+
+	retrieved.Cron = "@hourly"
+	UpdateSubscription(retrieved)
+
+	subs, _ := GetActiveSubscriptions()
+	if len(subs) != 1 || subs[0].Cron != "@hourly" {
+		t.Errorf("GetActiveSubscriptions() cron = %+v, want updated expression", subs)
+	}
+}
+
+func TestSubscriptionFeeds(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sub := &models.Subscription{Topic: "rust async", Frequency: "daily", IsActive: true}
+	CreateSubscription(sub)
+
+	for _, u := range []string{"https://without.boats/index.xml", "https://tokio.rs/blog/feed.xml"} {
+		if err := AddSubscriptionFeed(&models.SubscriptionFeed{SubscriptionID: sub.ID, URL: u}); err != nil {
+			t.Fatalf("AddSubscriptionFeed() error = %v", err)
+		}
+	}
+
+	err := AddSubscriptionFeed(&models.SubscriptionFeed{SubscriptionID: sub.ID, URL: "https://tokio.rs/blog/feed.xml"})
+	if !errors.Is(err, ErrFeedExists) {
+		t.Errorf("AddSubscriptionFeed() duplicate error = %v, want %v", err, ErrFeedExists)
+	}
+
+	feeds, err := GetSubscriptionFeeds(sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscriptionFeeds() error = %v", err)
+	}
+	if len(feeds) != 2 || feeds[0].URL != "https://without.boats/index.xml" {
+		t.Fatalf("GetSubscriptionFeeds() = %+v, want both feeds in insertion order", feeds)
+	}
+
+	removed, err := RemoveSubscriptionFeed(sub.ID, "https://without.boats/index.xml")
+	if err != nil || !removed {
+		t.Errorf("RemoveSubscriptionFeed() = %v, %v, want true, nil", removed, err)
+	}
+	removed, _ = RemoveSubscriptionFeed(sub.ID, "https://without.boats/index.xml")
+	if removed {
+		t.Error("RemoveSubscriptionFeed() removed a feed twice")
+	}
+
+	// Feeds go with their subscription
+	DeleteSubscription("rust async")
+	var count int
+	Get().QueryRow(`SELECT COUNT(*) FROM subscription_feeds`).Scan(&count)
+	if count != 0 {
+		t.Errorf("subscription_feeds has %d rows after unsubscribing, want 0", count)
+	}
+}
+
+func TestCreateSubscriptionWithFeeds(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	feeds := []string{"https://without.boats/index.xml", "https://tokio.rs/blog/feed.xml", "https://tokio.rs/blog/feed.xml"}
+	sub := &models.Subscription{Topic: "rust async", Frequency: "daily", IsActive: true}
+	if err := CreateSubscriptionWithFeeds(sub, feeds); err != nil {
+		t.Fatalf("CreateSubscriptionWithFeeds() error = %v", err)
+	}
+
+	got, err := GetSubscriptionFeeds(sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscriptionFeeds() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("GetSubscriptionFeeds() = %+v, want 2 feeds", got)
+	}
+
+	// A feed that can't be stored leaves no subscription behind
+	_, err = Get().Exec(`CREATE TRIGGER reject_feed BEFORE INSERT ON subscription_feeds
+		WHEN NEW.url = 'bad' BEGIN SELECT RAISE(ABORT, 'rejected'); END`)
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	sub = &models.Subscription{Topic: "zig", Frequency: "daily", IsActive: true}
+	if err := CreateSubscriptionWithFeeds(sub, []string{"https://ziglang.org/news/index.xml", "bad"}); err == nil {
+		t.Fatal("CreateSubscriptionWithFeeds() should fail when a feed does")
+	}
+	if _, err := GetSubscription("zig"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetSubscription() error = %v, want %v", err, sql.ErrNoRows)
+	}
+	var count int
+	Get().QueryRow(`SELECT COUNT(*) FROM subscription_feeds`).Scan(&count)
+	if count != 2 {
+		t.Errorf("subscription_feeds has %d rows, want 2", count)
+	}
+}
+
+func TestFeedStateStore(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sub := &models.Subscription{Topic: "rust async", Frequency: "daily", IsActive: true}
+	CreateSubscription(sub)
+
+	url := "https://without.boats/index.xml"
+	store := NewFeedStateStore(sub.ID)
+
+	state, err := store.LoadFeedState(url)
+	if err != nil || state != nil {
+		t.Fatalf("LoadFeedState() = %+v, %v, want nil, nil for a new feed", state, err)
+	}
+
+	checked := time.Date(2024, 6, 12, 9, 0, 0, 0, time.UTC)
+	store.SaveFeedState(&models.FeedState{URL: url, ETag: `"v1"`, GUIDs: []string{"a", "b"}, CheckedAt: checked})
+
+	// Saved state is visible to the same store but not written until Commit
+	if state, _ := store.LoadFeedState(url); state == nil || state.ETag != `"v1"` {
+		t.Errorf("LoadFeedState() before Commit = %+v, want pending state", state)
+	}
+	if state, _ := NewFeedStateStore(sub.ID).LoadFeedState(url); state != nil {
+		t.Errorf("LoadFeedState() from another store before Commit = %+v, want nil", state)
+	}
+
+	if err := store.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	state, err = NewFeedStateStore(sub.ID).LoadFeedState(url)
+	if err != nil {
+		t.Fatalf("LoadFeedState() error = %v", err)
+	}
+	if state.ETag != `"v1"` || strings.Join(state.GUIDs, ",") != "a,b" || !state.CheckedAt.Equal(checked) {
+		t.Errorf("LoadFeedState() after Commit = %+v", state)
+	}
+
+	// State is per subscription
+	other := &models.Subscription{Topic: "rust", Frequency: "daily", IsActive: true}
+	CreateSubscription(other)
+	if state, _ := NewFeedStateStore(other.ID).LoadFeedState(url); state != nil {
+		t.Errorf("LoadFeedState() for another subscription = %+v, want nil", state)
+	}
+
+	// Updating replaces the row
+	store.SaveFeedState(&models.FeedState{URL: url, ETag: `"v2"`, GUIDs: []string{"c"}})
+	store.Commit()
+	if state, _ := NewFeedStateStore(sub.ID).LoadFeedState(url); state.ETag != `"v2"` || len(state.GUIDs) != 1 {
+		t.Errorf("LoadFeedState() after update = %+v", state)
+	}
+}
+
+func TestUsageLedger(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	records := []*models.UsageRecord{
+		{Provider: "openai", Model: "gpt-4o", Purpose: "summarize", Topic: "rust", PromptTokens: 1000, CompletionTokens: 200, Cost: 0.0045, CreatedAt: yesterday},
+		{Provider: "openai", Model: "gpt-4o", Purpose: "score", Topic: "rust", PromptTokens: 500, CompletionTokens: 10, Cost: 0.001, CreatedAt: today},
+		{Provider: "anthropic", Model: "claude-sonnet-4", Purpose: "ask", PromptTokens: 2000, CompletionTokens: 800, Estimated: true, Cost: 0.018, CreatedAt: today},
+		{Provider: "openai", Model: "gpt-4o", Purpose: "ask", PromptTokens: 1, CompletionTokens: 1, CreatedAt: today.AddDate(0, -2, 0)},
+	}
+	for _, r := range records {
+		if err := (UsageLedger{}).RecordUsage(r); err != nil {
+			t.Fatalf("RecordUsage() error = %v", err)
+		}
+		if r.ID == 0 {
+			t.Errorf("RecordUsage() didn't set ID")
+		}
+	}
+
+	since := today.AddDate(0, -1, 0)
+	tests := []struct {
+		by   string
+		want map[string][2]int64 // calls, total tokens
+	}{
+		{"day", map[string][2]int64{
+			yesterday.Format("2006-01-02"): {1, 1200},
+			today.Format("2006-01-02"):     {2, 3310},
+		}},
+		{"topic", map[string][2]int64{"rust": {2, 1710}, "": {1, 2800}}},
+		{"provider", map[string][2]int64{"openai": {2, 1710}, "anthropic": {1, 2800}}},
+		{"purpose", map[string][2]int64{"summarize": {1, 1200}, "score": {1, 510}, "ask": {1, 2800}}},
+	}
+
+	for _, tt := range tests {
+		summaries, err := SummarizeUsage(tt.by, since)
+		if err != nil {
+			t.Fatalf("SummarizeUsage(%q) error = %v", tt.by, err)
+		}
+		got := make(map[string][2]int64)
+		for _, s := range summaries {
+			got[s.Key] = [2]int64{int64(s.Calls), s.TotalTokens()}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("SummarizeUsage(%q) = %v, want %v", tt.by, got, tt.want)
+			continue
+		}
+		for key, want := range tt.want {
+			if got[key] != want {
+				t.Errorf("SummarizeUsage(%q)[%q] = %v, want %v", tt.by, key, got[key], want)
+			}
+		}
+	}
+
+	// Days are newest first, everything else by cost
+	if summaries, _ := SummarizeUsage("day", since); summaries[0].Key != today.Format("2006-01-02") {
+		t.Errorf("SummarizeUsage(day)[0] = %q, want today", summaries[0].Key)
+	}
+	if summaries, _ := SummarizeUsage("provider", since); summaries[0].Key != "anthropic" {
+		t.Errorf("SummarizeUsage(provider)[0] = %q, want anthropic", summaries[0].Key)
+	}
+
+	if _, err := SummarizeUsage("week", since); err == nil {
+		t.Error("SummarizeUsage(week) error = nil, want error")
+	}
+
+	startOfDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	if total, err := UsageSince("", startOfDay); err != nil || total.Calls != 2 || total.TotalTokens() != 3310 {
+		t.Errorf("UsageSince(today) = %+v, %v, want 2 calls and 3310 tokens", total, err)
+	}
+	if total, _ := UsageSince("rust", since); total.Calls != 2 || fmt.Sprintf("%.4f", total.Cost) != "0.0055" {
+		t.Errorf("UsageSince(rust) = %+v, want 2 calls costing $0.0055", total)
+	}
+	if total, _ := UsageSince("go", since); total.Calls != 0 || total.Cost != 0 {
+		t.Errorf("UsageSince(go) = %+v, want nothing", total)
+	}
+}
+
+func TestSubscriptionBudget(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sub := &models.Subscription{Topic: "rust", Frequency: "daily", IsActive: true, Budget: &models.Budget{DailyCost: 0.5}}
+	if err := CreateSubscription(sub); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	got, _ := GetSubscription("rust")
+	if got.Budget == nil || *got.Budget != *sub.Budget {
+		t.Errorf("Budget = %+v, want %+v", got.Budget, sub.Budget)
+	}
+
+	got.Budget = &models.Budget{MonthlyTokens: 1000000}
+	UpdateSubscription(got)
+	got, _ = GetSubscription("rust")
+	if got.Budget == nil || got.Budget.MonthlyTokens != 1000000 || got.Budget.DailyCost != 0 {
+		t.Errorf("Budget after update = %+v", got.Budget)
+	}
+
+	// An empty budget is no budget
+	got.Budget = &models.Budget{}
+	UpdateSubscription(got)
+	if subs, _ := GetAllSubscriptions(); subs[0].Budget != nil {
+		t.Errorf("Budget after clearing = %+v, want nil", subs[0].Budget)
+	}
+}
+
+func TestCreateQuery(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	q := &models.Query{
+		Query:            "what is webgpu?",
+		Response:         "WebGPU is a browser graphics API.",
+		Provider:         "openai",
+		Model:            "gpt-4o",
+		Sources:          []models.QuerySource{{Title: "WebGPU", URL: "https://gpuweb.github.io"}},
+		LatencyMs:        1200,
+		PromptTokens:     300,
+		CompletionTokens: 80,
+	}
+
+	if err := CreateQuery(q); err != nil {
+		t.Fatalf("CreateQuery() error = %v", err)
+	}
+	if q.ID == 0 {
+		t.Error("CreateQuery() should set ID")
+	}
+
+	retrieved, err := GetQuery(q.ID)
+	if err != nil {
+		t.Fatalf("GetQuery() error = %v", err)
+	}
+
+	if retrieved.Query != q.Query {
+		t.Errorf("Query = %q, want %q", retrieved.Query, q.Query)
+	}
+	if retrieved.Model != "gpt-4o" {
+		t.Errorf("Model = %q, want %q", retrieved.Model, "gpt-4o")
+	}
+	if retrieved.LatencyMs != 1200 {
+		t.Errorf("LatencyMs = %d, want 1200", retrieved.LatencyMs)
+	}
+	if retrieved.TotalTokens() != 380 {
+		t.Errorf("TotalTokens() = %d, want 380", retrieved.TotalTokens())
+	}
+	if len(retrieved.Sources) != 1 || retrieved.Sources[0].URL != "https://gpuweb.github.io" {
+		t.Errorf("Sources = %+v, want one gpuweb source", retrieved.Sources)
+	}
+}
+
+func TestGetQueryNotFound(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := GetQuery(999)
+	if err == nil {
+		t.Error("GetQuery() should return error for nonexistent id")
+	}
+}
+
+func TestGetQueries(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	CreateQuery(&models.Query{Query: "rust borrow checker", Response: "Ownership rules", Provider: "openai"})
+	CreateQuery(&models.Query{Query: "tsmc n3", Response: "A 3nm process", Provider: "anthropic"})
+	CreateQuery(&models.Query{Query: "intel 4", Response: "Compared to n3, ...", Provider: "local"})
+
+	all, err := GetQueries(QueryFilter{})
+	if err != nil {
+		t.Fatalf("GetQueries() error = %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("GetQueries() returned %d queries, want 3", len(all))
+	}
+	if all[0].Query != "intel 4" {
+		t.Errorf("GetQueries() first = %q, want newest first", all[0].Query)
+	}
+
+	matches, err := GetQueries(QueryFilter{Search: "n3"})
+	if err != nil {
+		t.Fatalf("GetQueries() with search error = %v", err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("GetQueries() search matched %d queries, want 2", len(matches))
+	}
+
+	limited, _ := GetQueries(QueryFilter{Limit: 1})
+	if len(limited) != 1 {
+		t.Errorf("GetQueries() with limit returned %d queries, want 1", len(limited))
+	}
+}
+
+func TestDeleteQuery(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	q := &models.Query{Query: "delete me", Provider: "openai"}
+	CreateQuery(q)
+
+	deleted, err := DeleteQuery(q.ID)
+	if err != nil {
+		t.Fatalf("DeleteQuery() error = %v", err)
+	}
+	if !deleted {
+		t.Error("DeleteQuery() should report deletion")
+	}
+
+	deleted, _ = DeleteQuery(q.ID)
+	if deleted {
+		t.Error("DeleteQuery() on missing id should report false")
+	}
+}
+
+func TestDeleteAllQueries(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	CreateQuery(&models.Query{Query: "one", Provider: "openai"})
+	CreateQuery(&models.Query{Query: "two", Provider: "openai"})
+
+	count, err := DeleteAllQueries()
+	if err != nil {
+		t.Fatalf("DeleteAllQueries() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("DeleteAllQueries() = %d, want 2", count)
+	}
+}
+
+func TestMigrationsAddQueryHistoryColumns(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "legacy.db")
+
+	// Simulate a database created before query_history gained extra columns
+	legacy, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	_, err = legacy.Exec(`CREATE TABLE query_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query TEXT NOT NULL,
+		response TEXT,
+		provider TEXT,
+		sources TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("create legacy table error = %v", err)
+	}
+	legacy.Exec(`INSERT INTO query_history (query, response, provider) VALUES ('old', 'answer', 'openai')`)
+	legacy.Close()
+
+	if err := Open(dbPath); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer Close()
+
+	queries, err := GetQueries(QueryFilter{})
+	if err != nil {
+		t.Fatalf("GetQueries() on migrated db error = %v", err)
+	}
+	if len(queries) != 1 || queries[0].Query != "old" {
+		t.Errorf("GetQueries() = %+v, want legacy row preserved", queries)
+	}
+}
+
+func TestQueryThreads(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first := &models.Query{Query: "what is WebGPU?", Provider: "openai"}
+	if err := CreateQuery(first); err != nil {
+		t.Fatalf("CreateQuery() error = %v", err)
+	}
+	if first.ThreadID != first.ID {
+		t.Errorf("ThreadID = %d, want new thread %d", first.ThreadID, first.ID)
+	}
+
+	CreateQuery(&models.Query{Query: "unrelated", Provider: "openai"})
+
+	followUp := &models.Query{Query: "and Safari?", Provider: "openai", ThreadID: first.ThreadID}
+	if err := CreateQuery(followUp); err != nil {
+		t.Fatalf("CreateQuery() follow-up error = %v", err)
+	}
+
+	thread, err := GetThread(first.ThreadID)
+	if err != nil {
+		t.Fatalf("GetThread() error = %v", err)
+	}
+	if len(thread) != 2 {
+		t.Fatalf("GetThread() returned %d turns, want 2", len(thread))
+	}
+	if thread[0].ID != first.ID || thread[1].ID != followUp.ID {
+		t.Error("GetThread() should return turns oldest first")
+	}
+
+	latest, err := GetLatestQuery()
+	if err != nil {
+		t.Fatalf("GetLatestQuery() error = %v", err)
+	}
+	if latest.ID != followUp.ID {
+		t.Errorf("GetLatestQuery() = #%d, want #%d", latest.ID, followUp.ID)
+	}
+}
+
+func TestGetLatestQueryEmpty(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := GetLatestQuery()
+	if err != sql.ErrNoRows {
+		t.Errorf("GetLatestQuery() error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func createSearchFixtures(t *testing.T) (*models.Subscription, *models.Subscription) {
+	t.Helper()
+
+	chips := &models.Subscription{Topic: "silicon-chips", Frequency: "daily", IsActive: true}
+	rust := &models.Subscription{Topic: "rust-lang", Frequency: "daily", IsActive: true}
+	CreateSubscription(chips)
+	CreateSubscription(rust)
+
+	err := CreateFeedItems([]*models.FeedItem{
+		{SubscriptionID: chips.ID, Title: "TSMC N3 yields improve", Summary: "Yields on the 3nm node are up", Tags: []string{"tsmc", "foundry"}},
+		{SubscriptionID: chips.ID, Title: "Intel 18A roadmap", Content: "Intel compares 18A against TSMC offerings", Tags: []string{"intel"}},
+		{SubscriptionID: rust.ID, Title: "Async closures stabilized", Summary: "Rust 1.85 ships async closures", Tags: []string{"rust", "async"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateFeedItems() error = %v", err)
+	}
+
+	return chips, rust
+}
+
+func TestSearchFeedItems(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	createSearchFixtures(t)
+
+	matches, err := SearchFeedItems(FeedSearchFilter{Query: "tsmc"})
+	if err != nil {
+		t.Fatalf("SearchFeedItems() error = %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("SearchFeedItems() returned %d matches, want 2", len(matches))
+	}
+	if matches[0].Item.Title != "TSMC N3 yields improve" {
+		t.Errorf("best match = %q, want title match ranked first", matches[0].Item.Title)
+	}
+	if matches[0].Rank < matches[1].Rank {
+		t.Error("matches should be ordered by descending rank")
+	}
+	if matches[0].Topic != "silicon-chips" {
+		t.Errorf("Topic = %q, want %q", matches[0].Topic, "silicon-chips")
+	}
+	if !strings.Contains(matches[0].Snippet, HighlightStart) {
+		t.Errorf("Snippet = %q, want highlighted terms", matches[0].Snippet)
+	}
+}
+
+func TestSearchFeedItemsStemming(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	createSearchFixtures(t)
+
+	matches, err := SearchFeedItems(FeedSearchFilter{Query: "closure stabilizing"})
+	if err != nil {
+		t.Fatalf("SearchFeedItems() error = %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("SearchFeedItems() returned %d matches, want porter stemming to match 1", len(matches))
+	}
+}
+
+func TestSearchFeedItemsFilters(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	createSearchFixtures(t)
+
+	byTopic, _ := SearchFeedItems(FeedSearchFilter{Query: "tsmc OR async", MatchAny: true, Topic: "rust-lang"})
+	if len(byTopic) != 1 {
+		t.Errorf("topic filter returned %d matches, want 1", len(byTopic))
+	}
+
+	byTag, _ := SearchFeedItems(FeedSearchFilter{Query: "tsmc", Tag: "intel"})
+	if len(byTag) != 1 || byTag[0].Item.Title != "Intel 18A roadmap" {
+		t.Errorf("tag filter returned %d matches, want the Intel item", len(byTag))
+	}
+
+	anyWord, _ := SearchFeedItems(FeedSearchFilter{Query: "yields async", MatchAny: true})
+	if len(anyWord) != 2 {
+		t.Errorf("MatchAny returned %d matches, want 2", len(anyWord))
+	}
+
+	allWords, _ := SearchFeedItems(FeedSearchFilter{Query: "yields async"})
+	if len(allWords) != 0 {
+		t.Errorf("default AND search returned %d matches, want 0", len(allWords))
+	}
+
+	past := time.Now().Add(-time.Hour)
+	recent, _ := SearchFeedItems(FeedSearchFilter{Query: "tsmc", Since: &past})
+	if len(recent) != 2 {
+		t.Errorf("since filter returned %d matches, want 2", len(recent))
+	}
+
+	future := time.Now().Add(24 * time.Hour)
+	since, _ := SearchFeedItems(FeedSearchFilter{Query: "tsmc", Since: &future})
+	if len(since) != 0 {
+		t.Errorf("since filter returned %d matches, want 0", len(since))
+	}
+}
+
+func TestSearchFeedItemsStaysInSync(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	createSearchFixtures(t)
+
+	matches, _ := SearchFeedItems(FeedSearchFilter{Query: "tsmc"})
+	target := matches[0].Item
+
+	if _, err := db.Exec(`UPDATE feed_items SET title = 'Samsung SF2 update' WHERE id = ?`, target.ID); err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+
+	renamed, _ := SearchFeedItems(FeedSearchFilter{Query: "samsung"})
+	if len(renamed) != 1 {
+		t.Errorf("search after update returned %d matches, want 1", len(renamed))
+	}
+
+	if _, err := db.Exec(`DELETE FROM feed_items WHERE id = ?`, target.ID); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+
+	deleted, _ := SearchFeedItems(FeedSearchFilter{Query: "samsung"})
+	if len(deleted) != 0 {
+		t.Errorf("search after delete returned %d matches, want 0", len(deleted))
+	}
+}
+
+func TestSearchFeedItemsIndexesExistingRows(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "legacy.db")
+
+	if err := Open(dbPath); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sub := &models.Subscription{Topic: "legacy", Frequency: "daily", IsActive: true}
+	CreateSubscription(sub)
+	CreateFeedItem(&models.FeedItem{SubscriptionID: sub.ID, Title: "WebGPU ships in Safari"})
+
+	// Drop the index as if the database predates full-text search
+	if _, err := Rollback(3); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	Close()
+
+	if err := Open(dbPath); err != nil {
+		t.Fatalf("re-Open() error = %v", err)
+	}
+	defer Close()
+
+	matches, err := SearchFeedItems(FeedSearchFilter{Query: "webgpu"})
+	if err != nil {
+		t.Fatalf("SearchFeedItems() error = %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("SearchFeedItems() returned %d matches, want existing row indexed", len(matches))
+	}
+}
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		text     string
+		matchAny bool
+		expected string
+	}{
+		{"tsmc n3", false, `"tsmc" "n3"`},
+		{"tsmc n3", true, `"tsmc" OR "n3"`},
+		{"rust*", false, `"rust"*`},
+		{`say "hi"`, false, `"say" "hi"`},
+		{"node.js AND", false, `"node.js" "AND"`},
+		{"   ", false, ""},
+	}
+
+	for _, tt := range tests {
+		got := buildMatchQuery(tt.text, tt.matchAny)
+		if got != tt.expected {
+			t.Errorf("buildMatchQuery(%q, %v) = %q, want %q", tt.text, tt.matchAny, got, tt.expected)
+		}
+	}
+}
+
+func openFixture(t *testing.T, name string) string {
+	t.Helper()
+
+	schema, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture error = %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "fixture.db")
+	fixture, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer fixture.Close()
+
+	if _, err := fixture.Exec(string(schema)); err != nil {
+		t.Fatalf("load fixture error = %v", err)
+	}
+
+	return dbPath
+}
+
+func TestMigrateFromBaselineFixture(t *testing.T) {
+	dbPath := openFixture(t, "baseline.sql")
+
+	if err := Open(dbPath); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer Close()
+
+	version, err := SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+	if version != LatestVersion() {
+		t.Errorf("SchemaVersion() = %d, want %d", version, LatestVersion())
+	}
+
+	sub, err := GetSubscription("silicon-chips")
+	if err != nil || sub == nil {
+		t.Fatalf("GetSubscription() = %v, %v, want fixture subscription", sub, err)
+	}
+
+	q, err := GetQuery(1)
+	if err != nil {
+		t.Fatalf("GetQuery() error = %v", err)
+	}
+	if q.ThreadID != 1 {
+		t.Errorf("ThreadID = %d, want backfilled to 1", q.ThreadID)
+	}
+
+	matches, err := SearchFeedItems(FeedSearchFilter{Query: "tsmc"})
+	if err != nil {
+		t.Fatalf("SearchFeedItems() error = %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("SearchFeedItems() returned %d matches, want fixture item indexed", len(matches))
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count)
+	if count != len(models.DefaultCategories) {
+		t.Errorf("categories = %d, want %d seeded", count, len(models.DefaultCategories))
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	applied, err := Migrate()
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("Migrate() applied %d migrations on an up-to-date database, want 0", len(applied))
+	}
+}
+
+func TestRollbackAndReapply(t *testing.T) {
+	dbPath := openFixture(t, "baseline.sql")
+
+	if err := Open(dbPath); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer Close()
+
+	reverted, err := Rollback(1)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if len(reverted) != LatestVersion()-1 {
+		t.Errorf("Rollback() reverted %d migrations, want %d", len(reverted), LatestVersion()-1)
+	}
+	if reverted[0].Version != LatestVersion() {
+		t.Errorf("Rollback() reverted version %d first, want newest", reverted[0].Version)
+	}
+
+	version, _ := SchemaVersion()
+	if version != 1 {
+		t.Errorf("SchemaVersion() after rollback = %d, want 1", version)
+	}
+
+	var columns int
+	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('query_history') WHERE name = 'thread_id'`).Scan(&columns)
+	if columns != 0 {
+		t.Error("thread_id column should be dropped by rollback")
+	}
+
+	applied, err := Migrate()
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(applied) != LatestVersion()-1 {
+		t.Errorf("Migrate() applied %d migrations, want %d", len(applied), LatestVersion()-1)
+	}
+
+	q, err := GetQuery(1)
+	if err != nil || q.Query != "what is CoWoS?" {
+		t.Errorf("GetQuery() = %v, %v, want fixture row preserved", q, err)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (999, 'from_the_future')`)
+
+	if _, err := Migrate(); err == nil {
+		t.Error("Migrate() should refuse a schema newer than the build")
+	}
+}
+
+func TestMigrationFailureIsRolledBack(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	original := migrations
+	defer func() { migrations = original }()
+
+	migrations = append(migrations[:len(migrations):len(migrations)], Migration{
+		Version: LatestVersion() + 1,
+		Name:    "broken",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE half_done (id INTEGER)`,
+				`THIS IS NOT SQL`,
+			)
+		},
+		Down: func(tx *sql.Tx) error { return nil },
+	})
+
+	if _, err := Migrate(); err == nil {
+		t.Fatal("Migrate() should fail on a broken migration")
+	}
+
+	version, _ := SchemaVersion()
+	if version != original[len(original)-1].Version {
+		t.Errorf("SchemaVersion() = %d, want unchanged", version)
+	}
+
+	var tables int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'`).Scan(&tables)
+	if tables != 0 {
+		t.Error("partial migration should be rolled back")
+	}
+}
+
+func TestGetMigrationStatus(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := Rollback(LatestVersion() - 1); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	statuses, err := GetMigrationStatus()
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() error = %v", err)
+	}
+	if len(statuses) != LatestVersion() {
+		t.Fatalf("GetMigrationStatus() returned %d entries, want %d", len(statuses), LatestVersion())
+	}
+
+	for _, st := range statuses[:len(statuses)-1] {
+		if st.AppliedAt == nil {
+			t.Errorf("migration %d should be applied", st.Version)
+		}
+	}
+	if statuses[len(statuses)-1].AppliedAt != nil {
+		t.Error("rolled back migration should be pending")
+	}
+}
@@ -178,53 +178,64 @@ func scanFeedItems(rows *sql.Rows) ([]*models.FeedItem, error) {
 	var items []*models.FeedItem
 
 	for rows.Next() {
-		var item models.FeedItem
-		var summary, content, sourceName, sourceURL, tags sql.NullString
-		var publishedAt sql.NullTime
-		var relevanceScore sql.NullFloat64
-
-		err := rows.Scan(
-			&item.ID,
-			&item.SubscriptionID,
-			&item.Title,
-			&summary,
-			&content,
-			&sourceName,
-			&sourceURL,
-			&publishedAt,
-			&item.FetchedAt,
-			&item.IsRead,
-			&relevanceScore,
-			&tags,
-		)
+		item, err := scanFeedItemRow(rows)
 		if err != nil {
 			return nil, err
 		}
+		items = append(items, item)
+	}
 
-		if summary.Valid {
-			item.Summary = summary.String
-		}
-		if content.Valid {
-			item.Content = content.String
-		}
-		if sourceName.Valid {
-			item.SourceName = sourceName.String
-		}
-		if sourceURL.Valid {
-			item.SourceURL = sourceURL.String
-		}
-		if publishedAt.Valid {
-			item.PublishedAt = &publishedAt.Time
-		}
-		if relevanceScore.Valid {
-			item.RelevanceScore = relevanceScore.Float64
-		}
-		if tags.Valid {
-			_ = item.SetTagsFromJSON(tags.String)
-		}
+	return items, rows.Err()
+}
 
-		items = append(items, &item)
+// scanFeedItemRow scans the standard feed item columns followed by any extra
+// columns selected alongside them.
+func scanFeedItemRow(rows *sql.Rows, extra ...interface{}) (*models.FeedItem, error) {
+	var item models.FeedItem
+	var summary, content, sourceName, sourceURL, tags sql.NullString
+	var publishedAt sql.NullTime
+	var relevanceScore sql.NullFloat64
+
+	dest := []interface{}{
+		&item.ID,
+		&item.SubscriptionID,
+		&item.Title,
+		&summary,
+		&content,
+		&sourceName,
+		&sourceURL,
+		&publishedAt,
+		&item.FetchedAt,
+		&item.IsRead,
+		&relevanceScore,
+		&tags,
+	}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
 	}
 
-	return items, rows.Err()
+	if summary.Valid {
+		item.Summary = summary.String
+	}
+	if content.Valid {
+		item.Content = content.String
+	}
+	if sourceName.Valid {
+		item.SourceName = sourceName.String
+	}
+	if sourceURL.Valid {
+		item.SourceURL = sourceURL.String
+	}
+	if publishedAt.Valid {
+		item.PublishedAt = &publishedAt.Time
+	}
+	if relevanceScore.Valid {
+		item.RelevanceScore = relevanceScore.Float64
+	}
+	if tags.Valid {
+		_ = item.SetTagsFromJSON(tags.String)
+	}
+
+	return &item, nil
 }
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// ErrFeedExists is returned when a subscription already has a feed URL.
+var ErrFeedExists = errors.New("feed already added")
+
+// AddSubscriptionFeed attaches a feed to a subscription.
+func AddSubscriptionFeed(feed *models.SubscriptionFeed) error {
+	result, err := db.Exec(`
+		INSERT INTO subscription_feeds (subscription_id, url, title)
+		VALUES (?, ?, ?)
+		ON CONFLICT (subscription_id, url) DO NOTHING
+	`, feed.SubscriptionID, feed.URL, feed.Title)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrFeedExists
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	feed.ID = id
+	return nil
+}
+
+// RemoveSubscriptionFeed detaches a feed and reports whether it was attached.
+func RemoveSubscriptionFeed(subID int64, url string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM subscription_feeds WHERE subscription_id = ? AND url = ?`, subID, url)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetSubscriptionFeeds returns a subscription's feeds in the order they were added.
+func GetSubscriptionFeeds(subID int64) ([]*models.SubscriptionFeed, error) {
+	rows, err := db.Query(`
+		SELECT id, subscription_id, url, title, created_at
+		FROM subscription_feeds WHERE subscription_id = ?
+		ORDER BY id
+	`, subID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []*models.SubscriptionFeed
+	for rows.Next() {
+		var feed models.SubscriptionFeed
+		var title sql.NullString
+
+		if err := rows.Scan(&feed.ID, &feed.SubscriptionID, &feed.URL, &title, &feed.CreatedAt); err != nil {
+			return nil, err
+		}
+		if title.Valid {
+			feed.Title = title.String
+		}
+
+		feeds = append(feeds, &feed)
+	}
+
+	return feeds, rows.Err()
+}
+
+// FeedStateStore loads and records feed state for one subscription. Saved
+// state is held in memory until Commit, so a refresh that fails before its
+// items are stored will see the same items again next time.
+type FeedStateStore struct {
+	subscriptionID int64
+
+	mu      sync.Mutex
+	pending map[string]*models.FeedState
+}
+
+func NewFeedStateStore(subscriptionID int64) *FeedStateStore {
+	return &FeedStateStore{
+		subscriptionID: subscriptionID,
+		pending:        make(map[string]*models.FeedState),
+	}
+}
+
+// LoadFeedState returns the state of a feed, or nil if it was never fetched.
+func (s *FeedStateStore) LoadFeedState(url string) (*models.FeedState, error) {
+	s.mu.Lock()
+	state, ok := s.pending[url]
+	s.mu.Unlock()
+	if ok {
+		return state, nil
+	}
+
+	row := db.QueryRow(`
+		SELECT url, etag, last_modified, guids, checked_at
+		FROM feed_state WHERE subscription_id = ? AND url = ?
+	`, s.subscriptionID, url)
+
+	var st models.FeedState
+	var etag, lastModified, guids sql.NullString
+	var checkedAt sql.NullTime
+
+	err := row.Scan(&st.URL, &etag, &lastModified, &guids, &checkedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st.ETag = etag.String
+	st.LastModified = lastModified.String
+	if checkedAt.Valid {
+		st.CheckedAt = checkedAt.Time
+	}
+	if guids.Valid && guids.String != "" {
+		if err := json.Unmarshal([]byte(guids.String), &st.GUIDs); err != nil {
+			return nil, fmt.Errorf("corrupt feed state for %s: %w", url, err)
+		}
+	}
+
+	return &st, nil
+}
+
+// SaveFeedState records state to be written by Commit.
+func (s *FeedStateStore) SaveFeedState(state *models.FeedState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[state.URL] = state
+	return nil
+}
+
+// Commit writes all saved state.
+func (s *FeedStateStore) Commit() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, state := range s.pending {
+		guids, err := json.Marshal(state.GUIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO feed_state (subscription_id, url, etag, last_modified, guids, checked_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (subscription_id, url) DO UPDATE SET
+				etag = excluded.etag,
+				last_modified = excluded.last_modified,
+				guids = excluded.guids,
+				checked_at = excluded.checked_at
+		`, s.subscriptionID, state.URL, state.ETag, state.LastModified, string(guids), state.CheckedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.pending = make(map[string]*models.FeedState)
+	return nil
+}
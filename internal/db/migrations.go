@@ -1,70 +1,299 @@
 package db
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
+// Migration is a numbered, reversible schema change. Up and Down run inside a
+// transaction together with the schema_migrations bookkeeping, so a failed
+// migration leaves the database at the previous version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+	Down    func(tx *sql.Tx) error
+}
+
+// MigrationStatus reports whether a known migration has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations must stay in version order. Never edit a migration that has been
+// released; add a new one instead.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS subscriptions (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					topic TEXT NOT NULL,
+					category TEXT,
+					frequency TEXT NOT NULL DEFAULT 'daily',
+					sources TEXT,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					last_fetched_at DATETIME,
+					is_active BOOLEAN DEFAULT 1,
+					UNIQUE(topic)
+				)`,
+
+				`CREATE TABLE IF NOT EXISTS feed_items (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					subscription_id INTEGER NOT NULL,
+					title TEXT NOT NULL,
+					summary TEXT,
+					content TEXT,
+					source_name TEXT,
+					source_url TEXT,
+					published_at DATETIME,
+					fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					is_read BOOLEAN DEFAULT 0,
+					relevance_score REAL,
+					tags TEXT,
+					FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+				)`,
+
+				`CREATE TABLE IF NOT EXISTS query_history (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					query TEXT NOT NULL,
+					response TEXT,
+					provider TEXT,
+					sources TEXT,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+
+				`CREATE TABLE IF NOT EXISTS categories (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name TEXT NOT NULL UNIQUE,
+					display_name TEXT,
+					description TEXT,
+					default_sources TEXT,
+					keywords TEXT
+				)`,
+
+				`CREATE INDEX IF NOT EXISTS idx_feed_items_subscription ON feed_items(subscription_id)`,
+				`CREATE INDEX IF NOT EXISTS idx_feed_items_fetched ON feed_items(fetched_at)`,
+				`CREATE INDEX IF NOT EXISTS idx_feed_items_read ON feed_items(is_read)`,
+				`CREATE INDEX IF NOT EXISTS idx_subscriptions_active ON subscriptions(is_active)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`DROP TABLE IF EXISTS feed_items`,
+				`DROP TABLE IF EXISTS query_history`,
+				`DROP TABLE IF EXISTS categories`,
+				`DROP TABLE IF EXISTS subscriptions`,
+			)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "query_history_metadata",
+		Up: func(tx *sql.Tx) error {
+			for _, col := range []struct{ name, definition string }{
+				{"model", "TEXT"},
+				{"latency_ms", "INTEGER DEFAULT 0"},
+				{"prompt_tokens", "INTEGER DEFAULT 0"},
+				{"completion_tokens", "INTEGER DEFAULT 0"},
+			} {
+				if err := addColumn(tx, "query_history", col.name, col.definition); err != nil {
+					return err
+				}
+			}
+			return execAll(tx,
+				`CREATE INDEX IF NOT EXISTS idx_query_history_created ON query_history(created_at)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`DROP INDEX IF EXISTS idx_query_history_created`,
+				`ALTER TABLE query_history DROP COLUMN model`,
+				`ALTER TABLE query_history DROP COLUMN latency_ms`,
+				`ALTER TABLE query_history DROP COLUMN prompt_tokens`,
+				`ALTER TABLE query_history DROP COLUMN completion_tokens`,
+			)
+		},
+	},
+	{
+		Version: 3,
+		Name:    "query_history_threads",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumn(tx, "query_history", "thread_id", "INTEGER"); err != nil {
+				return err
+			}
+			// Queries saved before threads existed each start their own thread
+			return execAll(tx,
+				`UPDATE query_history SET thread_id = id WHERE thread_id IS NULL`,
+				`CREATE INDEX IF NOT EXISTS idx_query_history_thread ON query_history(thread_id)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`DROP INDEX IF EXISTS idx_query_history_thread`,
+				`ALTER TABLE query_history DROP COLUMN thread_id`,
+			)
+		},
+	},
+	{
+		Version: 4,
+		Name:    "feed_items_fts",
+		// External-content FTS5 index kept in sync by triggers; existing
+		// items are indexed by the rebuild.
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE VIRTUAL TABLE IF NOT EXISTS feed_items_fts USING fts5(
+					title, summary, content, tags,
+					content='feed_items',
+					content_rowid='id',
+					tokenize='porter unicode61'
+				)`,
+
+				`CREATE TRIGGER IF NOT EXISTS feed_items_fts_insert AFTER INSERT ON feed_items BEGIN
+					INSERT INTO feed_items_fts(rowid, title, summary, content, tags)
+					VALUES (new.id, new.title, new.summary, new.content, new.tags);
+				END`,
+
+				`CREATE TRIGGER IF NOT EXISTS feed_items_fts_delete AFTER DELETE ON feed_items BEGIN
+					INSERT INTO feed_items_fts(feed_items_fts, rowid, title, summary, content, tags)
+					VALUES ('delete', old.id, old.title, old.summary, old.content, old.tags);
+				END`,
+
+				`CREATE TRIGGER IF NOT EXISTS feed_items_fts_update AFTER UPDATE OF title, summary, content, tags ON feed_items BEGIN
+					INSERT INTO feed_items_fts(feed_items_fts, rowid, title, summary, content, tags)
+					VALUES ('delete', old.id, old.title, old.summary, old.content, old.tags);
+					INSERT INTO feed_items_fts(rowid, title, summary, content, tags)
+					VALUES (new.id, new.title, new.summary, new.content, new.tags);
+				END`,
+
+				`INSERT INTO feed_items_fts(feed_items_fts) VALUES ('rebuild')`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`DROP TRIGGER IF EXISTS feed_items_fts_insert`,
+				`DROP TRIGGER IF EXISTS feed_items_fts_delete`,
+				`DROP TRIGGER IF EXISTS feed_items_fts_update`,
+				`DROP TABLE IF EXISTS feed_items_fts`,
+			)
+		},
+	},
+	{
+		Version: 5,
+		Name:    "subscription_cron",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "subscriptions", "cron", "TEXT")
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, `ALTER TABLE subscriptions DROP COLUMN cron`)
+		},
+	},
+	{
+		Version: 6,
+		Name:    "subscription_feeds",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS subscription_feeds (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					subscription_id INTEGER NOT NULL,
+					url TEXT NOT NULL,
+					title TEXT,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+					UNIQUE(subscription_id, url)
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, `DROP TABLE IF EXISTS subscription_feeds`)
+		},
+	},
+	{
+		// Feed state is kept per subscription because several subscriptions
+		// can read the same feed, and each must see every new item.
+		Version: 7,
+		Name:    "feed_state",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS feed_state (
+					subscription_id INTEGER NOT NULL,
+					url TEXT NOT NULL,
+					etag TEXT,
+					last_modified TEXT,
+					guids TEXT,
+					checked_at DATETIME,
+					PRIMARY KEY (subscription_id, url),
+					FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, `DROP TABLE IF EXISTS feed_state`)
+		},
+	},
+	{
+		Version: 8,
+		Name:    "llm_usage",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS llm_usage (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					provider TEXT NOT NULL,
+					model TEXT,
+					purpose TEXT NOT NULL,
+					topic TEXT,
+					prompt_tokens INTEGER NOT NULL DEFAULT 0,
+					completion_tokens INTEGER NOT NULL DEFAULT 0,
+					estimated BOOLEAN NOT NULL DEFAULT 0,
+					cost REAL NOT NULL DEFAULT 0,
+					created_at DATETIME NOT NULL
+				)`,
+				`CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage(created_at)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, `DROP TABLE IF EXISTS llm_usage`)
+		},
+	},
+	{
+		Version: 9,
+		Name:    "subscription_budget",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "subscriptions", "budget", "TEXT")
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, `ALTER TABLE subscriptions DROP COLUMN budget`)
+		},
+	},
+	{
+		Version: 10,
+		Name:    "query_history_incomplete",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "query_history", "incomplete", "BOOLEAN NOT NULL DEFAULT 0")
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, `ALTER TABLE query_history DROP COLUMN incomplete`)
+		},
+	},
+}
+
+// LatestVersion is the schema version this build expects.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// RunMigrations brings the schema up to date and seeds default data.
 func RunMigrations() error {
-	migrations := []string{
-		`CREATE TABLE IF NOT EXISTS subscriptions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			topic TEXT NOT NULL,
-			category TEXT,
-			frequency TEXT NOT NULL DEFAULT 'daily',
-			sources TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			last_fetched_at DATETIME,
-			is_active BOOLEAN DEFAULT 1,
-			UNIQUE(topic)
-		)`,
-
-		`CREATE TABLE IF NOT EXISTS feed_items (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			subscription_id INTEGER NOT NULL,
-			title TEXT NOT NULL,
-			summary TEXT,
-			content TEXT,
-			source_name TEXT,
-			source_url TEXT,
-			published_at DATETIME,
-			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			is_read BOOLEAN DEFAULT 0,
-			relevance_score REAL,
-			tags TEXT,
-			FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
-		)`,
-
-		`CREATE TABLE IF NOT EXISTS query_history (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			query TEXT NOT NULL,
-			response TEXT,
-			provider TEXT,
-			sources TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		`CREATE TABLE IF NOT EXISTS categories (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL UNIQUE,
-			display_name TEXT,
-			description TEXT,
-			default_sources TEXT,
-			keywords TEXT
-		)`,
-
-		`CREATE INDEX IF NOT EXISTS idx_feed_items_subscription ON feed_items(subscription_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_feed_items_fetched ON feed_items(fetched_at)`,
-		`CREATE INDEX IF NOT EXISTS idx_feed_items_read ON feed_items(is_read)`,
-		`CREATE INDEX IF NOT EXISTS idx_subscriptions_active ON subscriptions(is_active)`,
-	}
-
-	for _, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return err
-		}
+	if _, err := Migrate(); err != nil {
+		return err
 	}
 
 	// Seed default categories
@@ -75,6 +304,182 @@ func RunMigrations() error {
 	return nil
 }
 
+// Migrate applies all pending migrations in order and returns the ones applied.
+func Migrate() ([]Migration, error) {
+	current, err := SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if current > LatestVersion() {
+		return nil, fmt.Errorf("database schema version %d is newer than this build supports (%d) - upgrade termiflow", current, LatestVersion())
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		err := inTx(func(tx *sql.Tx) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+// Rollback reverts applied migrations, newest first, until the schema is at
+// the target version. It returns the migrations that were reverted.
+func Rollback(target int) ([]Migration, error) {
+	if target < 0 {
+		return nil, fmt.Errorf("invalid target version: %d", target)
+	}
+
+	current, err := SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+
+		err := inTx(func(tx *sql.Tx) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("rollback %d (%s): %w", m.Version, m.Name, err)
+		}
+
+		reverted = append(reverted, m)
+	}
+
+	return reverted, nil
+}
+
+// SchemaVersion returns the highest applied migration, or 0 for a new database.
+func SchemaVersion() (int, error) {
+	if err := ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// GetMigrationStatus lists every known migration with when it was applied.
+func GetMigrationStatus() ([]*MigrationStatus, error) {
+	if err := ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = &MigrationStatus{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+func ensureMigrationsTable() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+func inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func execAll(tx *sql.Tx, statements ...string) error {
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn is a no-op when the column already exists, so databases created
+// before versioned migrations upgrade cleanly.
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	return err
+}
+
 func seedCategories() error {
 	for _, cat := range models.DefaultCategories {
 		keywords, _ := json.Marshal(cat.Keywords)
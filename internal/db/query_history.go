@@ -0,0 +1,198 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+const queryColumns = `id, thread_id, query, response, provider, model, sources, latency_ms, prompt_tokens, completion_tokens, incomplete, created_at`
+
+func CreateQuery(q *models.Query) error {
+	result, err := db.Exec(`
+		INSERT INTO query_history (thread_id, query, response, provider, model, sources, latency_ms, prompt_tokens, completion_tokens, incomplete)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, nullableID(q.ThreadID), q.Query, q.Response, q.Provider, q.Model, q.GetSourcesJSON(), q.LatencyMs, q.PromptTokens, q.CompletionTokens, q.Incomplete)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	q.ID = id
+
+	// A query that doesn't continue a thread starts its own
+	if q.ThreadID == 0 {
+		q.ThreadID = id
+		_, err = db.Exec(`UPDATE query_history SET thread_id = ? WHERE id = ?`, id, id)
+	}
+	return err
+}
+
+func nullableID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func GetQuery(id int64) (*models.Query, error) {
+	rows, err := db.Query(`SELECT `+queryColumns+` FROM query_history WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queries, err := scanQueries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return queries[0], nil
+}
+
+// GetThread returns all queries in a thread, oldest first.
+func GetThread(threadID int64) ([]*models.Query, error) {
+	rows, err := db.Query(`
+		SELECT `+queryColumns+` FROM query_history
+		WHERE thread_id = ?
+		ORDER BY id ASC
+	`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanQueries(rows)
+}
+
+// GetLatestQuery returns the most recently saved query.
+func GetLatestQuery() (*models.Query, error) {
+	queries, err := GetQueries(QueryFilter{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return queries[0], nil
+}
+
+type QueryFilter struct {
+	Search string
+	Limit  int
+	Offset int
+}
+
+func GetQueries(filter QueryFilter) ([]*models.Query, error) {
+	query := `SELECT ` + queryColumns + ` FROM query_history WHERE 1=1`
+	args := []interface{}{}
+
+	if filter.Search != "" {
+		query += " AND (query LIKE ? OR response LIKE ?)"
+		pattern := "%" + filter.Search + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	query += " ORDER BY created_at DESC, id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanQueries(rows)
+}
+
+func DeleteQuery(id int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM query_history WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func DeleteAllQueries() (int64, error) {
+	result, err := db.Exec(`DELETE FROM query_history`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanQueries(rows *sql.Rows) ([]*models.Query, error) {
+	var queries []*models.Query
+
+	for rows.Next() {
+		var q models.Query
+		var threadID sql.NullInt64
+		var response, provider, model, sources sql.NullString
+		var latency, promptTokens, completionTokens sql.NullInt64
+
+		err := rows.Scan(
+			&q.ID,
+			&threadID,
+			&q.Query,
+			&response,
+			&provider,
+			&model,
+			&sources,
+			&latency,
+			&promptTokens,
+			&completionTokens,
+			&q.Incomplete,
+			&q.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if threadID.Valid {
+			q.ThreadID = threadID.Int64
+		} else {
+			q.ThreadID = q.ID
+		}
+		if response.Valid {
+			q.Response = response.String
+		}
+		if provider.Valid {
+			q.Provider = provider.String
+		}
+		if model.Valid {
+			q.Model = model.String
+		}
+		if sources.Valid {
+			_ = q.SetSourcesFromJSON(sources.String)
+		}
+		if latency.Valid {
+			q.LatencyMs = latency.Int64
+		}
+		if promptTokens.Valid {
+			q.PromptTokens = int(promptTokens.Int64)
+		}
+		if completionTokens.Valid {
+			q.CompletionTokens = int(completionTokens.Int64)
+		}
+
+		queries = append(queries, &q)
+	}
+
+	return queries, rows.Err()
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// Markers wrapped around matched terms in FeedItemMatch.Snippet.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+type FeedSearchFilter struct {
+	Query    string
+	MatchAny bool // match items containing any word instead of all words
+	Topic    string
+	Tag      string
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+}
+
+type FeedItemMatch struct {
+	Item    *models.FeedItem
+	Topic   string
+	Rank    float64 // higher is more relevant
+	Snippet string
+}
+
+// SearchFeedItems runs a full-text search over feed item titles, summaries,
+// content and tags, best matches first.
+func SearchFeedItems(filter FeedSearchFilter) ([]*FeedItemMatch, error) {
+	match := buildMatchQuery(filter.Query, filter.MatchAny)
+	if match == "" {
+		return nil, nil
+	}
+
+	// Title and tag hits count for more than body text
+	query := `
+		SELECT fi.id, fi.subscription_id, fi.title, fi.summary, fi.content,
+			   fi.source_name, fi.source_url, fi.published_at, fi.fetched_at,
+			   fi.is_read, fi.relevance_score, fi.tags,
+			   s.topic,
+			   bm25(feed_items_fts, 10.0, 5.0, 1.0, 3.0) AS rank,
+			   snippet(feed_items_fts, -1, ?, ?, '…', 16)
+		FROM feed_items_fts
+		JOIN feed_items fi ON fi.id = feed_items_fts.rowid
+		JOIN subscriptions s ON fi.subscription_id = s.id
+		WHERE feed_items_fts MATCH ?
+	`
+	args := []interface{}{HighlightStart, HighlightEnd, match}
+
+	if filter.Topic != "" {
+		query += " AND s.topic = ?"
+		args = append(args, filter.Topic)
+	}
+
+	if filter.Tag != "" {
+		query += " AND fi.tags LIKE ?"
+		args = append(args, `%"`+strings.ToLower(filter.Tag)+`"%`)
+	}
+
+	if filter.Since != nil {
+		query += " AND fi.fetched_at >= ?"
+		args = append(args, *filter.Since)
+	}
+
+	if filter.Until != nil {
+		query += " AND fi.fetched_at < ?"
+		args = append(args, *filter.Until)
+	}
+
+	query += " ORDER BY rank"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*FeedItemMatch
+	for rows.Next() {
+		var m FeedItemMatch
+		var snippet *string
+
+		item, err := scanFeedItemRow(rows, &m.Topic, &m.Rank, &snippet)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Item = item
+		m.Rank = -m.Rank // bm25 scores are negative, lower is better
+		if snippet != nil {
+			m.Snippet = *snippet
+		}
+		matches = append(matches, &m)
+	}
+
+	return matches, rows.Err()
+}
+
+// buildMatchQuery turns free text into an FTS5 query. Every word is quoted so
+// punctuation in user input can't break the query syntax, and a trailing *
+// is kept outside the quotes for prefix matching.
+func buildMatchQuery(text string, matchAny bool) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.Trim(word, `*"`)
+		if word == "" {
+			continue
+		}
+
+		term := `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+
+	if matchAny {
+		return strings.Join(terms, " OR ")
+	}
+	return strings.Join(terms, " ")
+}
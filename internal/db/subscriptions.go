@@ -7,11 +7,25 @@ import (
 	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
+const subscriptionColumns = `id, topic, category, frequency, cron, sources, budget, created_at, updated_at, last_fetched_at, is_active`
+
 func CreateSubscription(sub *models.Subscription) error {
-	result, err := db.Exec(`
-		INSERT INTO subscriptions (topic, category, frequency, sources, is_active)
-		VALUES (?, ?, ?, ?, ?)
-	`, sub.Topic, sub.Category, sub.Frequency, sub.GetSourcesJSON(), sub.IsActive)
+	return CreateSubscriptionWithFeeds(sub, nil)
+}
+
+// CreateSubscriptionWithFeeds adds a subscription and attaches feeds to it in
+// one transaction, so a failure leaves neither behind.
+func CreateSubscriptionWithFeeds(sub *models.Subscription, feeds []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`
+		INSERT INTO subscriptions (topic, category, frequency, cron, sources, budget, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, sub.Topic, sub.Category, sub.Frequency, sub.Cron, sub.GetSourcesJSON(), sub.GetBudgetJSON(), sub.IsActive)
 
 	if err != nil {
 		return err
@@ -21,13 +35,28 @@ func CreateSubscription(sub *models.Subscription) error {
 	if err != nil {
 		return err
 	}
+
+	for _, u := range feeds {
+		_, err := tx.Exec(`
+			INSERT INTO subscription_feeds (subscription_id, url, title)
+			VALUES (?, ?, ?)
+			ON CONFLICT (subscription_id, url) DO NOTHING
+		`, id, u, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	sub.ID = id
 	return nil
 }
 
 func GetSubscription(topic string) (*models.Subscription, error) {
 	row := db.QueryRow(`
-		SELECT id, topic, category, frequency, sources, created_at, updated_at, last_fetched_at, is_active
+		SELECT `+subscriptionColumns+`
 		FROM subscriptions WHERE topic = ?
 	`, topic)
 
@@ -36,7 +65,7 @@ func GetSubscription(topic string) (*models.Subscription, error) {
 
 func GetSubscriptionByID(id int64) (*models.Subscription, error) {
 	row := db.QueryRow(`
-		SELECT id, topic, category, frequency, sources, created_at, updated_at, last_fetched_at, is_active
+		SELECT `+subscriptionColumns+`
 		FROM subscriptions WHERE id = ?
 	`, id)
 
@@ -45,7 +74,7 @@ func GetSubscriptionByID(id int64) (*models.Subscription, error) {
 
 func GetActiveSubscriptions() ([]*models.Subscription, error) {
 	rows, err := db.Query(`
-		SELECT id, topic, category, frequency, sources, created_at, updated_at, last_fetched_at, is_active
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions WHERE is_active = 1
 		ORDER BY created_at DESC
 	`)
@@ -59,7 +88,7 @@ func GetActiveSubscriptions() ([]*models.Subscription, error) {
 
 func GetAllSubscriptions() ([]*models.Subscription, error) {
 	rows, err := db.Query(`
-		SELECT id, topic, category, frequency, sources, created_at, updated_at, last_fetched_at, is_active
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
 		ORDER BY created_at DESC
 	`)
@@ -75,9 +104,9 @@ func UpdateSubscription(sub *models.Subscription) error {
 	sub.UpdatedAt = time.Now()
 	_, err := db.Exec(`
 		UPDATE subscriptions
-		SET topic = ?, category = ?, frequency = ?, sources = ?, updated_at = ?, last_fetched_at = ?, is_active = ?
+		SET topic = ?, category = ?, frequency = ?, cron = ?, sources = ?, budget = ?, updated_at = ?, last_fetched_at = ?, is_active = ?
 		WHERE id = ?
-	`, sub.Topic, sub.Category, sub.Frequency, sub.GetSourcesJSON(), sub.UpdatedAt, sub.LastFetchedAt, sub.IsActive, sub.ID)
+	`, sub.Topic, sub.Category, sub.Frequency, sub.Cron, sub.GetSourcesJSON(), sub.GetBudgetJSON(), sub.UpdatedAt, sub.LastFetchedAt, sub.IsActive, sub.ID)
 	return err
 }
 
@@ -100,7 +129,9 @@ func UpdateLastFetched(id int64) error {
 func scanSubscription(row *sql.Row) (*models.Subscription, error) {
 	var sub models.Subscription
 	var sources sql.NullString
+	var budget sql.NullString
 	var category sql.NullString
+	var cronExpr sql.NullString
 	var lastFetched sql.NullTime
 
 	err := row.Scan(
@@ -108,7 +139,9 @@ func scanSubscription(row *sql.Row) (*models.Subscription, error) {
 		&sub.Topic,
 		&category,
 		&sub.Frequency,
+		&cronExpr,
 		&sources,
+		&budget,
 		&sub.CreatedAt,
 		&sub.UpdatedAt,
 		&lastFetched,
@@ -121,9 +154,15 @@ func scanSubscription(row *sql.Row) (*models.Subscription, error) {
 	if category.Valid {
 		sub.Category = category.String
 	}
+	if cronExpr.Valid {
+		sub.Cron = cronExpr.String
+	}
 	if sources.Valid {
 		_ = sub.SetSourcesFromJSON(sources.String)
 	}
+	if budget.Valid {
+		_ = sub.SetBudgetFromJSON(budget.String)
+	}
 	if lastFetched.Valid {
 		sub.LastFetchedAt = &lastFetched.Time
 	}
@@ -137,7 +176,9 @@ func scanSubscriptions(rows *sql.Rows) ([]*models.Subscription, error) {
 	for rows.Next() {
 		var sub models.Subscription
 		var sources sql.NullString
+		var budget sql.NullString
 		var category sql.NullString
+		var cronExpr sql.NullString
 		var lastFetched sql.NullTime
 
 		err := rows.Scan(
@@ -145,7 +186,9 @@ func scanSubscriptions(rows *sql.Rows) ([]*models.Subscription, error) {
 			&sub.Topic,
 			&category,
 			&sub.Frequency,
+			&cronExpr,
 			&sources,
+			&budget,
 			&sub.CreatedAt,
 			&sub.UpdatedAt,
 			&lastFetched,
@@ -158,9 +201,15 @@ func scanSubscriptions(rows *sql.Rows) ([]*models.Subscription, error) {
 		if category.Valid {
 			sub.Category = category.String
 		}
+		if cronExpr.Valid {
+			sub.Cron = cronExpr.String
+		}
 		if sources.Valid {
 			_ = sub.SetSourcesFromJSON(sources.String)
 		}
+		if budget.Valid {
+			_ = sub.SetBudgetFromJSON(budget.String)
+		}
 		if lastFetched.Valid {
 			sub.LastFetchedAt = &lastFetched.Time
 		}
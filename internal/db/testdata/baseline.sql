@@ -0,0 +1,62 @@
+-- Schema and sample data as created by termiflow before versioned migrations.
+
+CREATE TABLE subscriptions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic TEXT NOT NULL,
+	category TEXT,
+	frequency TEXT NOT NULL DEFAULT 'daily',
+	sources TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_fetched_at DATETIME,
+	is_active BOOLEAN DEFAULT 1,
+	UNIQUE(topic)
+);
+
+CREATE TABLE feed_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscription_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	summary TEXT,
+	content TEXT,
+	source_name TEXT,
+	source_url TEXT,
+	published_at DATETIME,
+	fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	is_read BOOLEAN DEFAULT 0,
+	relevance_score REAL,
+	tags TEXT,
+	FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE query_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	query TEXT NOT NULL,
+	response TEXT,
+	provider TEXT,
+	sources TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE categories (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	display_name TEXT,
+	description TEXT,
+	default_sources TEXT,
+	keywords TEXT
+);
+
+CREATE INDEX idx_feed_items_subscription ON feed_items(subscription_id);
+CREATE INDEX idx_feed_items_fetched ON feed_items(fetched_at);
+CREATE INDEX idx_feed_items_read ON feed_items(is_read);
+CREATE INDEX idx_subscriptions_active ON subscriptions(is_active);
+
+INSERT INTO subscriptions (id, topic, category, frequency, sources, is_active)
+VALUES (1, 'silicon-chips', 'silicon-chips', 'daily', '["rss"]', 1);
+
+INSERT INTO feed_items (id, subscription_id, title, summary, source_name, source_url, relevance_score, tags)
+VALUES (1, 1, 'TSMC begins N2 risk production', 'Gate-all-around transistors arrive', 'semianalysis', 'https://example.com/n2', 0.9, '["tsmc","n2"]');
+
+INSERT INTO query_history (id, query, response, provider, sources)
+VALUES (1, 'what is CoWoS?', 'A 2.5D packaging technology.', 'openai', '[]');
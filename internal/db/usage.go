@@ -0,0 +1,119 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// usageTimeLayout stores times in a form SQLite's date functions read, so
+// usage can be grouped by day.
+const usageTimeLayout = "2006-01-02 15:04:05"
+
+// RecordUsage adds an LLM call to the usage ledger.
+func RecordUsage(u *models.UsageRecord) error {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO llm_usage (provider, model, purpose, topic, prompt_tokens, completion_tokens, estimated, cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, u.Provider, u.Model, u.Purpose, nullableString(u.Topic), u.PromptTokens, u.CompletionTokens, u.Estimated, u.Cost, u.CreatedAt.UTC().Format(usageTimeLayout))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID = id
+	return nil
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// UsageLedger records LLM calls in the database.
+type UsageLedger struct{}
+
+func (UsageLedger) RecordUsage(u *models.UsageRecord) error {
+	return RecordUsage(u)
+}
+
+// usageGroups are the ways usage can be summarized, as SQL expressions.
+// Days are local calendar days.
+var usageGroups = map[string]string{
+	"day":      `date(created_at, 'localtime')`,
+	"topic":    `COALESCE(topic, '')`,
+	"provider": `provider`,
+	"model":    `provider || ' ' || COALESCE(model, '')`,
+	"purpose":  `purpose`,
+}
+
+// UsageGroups lists the groupings SummarizeUsage accepts.
+var UsageGroups = []string{"day", "topic", "provider", "model", "purpose"}
+
+// SummarizeUsage totals the calls made since a time by day, topic, provider,
+// model or purpose. Days are listed newest first; other groups by cost.
+func SummarizeUsage(by string, since time.Time) ([]*models.UsageSummary, error) {
+	key, ok := usageGroups[by]
+	if !ok {
+		return nil, fmt.Errorf("can't group usage by %q", by)
+	}
+
+	order := "cost DESC, key"
+	if by == "day" {
+		order = "key DESC"
+	}
+
+	rows, err := db.Query(`
+		SELECT `+key+` AS key, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost) AS cost
+		FROM llm_usage
+		WHERE created_at >= ?
+		GROUP BY key
+		ORDER BY `+order,
+		since.UTC().Format(usageTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*models.UsageSummary
+	for rows.Next() {
+		var s models.UsageSummary
+		if err := rows.Scan(&s.Key, &s.Calls, &s.PromptTokens, &s.CompletionTokens, &s.Cost); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &s)
+	}
+
+	return summaries, rows.Err()
+}
+
+// UsageSince totals the calls made since a time against a topic, or every
+// call when topic is empty.
+func UsageSince(topic string, since time.Time) (*models.UsageSummary, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost), 0)
+		FROM llm_usage
+		WHERE created_at >= ?`
+	args := []interface{}{since.UTC().Format(usageTimeLayout)}
+	if topic != "" {
+		query += ` AND topic = ?`
+		args = append(args, topic)
+	}
+
+	s := &models.UsageSummary{Key: topic}
+	err := db.QueryRow(query, args...).Scan(&s.Calls, &s.PromptTokens, &s.CompletionTokens, &s.Cost)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,360 @@
+// Package httpcache keeps HTTP responses on disk so searches, pages and feeds
+// that were fetched recently aren't fetched again, and so they can still be
+// read offline.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Namespaces group entries by what they hold, so each kind can have its own
+// TTL and be cleared on its own.
+const (
+	Search = "search"
+	Pages  = "pages"
+	Feeds  = "feeds"
+)
+
+// Namespaces lists every namespace in the order they're reported.
+var Namespaces = []string{Search, Pages, Feeds}
+
+// Cache is a content-addressed store of responses under a directory. Each
+// entry is a file named by the hash of its request. Once the files add up to
+// more than the size limit, the least recently used are removed.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	sized bool
+}
+
+// Open returns a cache in dir that holds at most maxBytes, or has no limit if
+// maxBytes is 0. The directory is created on the first write.
+func Open(dir string, maxBytes int64) *Cache {
+	return &Cache{dir: dir, maxBytes: maxBytes}
+}
+
+// Dir returns the directory the cache is stored in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// entry is a stored response. On disk it's a line of JSON followed by the
+// body, so listing entries doesn't mean reading every body.
+type entry struct {
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	StoredAt time.Time   `json:"stored_at"`
+	Expires  time.Time   `json:"expires"`
+
+	Body []byte `json:"-"`
+}
+
+func (e *entry) fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+// key hashes everything that identifies a request.
+func key(method, url string, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, method)
+	io.WriteString(h, " ")
+	io.WriteString(h, url)
+	io.WriteString(h, "\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(namespace, key string) string {
+	return filepath.Join(c.dir, namespace, key[:2], key+".json")
+}
+
+// get returns the entry stored under key, or nil if there isn't one. Reading
+// an entry marks it as recently used.
+func (c *Cache) get(namespace, key string) *entry {
+	path := c.path(namespace, key)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	meta, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil
+	}
+	var e entry
+	if err := json.Unmarshal(meta, &e); err != nil {
+		return nil
+	}
+	e.Body = body
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return &e
+}
+
+// put stores e under key, replacing what was there, and evicts old entries
+// if the cache has grown past its limit.
+func (c *Cache) put(namespace, key string, e *entry) error {
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(namespace, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it so readers, including other
+	// termiflow processes, never see half an entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(append(meta, '\n'), e.Body...))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	var replaced int64
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return c.grew(int64(len(meta)+1+len(e.Body)) - replaced)
+}
+
+// grew accounts for n more bytes and evicts entries if that takes the cache
+// over its limit.
+func (c *Cache) grew(n int64) error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.sized {
+		// The first write counts what earlier runs left behind, which
+		// already includes this entry
+		files, err := c.files()
+		if err != nil {
+			return err
+		}
+		c.size, c.sized = 0, true
+		for _, f := range files {
+			c.size += f.size
+		}
+	} else {
+		c.size += n
+	}
+
+	if c.size > c.maxBytes {
+		return c.evict()
+	}
+	return nil
+}
+
+// evict removes the least recently used entries until the cache is down to
+// 90% of its limit, leaving room for a few writes before evicting again.
+// Expired entries aren't preferred: they're still what offline mode serves.
+func (c *Cache) evict() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	c.size = 0
+	for _, f := range files {
+		c.size += f.size
+	}
+
+	target := c.maxBytes / 10 * 9
+	for _, f := range files {
+		if c.size <= target {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		c.size -= f.size
+	}
+	return nil
+}
+
+type file struct {
+	path      string
+	namespace string
+	size      int64
+	modTime   time.Time
+}
+
+// files lists every entry in the cache.
+func (c *Cache) files() ([]file, error) {
+	var files []file
+
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			// Removed since the directory was read
+			return nil
+		}
+
+		rel, err := filepath.Rel(c.dir, path)
+		if err != nil {
+			return err
+		}
+		namespace, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+
+		files = append(files, file{
+			path:      path,
+			namespace: namespace,
+			size:      info.Size(),
+			modTime:   info.ModTime(),
+		})
+		return nil
+	})
+
+	return files, err
+}
+
+// NamespaceStats describes the entries in one namespace.
+type NamespaceStats struct {
+	Name    string
+	Entries int
+	Bytes   int64
+	// Expired entries are only served offline
+	Expired int
+}
+
+// Stats describes what the cache holds.
+type Stats struct {
+	Dir        string
+	MaxBytes   int64
+	Namespaces []NamespaceStats
+	Total      NamespaceStats
+}
+
+// Stats counts the entries in every namespace, including empty ones.
+func (c *Cache) Stats() (*Stats, error) {
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{Dir: c.dir, MaxBytes: c.maxBytes}
+	for _, name := range Namespaces {
+		stats.Namespaces = append(stats.Namespaces, NamespaceStats{Name: name})
+	}
+
+	now := time.Now()
+	for _, f := range files {
+		i := slices.Index(Namespaces, f.namespace)
+		if i < 0 {
+			continue
+		}
+		ns := &stats.Namespaces[i]
+		ns.Entries++
+		ns.Bytes += f.size
+		if expires, err := readExpires(f.path); err == nil && !now.Before(expires) {
+			ns.Expired++
+		}
+	}
+
+	for _, ns := range stats.Namespaces {
+		stats.Total.Entries += ns.Entries
+		stats.Total.Bytes += ns.Bytes
+		stats.Total.Expired += ns.Expired
+	}
+
+	return stats, nil
+}
+
+// readExpires reads an entry's expiry without reading its body.
+func readExpires(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	meta, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var e entry
+	if err := json.Unmarshal(meta, &e); err != nil {
+		return time.Time{}, err
+	}
+	return e.Expires, nil
+}
+
+// Clear removes the entries in a namespace, or every entry if namespace is
+// empty, and returns how many were removed.
+func (c *Cache) Clear(namespace string) (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, f := range files {
+		if namespace != "" && f.namespace != namespace {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+
+	c.mu.Lock()
+	c.sized = false
+	c.mu.Unlock()
+
+	return removed, nil
+}
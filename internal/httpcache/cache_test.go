@@ -0,0 +1,218 @@
+package httpcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer answers every request with its path and body and counts the
+// requests that reached it.
+func countingServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/error":
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		case "/private":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.URL.Path, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, client *http.Client, method, url, body string) (string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), nil
+}
+
+func TestTransportServesFreshEntries(t *testing.T) {
+	var hits atomic.Int32
+	server := countingServer(t, &hits)
+	cache := Open(t.TempDir(), 0)
+	client := &http.Client{Transport: cache.Transport(nil, Policy{Namespace: Pages, TTL: time.Hour})}
+
+	for i := 0; i < 3; i++ {
+		got, err := get(t, client, "GET", server.URL+"/a", "")
+		if err != nil {
+			t.Fatalf("GET /a error = %v", err)
+		}
+		if got != "/a " {
+			t.Errorf("GET /a = %q, want %q", got, "/a ")
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+
+	// Requests differing only by body are different entries
+	for _, body := range []string{`{"q":"go"}`, `{"q":"rust"}`, `{"q":"go"}`} {
+		got, err := get(t, client, "POST", server.URL+"/search", body)
+		if err != nil {
+			t.Fatalf("POST /search error = %v", err)
+		}
+		if want := "/search " + body; got != want {
+			t.Errorf("POST /search %s = %q, want %q", body, got, want)
+		}
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestTransportSkipsUnstorableResponses(t *testing.T) {
+	var hits atomic.Int32
+	server := countingServer(t, &hits)
+	cache := Open(t.TempDir(), 0)
+	client := &http.Client{Transport: cache.Transport(nil, Policy{Namespace: Pages, TTL: time.Hour})}
+
+	for _, path := range []string{"/error", "/error", "/private", "/private"} {
+		if _, err := get(t, client, "GET", server.URL+path, ""); err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+	}
+	if n := hits.Load(); n != 4 {
+		t.Errorf("server saw %d requests, want 4", n)
+	}
+}
+
+func TestTransportExpiresEntries(t *testing.T) {
+	var hits atomic.Int32
+	server := countingServer(t, &hits)
+	cache := Open(t.TempDir(), 0)
+	client := &http.Client{Transport: cache.Transport(nil, Policy{Namespace: Feeds, TTL: time.Nanosecond})}
+
+	for i := 0; i < 2; i++ {
+		if _, err := get(t, client, "GET", server.URL+"/feed", ""); err != nil {
+			t.Fatalf("GET /feed error = %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+
+	// Offline, the expired entry is still served
+	offline := &http.Client{Transport: cache.Transport(nil, Policy{Namespace: Feeds, Offline: true})}
+	got, err := get(t, offline, "GET", server.URL+"/feed", "")
+	if err != nil {
+		t.Fatalf("offline GET /feed error = %v", err)
+	}
+	if got != "/feed " {
+		t.Errorf("offline GET /feed = %q, want %q", got, "/feed ")
+	}
+	if _, err := get(t, offline, "GET", server.URL+"/other", ""); !errors.Is(err, ErrOffline) {
+		t.Errorf("offline GET /other error = %v, want ErrOffline", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache := Open(dir, 0)
+
+	body := strings.Repeat("x", 1000)
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"a", "b", "c"} {
+		k := key("GET", name, nil)
+		if err := cache.put(Pages, k, &entry{URL: name, Status: 200, Body: []byte(body)}); err != nil {
+			t.Fatal(err)
+		}
+		// a is the oldest, c the newest
+		at := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(cache.path(Pages, k), at, at)
+	}
+
+	// Reading a makes it the most recently used
+	if cache.get(Pages, key("GET", "a", nil)) == nil {
+		t.Fatal("get(a) = nil")
+	}
+
+	// Room for two entries: the next write evicts b and c
+	limited := Open(dir, 2500)
+	if err := limited.put(Pages, key("GET", "d", nil), &entry{URL: "d", Status: 200, Body: []byte(body)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"a": true, "b": false, "c": false, "d": true} {
+		if got := limited.get(Pages, key("GET", name, nil)) != nil; got != want {
+			t.Errorf("after eviction, %s cached = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCacheStatsAndClear(t *testing.T) {
+	cache := Open(t.TempDir(), 1<<20)
+	now := time.Now()
+
+	entries := []struct {
+		namespace string
+		url       string
+		expires   time.Time
+	}{
+		{Search, "q1", now.Add(time.Hour)},
+		{Search, "q2", now.Add(-time.Hour)},
+		{Feeds, "f1", now.Add(time.Hour)},
+	}
+	for _, e := range entries {
+		if err := cache.put(e.namespace, key("GET", e.url, nil), &entry{URL: e.url, Status: 200, Expires: e.expires, Body: []byte("body")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if len(stats.Namespaces) != len(Namespaces) {
+		t.Fatalf("Stats() has %d namespaces, want %d", len(stats.Namespaces), len(Namespaces))
+	}
+	want := map[string][2]int{Search: {2, 1}, Pages: {0, 0}, Feeds: {1, 0}}
+	for _, ns := range stats.Namespaces {
+		if got := [2]int{ns.Entries, ns.Expired}; got != want[ns.Name] {
+			t.Errorf("Stats() %s entries, expired = %v, want %v", ns.Name, got, want[ns.Name])
+		}
+	}
+	if stats.Total.Entries != 3 || stats.Total.Bytes == 0 {
+		t.Errorf("Stats() Total = %+v, want 3 entries", stats.Total)
+	}
+
+	removed, err := cache.Clear(Search)
+	if err != nil || removed != 2 {
+		t.Errorf("Clear(search) = %d, %v, want 2, nil", removed, err)
+	}
+	removed, err = cache.Clear("")
+	if err != nil || removed != 1 {
+		t.Errorf("Clear() = %d, %v, want 1, nil", removed, err)
+	}
+	if stats, _ := cache.Stats(); stats.Total.Entries != 0 {
+		t.Errorf("after Clear(), Stats() Total = %+v, want empty", stats.Total)
+	}
+}
@@ -0,0 +1,178 @@
+package httpcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrOffline is returned in offline mode for requests that aren't cached.
+var ErrOffline = errors.New("offline and not in the cache")
+
+// Policy says how a Transport uses the cache.
+type Policy struct {
+	// Namespace is where responses are stored
+	Namespace string
+	// TTL is how long a stored response is served instead of fetching again.
+	// Zero stores nothing.
+	TTL time.Duration
+	// Offline serves every request from the cache, however old the entry,
+	// and fails those that aren't cached
+	Offline bool
+}
+
+type transport struct {
+	cache  *Cache
+	policy Policy
+	base   http.RoundTripper
+}
+
+// Transport returns a RoundTripper that answers requests from the cache under
+// policy and sends the rest to base, storing successful responses. A nil
+// *Cache returns base.
+func (c *Cache) Transport(base http.RoundTripper, policy Policy) http.RoundTripper {
+	if c == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{cache: c, policy: policy, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, req, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	k := key(req.Method, req.URL.String(), body)
+
+	if e := t.cache.get(t.policy.Namespace, k); e != nil && (t.policy.Offline || e.fresh(time.Now())) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return e.response(req), nil
+	}
+
+	if t.policy.Offline {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, ErrOffline
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !t.storable(resp) {
+		return resp, err
+	}
+
+	limit := t.maxEntry()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		// Too big to keep: hand back what was read followed by the rest
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	now := time.Now()
+	// A response that can't be stored is still a good response
+	t.cache.put(t.policy.Namespace, k, &entry{
+		URL:      req.URL.String(),
+		Status:   resp.StatusCode,
+		Header:   resp.Header.Clone(),
+		StoredAt: now,
+		Expires:  now.Add(t.policy.TTL),
+		Body:     data,
+	})
+
+	return resp, nil
+}
+
+// storable reports whether resp should be kept: successful responses, and
+// redirects so the page they lead to can be found offline, unless the server
+// asked to keep them out of caches.
+func (t *transport) storable(resp *http.Response) bool {
+	if t.policy.TTL <= 0 {
+		return false
+	}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+	case resp.StatusCode == http.StatusMovedPermanently, resp.StatusCode == http.StatusFound,
+		resp.StatusCode == http.StatusSeeOther, resp.StatusCode == http.StatusTemporaryRedirect,
+		resp.StatusCode == http.StatusPermanentRedirect:
+	default:
+		return false
+	}
+	return !strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store")
+}
+
+// maxEntry is the largest body kept, so one response can't push most of the
+// cache out.
+func (t *transport) maxEntry() int64 {
+	const defaultMaxEntry = 10 << 20
+
+	if t.cache.maxBytes > 0 {
+		return min(t.cache.maxBytes/8, defaultMaxEntry)
+	}
+	return defaultMaxEntry
+}
+
+// requestBody returns a copy of the request body, which is part of what
+// identifies a request, and a request that can still send it.
+func requestBody(req *http.Request) ([]byte, *http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, req, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		return data, req, err
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, req, nil
+}
+
+func (e *entry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Age", fmt.Sprint(int(time.Since(e.StoredAt).Seconds())))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -31,7 +31,7 @@ func Ask(ctx context.Context, question string, llmProvider llm.Provider, searchP
 	prompt := buildPromptWithSources(question, sources)
 
 	// Get LLM response
-	resp, err := llmProvider.Complete(ctx, llm.CompletionRequest{
+	resp, err := llmProvider.Complete(llm.WithPurpose(ctx, llm.PurposeAsk), llm.CompletionRequest{
 		Messages: []llm.Message{
 			{Role: "system", Content: "You are a helpful assistant that provides accurate, well-researched answers. Use the provided sources to inform your response. Be concise but thorough."},
 			{Role: "user", Content: prompt},
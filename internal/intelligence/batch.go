@@ -0,0 +1,100 @@
+package intelligence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+)
+
+// Curation is the relevance score, summary and tags for one search result.
+type Curation struct {
+	Relevance float64
+	Summary   string
+	Tags      []string
+}
+
+type batchCuration struct {
+	ID        int      `json:"id"`
+	Relevance float64  `json:"relevance"`
+	Summary   string   `json:"summary"`
+	Tags      []string `json:"tags"`
+}
+
+// CurateBatch scores, summarizes and tags several results in one request.
+// The returned slice is parallel to results; entries the model left out are
+// nil so the caller can curate them individually. An error means the
+// response couldn't be used at all.
+func CurateBatch(ctx context.Context, provider llm.Provider, topic string, results []search.SearchResult) ([]*Curation, error) {
+	var articles strings.Builder
+	for i, r := range results {
+		fmt.Fprintf(&articles, "[%d]\nTitle: %s\nSnippet: %s\nContent: %s\n\n",
+			i+1, r.Title, r.Snippet, truncateContent(r.Content, 1000))
+	}
+
+	prompt := fmt.Sprintf(`You are curating articles for a developer subscribed to the topic "%s".
+
+For each article below:
+- rate its relevance to the topic from 0.0 (not relevant) to 1.0 (perfectly relevant)
+- summarize it in 2-3 sentences, focusing on the key technical insights and why they matter
+- extract 2-4 lowercase technical tags
+
+Articles:
+
+%sRespond with only a JSON array containing one object per article, in this form:
+[{"id": 1, "relevance": 0.8, "summary": "...", "tags": ["tag", "tag"]}]`, topic, articles.String())
+
+	resp, err := provider.Complete(llm.WithPurpose(ctx, llm.PurposeCurate), llm.CompletionRequest{
+		Messages: []llm.Message{
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   250*len(results) + 100,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBatchCuration(resp.Content, len(results))
+}
+
+// parseBatchCuration reads the JSON array from a CurateBatch response. Models
+// often wrap JSON in prose or code fences, so only the outermost array is
+// parsed.
+func parseBatchCuration(content string, n int) ([]*Curation, error) {
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in batch curation response")
+	}
+
+	var entries []batchCuration
+	if err := json.Unmarshal([]byte(content[start:end+1]), &entries); err != nil {
+		return nil, fmt.Errorf("invalid batch curation response: %w", err)
+	}
+
+	curations := make([]*Curation, n)
+	found := 0
+	for _, e := range entries {
+		i := e.ID - 1
+		if i < 0 || i >= n || curations[i] != nil {
+			continue
+		}
+
+		curations[i] = &Curation{
+			Relevance: clampScore(e.Relevance),
+			Summary:   strings.TrimSpace(e.Summary),
+			Tags:      cleanTags(e.Tags),
+		}
+		found++
+	}
+
+	if found == 0 {
+		return nil, fmt.Errorf("batch curation response matched no articles")
+	}
+
+	return curations, nil
+}
@@ -0,0 +1,103 @@
+package intelligence
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// DefaultHistoryBudget is the approximate number of tokens of prior turns
+// replayed into a follow-up question before older turns get condensed.
+const DefaultHistoryBudget = 6000
+
+// BuildConversation assembles the messages for a follow-up question. Prior
+// turns are replayed newest-first until the token budget is spent; anything
+// older is condensed into a short recap so the model keeps the thread of the
+// conversation without the full text.
+func BuildConversation(systemPrompt string, turns []*models.Query, prompt string, budget int) []llm.Message {
+	if budget <= 0 {
+		budget = DefaultHistoryBudget
+	}
+
+	messages := []llm.Message{{Role: "system", Content: systemPrompt}}
+
+	// Walk backwards keeping as many recent turns as fit
+	used := 0
+	keepFrom := len(turns)
+	for i := len(turns) - 1; i >= 0; i-- {
+		cost := EstimateTokens(formatTurnPrompt(turns[i])) + EstimateTokens(turns[i].Response)
+		if used+cost > budget && keepFrom < len(turns) {
+			break
+		}
+		used += cost
+		keepFrom = i
+	}
+
+	if keepFrom > 0 {
+		messages = append(messages, llm.Message{
+			Role:    "user",
+			Content: summarizeTurns(turns[:keepFrom]),
+		})
+		messages = append(messages, llm.Message{
+			Role:    "assistant",
+			Content: "Understood, I'll keep that earlier context in mind.",
+		})
+	}
+
+	for _, turn := range turns[keepFrom:] {
+		messages = append(messages,
+			llm.Message{Role: "user", Content: formatTurnPrompt(turn)},
+			llm.Message{Role: "assistant", Content: turn.Response},
+		)
+	}
+
+	messages = append(messages, llm.Message{Role: "user", Content: prompt})
+	return messages
+}
+
+// EstimateTokens approximates the token count of text (roughly 4 characters
+// per token for English prose).
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+func formatTurnPrompt(turn *models.Query) string {
+	var sb strings.Builder
+
+	if len(turn.Sources) > 0 {
+		sb.WriteString("Sources used for this question:\n")
+		for i, src := range turn.Sources {
+			sb.WriteString(fmt.Sprintf("[%d] %s (%s)\n", i+1, src.Title, src.URL))
+			if src.Snippet != "" {
+				sb.WriteString(fmt.Sprintf("    %s\n", truncateContent(src.Snippet, 300)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("Question: ")
+	sb.WriteString(turn.Query)
+	return sb.String()
+}
+
+func summarizeTurns(turns []*models.Query) string {
+	var sb strings.Builder
+	sb.WriteString("Summary of earlier questions in this conversation:\n")
+	for _, turn := range turns {
+		sb.WriteString(fmt.Sprintf("- Q: %s\n  A: %s\n", turn.Query, firstSentence(turn.Response, 200)))
+	}
+	return sb.String()
+}
+
+func firstSentence(text string, maxLen int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if idx := strings.Index(text, ". "); idx > 0 {
+		text = text[:idx+1]
+	}
+	if len(text) > maxLen {
+		return truncateContent(text, maxLen-3) + "..."
+	}
+	return text
+}
@@ -0,0 +1,90 @@
+package intelligence
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+func TestBuildConversation_NoHistory(t *testing.T) {
+	messages := BuildConversation("system", nil, "Question: why?", 0)
+
+	if len(messages) != 2 {
+		t.Fatalf("messages length = %d, want 2", len(messages))
+	}
+	if messages[0].Role != "system" || messages[1].Role != "user" {
+		t.Errorf("roles = %q, %q, want system, user", messages[0].Role, messages[1].Role)
+	}
+}
+
+func TestBuildConversation_ReplaysTurns(t *testing.T) {
+	turns := []*models.Query{
+		{
+			Query:    "what is WebGPU?",
+			Response: "A browser GPU API.",
+			Sources:  []models.QuerySource{{Title: "gpuweb", URL: "https://gpuweb.github.io", Snippet: "spec"}},
+		},
+		{Query: "who ships it?", Response: "Chrome and Firefox."},
+	}
+
+	messages := BuildConversation("system", turns, "Question: and Safari?", 0)
+
+	// system + 2 turns * 2 messages + new question
+	if len(messages) != 6 {
+		t.Fatalf("messages length = %d, want 6", len(messages))
+	}
+	if !strings.Contains(messages[1].Content, "https://gpuweb.github.io") {
+		t.Errorf("first turn should include its sources, got %q", messages[1].Content)
+	}
+	if messages[2].Role != "assistant" || messages[2].Content != "A browser GPU API." {
+		t.Errorf("messages[2] = %+v, want first answer", messages[2])
+	}
+	if messages[5].Content != "Question: and Safari?" {
+		t.Errorf("last message = %q, want new question", messages[5].Content)
+	}
+}
+
+func TestBuildConversation_CondensesOldTurns(t *testing.T) {
+	long := strings.Repeat("word ", 400)
+	turns := []*models.Query{
+		{Query: "first question", Response: "First answer. " + long},
+		{Query: "second question", Response: "Second answer. " + long},
+		{Query: "third question", Response: "Third answer."},
+	}
+
+	messages := BuildConversation("system", turns, "Question: fourth", 600)
+
+	if !strings.Contains(messages[1].Content, "first question") {
+		t.Errorf("recap should mention dropped turns, got %q", messages[1].Content)
+	}
+	if strings.Contains(messages[1].Content, long) {
+		t.Error("recap should not contain full answers")
+	}
+
+	last := messages[len(messages)-2]
+	if last.Role != "assistant" || last.Content != "Third answer." {
+		t.Errorf("most recent turn should be replayed verbatim, got %+v", last)
+	}
+}
+
+func TestBuildConversation_KeepsLatestTurnOverBudget(t *testing.T) {
+	turns := []*models.Query{
+		{Query: "only question", Response: strings.Repeat("x", 10000)},
+	}
+
+	messages := BuildConversation("system", turns, "Question: next", 10)
+
+	if len(messages) != 4 {
+		t.Fatalf("messages length = %d, want 4", len(messages))
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if EstimateTokens("") != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d, want 0", EstimateTokens(""))
+	}
+	if EstimateTokens("abcdefgh") != 2 {
+		t.Errorf("EstimateTokens(8 chars) = %d, want 2", EstimateTokens("abcdefgh"))
+	}
+}
@@ -2,79 +2,222 @@ package intelligence
 
 import (
 	"context"
+	"errors"
 	"sort"
+	"unicode/utf8"
 
 	"github.com/oluoyefeso/termiflow/internal/providers/llm"
 	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/internal/workpool"
 	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
+// ErrNotCurated is returned by providers that decline to curate a result, such
+// as one guarding a budget. Results they won't score are left out instead of
+// being kept at the default score, so a later refresh can curate them.
+var ErrNotCurated = errors.New("not curated")
+
+// Results scoring at least relevanceThreshold are kept, and those scoring
+// above summaryThreshold are summarized and tagged.
+const (
+	relevanceThreshold = 0.5
+	summaryThreshold   = 0.5
+)
+
 type Curator struct {
 	llmProvider llm.Provider
+	concurrency int
+	batchSize   int
+	// scoreOnly leaves out summaries and tags
+	scoreOnly bool
 }
 
-func NewCurator(provider llm.Provider) *Curator {
+// NewCurator returns a curator that makes up to concurrency requests at once,
+// each curating up to batchSize results. A batchSize of 1 or less curates
+// every result with separate requests. Rate limits are left to the provider.
+func NewCurator(provider llm.Provider, concurrency, batchSize int) *Curator {
 	return &Curator{
 		llmProvider: provider,
+		concurrency: concurrency,
+		batchSize:   batchSize,
 	}
 }
 
-// CurateResults processes search results and returns curated feed items
-func (c *Curator) CurateResults(ctx context.Context, topic string, results []search.SearchResult) ([]*models.FeedItem, error) {
-	var items []*models.FeedItem
-
-	for _, result := range results {
-		item := &models.FeedItem{
-			Title:       result.Title,
-			SourceName:  result.Source,
-			SourceURL:   result.URL,
-			Content:     truncateContent(result.Content, 2000),
-			PublishedAt: &result.PublishedAt,
+// WithProvider returns a copy of the curator that asks p instead.
+func (c *Curator) WithProvider(p llm.Provider) *Curator {
+	copied := *c
+	copied.llmProvider = p
+	return &copied
+}
+
+// WithoutSummaries returns a copy of the curator that only scores results,
+// leaving out summaries and tags to spend fewer tokens.
+func (c *Curator) WithoutSummaries() *Curator {
+	copied := *c
+	copied.scoreOnly = true
+	return &copied
+}
+
+// Prompt overheads and typical answer lengths, in tokens, for Estimate
+const (
+	scorePromptTokens     = 110
+	scoreAnswerTokens     = 4
+	summarizePromptTokens = 50
+	summarizeAnswerTokens = 100
+	tagsPromptTokens      = 40
+	tagsAnswerTokens      = 15
+	batchPromptTokens     = 120
+	batchArticleTokens    = 10
+	batchAnswerTokens     = 120
+)
+
+// Estimate roughly predicts the tokens CurateResults will spend on results,
+// taking every result to be relevant enough to summarize.
+func (c *Curator) Estimate(results []search.SearchResult) (promptTokens, completionTokens int) {
+	if c.batchSize > 1 && !c.scoreOnly {
+		batches := (len(results) + c.batchSize - 1) / c.batchSize
+		promptTokens = batches * batchPromptTokens
+		for _, r := range results {
+			promptTokens += batchArticleTokens + EstimateTokens(r.Title+r.Snippet+truncateContent(r.Content, 1000))
 		}
+		return promptTokens, len(results) * batchAnswerTokens
+	}
 
-		// Score relevance
-		score, err := ScoreRelevance(ctx, c.llmProvider, topic, result.Title, result.Snippet)
-		if err != nil {
-			score = 0.5 // Default score on error
+	for _, r := range results {
+		promptTokens += scorePromptTokens + EstimateTokens(r.Title+r.Snippet)
+		completionTokens += scoreAnswerTokens
+		if c.scoreOnly {
+			continue
 		}
-		item.RelevanceScore = score
-
-		// Only process items above threshold
-		if score > 0.5 {
-			// Generate summary
-			summary, err := Summarize(ctx, c.llmProvider, topic, result.Title, result.Content)
-			if err == nil {
-				item.Summary = summary
-			}
+		article := EstimateTokens(r.Title + r.Content)
+		promptTokens += summarizePromptTokens + tagsPromptTokens + 2*article
+		completionTokens += summarizeAnswerTokens + tagsAnswerTokens
+	}
+	return promptTokens, completionTokens
+}
 
-			// Extract tags
-			tags, err := ExtractTags(ctx, c.llmProvider, result.Title, result.Content)
-			if err == nil {
-				item.Tags = tags
-			}
+// CurateResults processes search results and returns curated feed items.
+// Results the provider declines to score with ErrNotCurated are left out.
+func (c *Curator) CurateResults(ctx context.Context, topic string, results []search.SearchResult) ([]*models.FeedItem, error) {
+	items := make([]*models.FeedItem, len(results))
+
+	if c.batchSize > 1 && !c.scoreOnly {
+		batches := (len(results) + c.batchSize - 1) / c.batchSize
+		workpool.Run(ctx, c.concurrency, batches, func(b int) {
+			start := b * c.batchSize
+			end := min(start+c.batchSize, len(results))
+			c.curateBatch(ctx, topic, results[start:end], items[start:end])
+		})
+	}
+
+	// Curate individually whatever batching didn't cover
+	var pending []int
+	for i, item := range items {
+		if item == nil {
+			pending = append(pending, i)
 		}
+	}
 
-		items = append(items, item)
+	workpool.Run(ctx, c.concurrency, len(pending), func(k int) {
+		i := pending[k]
+		items[i] = c.curateResult(ctx, topic, results[i])
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// Filter and sort by relevance
-	items = filterByRelevance(items, 0.5)
+	items = filterByRelevance(items, relevanceThreshold)
 	sortByRelevanceAndRecency(items)
 
 	return items, nil
 }
 
+// curateBatch fills items with curations from a single batched request. If
+// the response can't be parsed, items are left nil.
+func (c *Curator) curateBatch(ctx context.Context, topic string, results []search.SearchResult, items []*models.FeedItem) {
+	curations, err := CurateBatch(ctx, c.llmProvider, topic, results)
+	if err != nil {
+		return
+	}
+
+	for i, curation := range curations {
+		if curation == nil {
+			continue
+		}
+
+		item := newFeedItem(results[i])
+		item.RelevanceScore = curation.Relevance
+		// As when curating one by one, only relevant enough items keep theirs
+		if curation.Relevance > summaryThreshold {
+			item.Summary = curation.Summary
+			item.Tags = curation.Tags
+		}
+		items[i] = item
+	}
+}
+
+// curateResult scores a result and, if it's relevant enough, summarizes and
+// tags it. It returns nil if the provider declined to score it.
+func (c *Curator) curateResult(ctx context.Context, topic string, result search.SearchResult) *models.FeedItem {
+	item := newFeedItem(result)
+
+	// Score relevance
+	score, err := ScoreRelevance(ctx, c.llmProvider, topic, result.Title, result.Snippet)
+	if errors.Is(err, ErrNotCurated) {
+		return nil
+	}
+	if err != nil {
+		score = 0.5 // Default score on error
+	}
+	item.RelevanceScore = score
+
+	// Only process items above threshold
+	if score > summaryThreshold && !c.scoreOnly {
+		// Generate summary
+		summary, err := Summarize(ctx, c.llmProvider, topic, result.Title, result.Content)
+		if err == nil {
+			item.Summary = summary
+		}
+
+		// Extract tags
+		tags, err := ExtractTags(ctx, c.llmProvider, result.Title, result.Content)
+		if err == nil {
+			item.Tags = tags
+		}
+	}
+
+	return item
+}
+
+func newFeedItem(result search.SearchResult) *models.FeedItem {
+	return &models.FeedItem{
+		Title:       result.Title,
+		SourceName:  result.Source,
+		SourceURL:   result.URL,
+		Content:     truncateContent(result.Content, 2000),
+		PublishedAt: &result.PublishedAt,
+	}
+}
+
+// truncateContent cuts content to at most maxLen bytes, without splitting a
+// character.
 func truncateContent(content string, maxLen int) string {
 	if len(content) <= maxLen {
 		return content
 	}
-	return content[:maxLen]
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut]
 }
 
 func filterByRelevance(items []*models.FeedItem, threshold float64) []*models.FeedItem {
 	var filtered []*models.FeedItem
 	for _, item := range items {
-		if item.RelevanceScore >= threshold {
+		if item != nil && item.RelevanceScore >= threshold {
 			filtered = append(filtered, item)
 		}
 	}
@@ -82,7 +225,7 @@ func filterByRelevance(items []*models.FeedItem, threshold float64) []*models.Fe
 }
 
 func sortByRelevanceAndRecency(items []*models.FeedItem) {
-	sort.Slice(items, func(i, j int) bool {
+	sort.SliceStable(items, func(i, j int) bool {
 		// Combine relevance (70%) and recency (30%)
 		scoreI := items[i].RelevanceScore * 0.7
 		scoreJ := items[j].RelevanceScore * 0.7
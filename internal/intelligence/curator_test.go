@@ -0,0 +1,350 @@
+package intelligence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+)
+
+// countingProvider answers curation prompts and records how many calls were
+// in flight at once.
+type countingProvider struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (p *countingProvider) Name() string    { return "counting" }
+func (p *countingProvider) Model() string   { return "test" }
+func (p *countingProvider) Available() bool { return true }
+
+func (p *countingProvider) Stream(ctx context.Context, req llm.CompletionRequest) (<-chan llm.StreamChunk, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *countingProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.mu.Lock()
+	p.inFlight++
+	if p.inFlight > p.maxInFlight {
+		p.maxInFlight = p.inFlight
+	}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+
+	prompt := req.Messages[0].Content
+	switch {
+	case strings.Contains(prompt, "Rate the relevance"):
+		return &llm.CompletionResponse{Content: "0.9"}, nil
+	case strings.Contains(prompt, "Extract 2-4"):
+		return &llm.CompletionResponse{Content: "go, testing"}, nil
+	default:
+		return &llm.CompletionResponse{Content: "A summary."}, nil
+	}
+}
+
+func TestCurateResultsConcurrency(t *testing.T) {
+	provider := &countingProvider{}
+	curator := NewCurator(provider, 3, 1)
+
+	var results []search.SearchResult
+	for i := 0; i < 10; i++ {
+		results = append(results, search.SearchResult{
+			Title: fmt.Sprintf("Result %d", i),
+			URL:   fmt.Sprintf("https://example.com/%d", i),
+		})
+	}
+
+	items, err := curator.CurateResults(context.Background(), "go", results)
+	if err != nil {
+		t.Fatalf("CurateResults() error = %v", err)
+	}
+
+	if provider.maxInFlight > 3 {
+		t.Errorf("max concurrent calls = %d, want at most 3", provider.maxInFlight)
+	}
+
+	if len(items) != len(results) {
+		t.Fatalf("CurateResults() returned %d items, want %d", len(items), len(results))
+	}
+
+	// Equal scores keep the order the results came in
+	for i, item := range items {
+		if item.SourceURL != results[i].URL {
+			t.Errorf("items[%d].SourceURL = %q, want %q", i, item.SourceURL, results[i].URL)
+		}
+		if item.Summary != "A summary." {
+			t.Errorf("items[%d].Summary = %q, want %q", i, item.Summary, "A summary.")
+		}
+	}
+}
+
+func TestCurateResultsCancelled(t *testing.T) {
+	curator := NewCurator(&countingProvider{}, 2, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := curator.CurateResults(ctx, "go", []search.SearchResult{{Title: "A"}, {Title: "B"}})
+	if err != context.Canceled {
+		t.Errorf("CurateResults() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// batchProvider answers batched curation prompts with batchResponse and
+// per-item prompts the way countingProvider does.
+type batchProvider struct {
+	countingProvider
+	batchResponse string
+
+	mu           sync.Mutex
+	batchCalls   int
+	perItemCalls int
+}
+
+func (p *batchProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if strings.Contains(req.Messages[0].Content, "JSON array") {
+		p.batchCalls++
+		return &llm.CompletionResponse{Content: p.batchResponse}, nil
+	}
+
+	p.perItemCalls++
+	return p.countingProvider.Complete(ctx, req)
+}
+
+func TestCurateResultsBatched(t *testing.T) {
+	results := []search.SearchResult{
+		{Title: "A", URL: "https://example.com/a"},
+		{Title: "B", URL: "https://example.com/b"},
+		{Title: "C", URL: "https://example.com/c"},
+	}
+
+	tests := []struct {
+		name         string
+		response     string
+		wantPerItem  int
+		wantSummary0 string
+	}{
+		{
+			name: "all curated in one call",
+			response: "```json\n" + `[
+				{"id": 1, "relevance": 0.9, "summary": "About A.", "tags": ["Go", "#cli"]},
+				{"id": 2, "relevance": 0.8, "summary": "About B.", "tags": []},
+				{"id": 3, "relevance": 0.7, "summary": "About C.", "tags": ["go"]}
+			]` + "\n```",
+			wantPerItem:  0,
+			wantSummary0: "About A.",
+		},
+		{
+			name:         "missing entry falls back",
+			response:     `[{"id": 1, "relevance": 0.9, "summary": "About A."}, {"id": 3, "relevance": 0.7, "summary": "About C."}]`,
+			wantPerItem:  3, // score, summary and tags for B
+			wantSummary0: "About A.",
+		},
+		{
+			name:         "unparseable response falls back",
+			response:     "Sorry, I can't help with that.",
+			wantPerItem:  9,
+			wantSummary0: "A summary.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &batchProvider{batchResponse: tt.response}
+			items, err := NewCurator(provider, 2, 10).CurateResults(context.Background(), "go", results)
+			if err != nil {
+				t.Fatalf("CurateResults() error = %v", err)
+			}
+
+			if provider.batchCalls != 1 {
+				t.Errorf("batch calls = %d, want 1", provider.batchCalls)
+			}
+			if provider.perItemCalls != tt.wantPerItem {
+				t.Errorf("per-item calls = %d, want %d", provider.perItemCalls, tt.wantPerItem)
+			}
+			if len(items) != len(results) {
+				t.Fatalf("CurateResults() returned %d items, want %d", len(items), len(results))
+			}
+			if items[0].SourceURL != results[0].URL || items[0].Summary != tt.wantSummary0 {
+				t.Errorf("items[0] = %q %q, want %q %q", items[0].SourceURL, items[0].Summary, results[0].URL, tt.wantSummary0)
+			}
+		})
+	}
+}
+
+func TestCurateResultsBatchedSummaryThreshold(t *testing.T) {
+	results := []search.SearchResult{
+		{Title: "A", URL: "https://example.com/a"},
+		{Title: "B", URL: "https://example.com/b"},
+	}
+	provider := &batchProvider{batchResponse: `[
+		{"id": 1, "relevance": 0.9, "summary": "About A.", "tags": ["go"]},
+		{"id": 2, "relevance": 0.5, "summary": "About B.", "tags": ["go"]}
+	]`}
+
+	items, err := NewCurator(provider, 2, 10).CurateResults(context.Background(), "go", results)
+	if err != nil {
+		t.Fatalf("CurateResults() error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("CurateResults() returned %d items, want 2", len(items))
+	}
+	for _, item := range items {
+		wantSummary := item.RelevanceScore > 0.5
+		if (item.Summary != "") != wantSummary || (len(item.Tags) > 0) != wantSummary {
+			t.Errorf("item with relevance %.1f has summary %q and tags %v", item.RelevanceScore, item.Summary, item.Tags)
+		}
+	}
+}
+
+// decliningProvider refuses to curate results whose title is in declined.
+type decliningProvider struct {
+	countingProvider
+	declined string
+}
+
+func (p *decliningProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	if strings.Contains(req.Messages[0].Content, "Content Title: "+p.declined) {
+		return nil, ErrNotCurated
+	}
+	return &llm.CompletionResponse{Content: "0.9"}, nil
+}
+
+func TestCurateResultsLeavesOutDeclined(t *testing.T) {
+	results := []search.SearchResult{
+		{Title: "A", URL: "https://example.com/a"},
+		{Title: "B", URL: "https://example.com/b"},
+	}
+
+	items, err := NewCurator(&decliningProvider{declined: "B"}, 1, 1).CurateResults(context.Background(), "go", results)
+	if err != nil {
+		t.Fatalf("CurateResults() error = %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "A" {
+		t.Errorf("CurateResults() = %d items, want only A", len(items))
+	}
+}
+
+func TestCurateResultsWithoutSummaries(t *testing.T) {
+	results := []search.SearchResult{
+		{Title: "A", URL: "https://example.com/a"},
+		{Title: "B", URL: "https://example.com/b"},
+	}
+
+	provider := &batchProvider{}
+	items, err := NewCurator(provider, 2, 10).WithoutSummaries().CurateResults(context.Background(), "go", results)
+	if err != nil {
+		t.Fatalf("CurateResults() error = %v", err)
+	}
+
+	// Scored one at a time, with no summaries or tags
+	if provider.batchCalls != 0 || provider.perItemCalls != 2 {
+		t.Errorf("batch, per-item calls = %d, %d, want 0, 2", provider.batchCalls, provider.perItemCalls)
+	}
+	for _, item := range items {
+		if item.Summary != "" || len(item.Tags) != 0 {
+			t.Errorf("item %s has summary %q and tags %v, want none", item.Title, item.Summary, item.Tags)
+		}
+	}
+}
+
+func TestCuratorEstimate(t *testing.T) {
+	results := []search.SearchResult{
+		{Title: "A", Snippet: strings.Repeat("s", 400), Content: strings.Repeat("c", 4000)},
+		{Title: "B", Snippet: strings.Repeat("s", 400), Content: strings.Repeat("c", 4000)},
+	}
+
+	individual := NewCurator(&countingProvider{}, 1, 1)
+	prompt, completion := individual.Estimate(results)
+	scorePrompt, scoreCompletion := individual.WithoutSummaries().Estimate(results)
+	batchPrompt, batchCompletion := NewCurator(&countingProvider{}, 1, 10).Estimate(results)
+
+	if scorePrompt >= prompt || scoreCompletion >= completion {
+		t.Errorf("Estimate() without summaries = %d, %d, want less than %d, %d", scorePrompt, scoreCompletion, prompt, completion)
+	}
+	// Batches send each article once, truncated
+	if batchPrompt >= prompt {
+		t.Errorf("batched Estimate() prompt = %d, want less than %d", batchPrompt, prompt)
+	}
+	if batchCompletion == 0 || prompt < 2*1000 {
+		t.Errorf("Estimate() = %d, %d and batched %d, %d, want the articles counted", prompt, completion, batchPrompt, batchCompletion)
+	}
+}
+
+func TestParseBatchCuration(t *testing.T) {
+	curations, err := parseBatchCuration(`Here you go: [
+		{"id": 2, "relevance": 1.4, "summary": " Second. ", "tags": ["#GPU", " "]},
+		{"id": 2, "relevance": 0.1, "summary": "Duplicate."},
+		{"id": 7, "relevance": 0.5, "summary": "Out of range."}
+	]`, 3)
+	if err != nil {
+		t.Fatalf("parseBatchCuration() error = %v", err)
+	}
+
+	if curations[0] != nil || curations[2] != nil {
+		t.Errorf("parseBatchCuration() filled entries the response didn't cover")
+	}
+
+	got := curations[1]
+	if got == nil {
+		t.Fatal("parseBatchCuration() missing entry for id 2")
+	}
+	if got.Relevance != 1 {
+		t.Errorf("Relevance = %v, want 1", got.Relevance)
+	}
+	if got.Summary != "Second." {
+		t.Errorf("Summary = %q, want %q", got.Summary, "Second.")
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "gpu" {
+		t.Errorf("Tags = %v, want [gpu]", got.Tags)
+	}
+
+	for _, bad := range []string{"no json here", "[not json]", `[{"id": 9}]`} {
+		if _, err := parseBatchCuration(bad, 3); err == nil {
+			t.Errorf("parseBatchCuration(%q) should return error", bad)
+		}
+	}
+}
+
+func TestTruncateContent(t *testing.T) {
+	tests := []struct {
+		content string
+		maxLen  int
+		want    string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc"},
+		// "é" is two bytes and "日" three; neither is split
+		{"café", 4, "caf"},
+		{"日本語", 4, "日"},
+		{"日本語", 2, ""},
+	}
+
+	for _, tt := range tests {
+		got := truncateContent(tt.content, tt.maxLen)
+		if got != tt.want {
+			t.Errorf("truncateContent(%q, %d) = %q, want %q", tt.content, tt.maxLen, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateContent(%q, %d) = %q, not valid UTF-8", tt.content, tt.maxLen, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package intelligence
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+var stopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "as": true,
+	"at": true, "be": true, "by": true, "can": true, "did": true, "do": true,
+	"does": true, "for": true, "from": true, "has": true, "have": true,
+	"how": true, "i": true, "in": true, "is": true, "it": true, "its": true,
+	"latest": true, "me": true, "new": true, "of": true, "on": true, "or": true,
+	"tell": true, "that": true, "the": true, "there": true, "this": true,
+	"to": true, "vs": true, "was": true, "what": true, "whats": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true,
+	"will": true, "with": true, "you": true,
+}
+
+// Keywords extracts the distinctive search terms from a question.
+func Keywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '.'
+	})
+
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, f := range fields {
+		f = strings.Trim(f, "-.")
+		if len(f) < 2 || stopWords[f] || seen[f] {
+			continue
+		}
+		seen[f] = true
+		keywords = append(keywords, f)
+	}
+	return keywords
+}
+
+// RankFeedItems scores stored feed items against the question's keywords and
+// returns the best matches. Items matching too few of the keywords are dropped.
+func RankFeedItems(keywords []string, items []*models.FeedItem, limit int) []*models.FeedItem {
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	minMatched := 2
+	if len(keywords) < minMatched {
+		minMatched = len(keywords)
+	}
+
+	type scored struct {
+		item  *models.FeedItem
+		score float64
+	}
+
+	var ranked []scored
+	for _, item := range items {
+		title := strings.ToLower(item.Title)
+		tags := strings.ToLower(strings.Join(item.Tags, " "))
+		summary := strings.ToLower(item.Summary)
+		content := strings.ToLower(item.Content)
+
+		score := 0.0
+		matched := 0
+		for _, kw := range keywords {
+			termScore := 3*float64(strings.Count(title, kw)) +
+				2*float64(strings.Count(tags, kw)) +
+				float64(strings.Count(summary, kw)) +
+				0.5*float64(strings.Count(content, kw))
+			if termScore > 0 {
+				matched++
+				score += termScore
+			}
+		}
+
+		if matched < minMatched {
+			continue
+		}
+
+		// Favor items that cover more of the question
+		score *= float64(matched) / float64(len(keywords))
+		ranked = append(ranked, scored{item: item, score: score})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	result := make([]*models.FeedItem, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.item
+	}
+	return result
+}
+
+// FeedItemToSource converts a stored feed item into a source for prompting.
+func FeedItemToSource(item *models.FeedItem) search.SearchResult {
+	snippet := item.Summary
+	if snippet == "" {
+		snippet = truncateContent(item.Content, 500)
+	}
+
+	result := search.SearchResult{
+		Title:   item.Title,
+		URL:     item.SourceURL,
+		Snippet: snippet,
+		Content: item.Content,
+		Source:  item.SourceName,
+		ItemID:  item.ID,
+	}
+	if item.PublishedAt != nil {
+		result.PublishedAt = *item.PublishedAt
+	}
+	return result
+}
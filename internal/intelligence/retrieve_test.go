@@ -0,0 +1,79 @@
+package intelligence
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"What is the latest on TSMC N3?", []string{"tsmc", "n3"}},
+		{"how does rust's borrow-checker work", []string{"rust", "borrow-checker", "work"}},
+		{"the the THE", nil},
+		{"Node.js vs Deno", []string{"node.js", "deno"}},
+	}
+
+	for _, tt := range tests {
+		got := Keywords(tt.input)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Keywords(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestRankFeedItems(t *testing.T) {
+	items := []*models.FeedItem{
+		{ID: 1, Title: "Kubernetes 1.30 released", Summary: "New scheduler features"},
+		{ID: 2, Title: "TSMC N3 yields improve", Summary: "TSMC reports better N3 yields", Tags: []string{"tsmc"}},
+		{ID: 3, Title: "Intel roadmap", Content: "Comparison with TSMC and its N3 node"},
+		{ID: 4, Title: "TSMC earnings", Summary: "Revenue up"},
+	}
+
+	ranked := RankFeedItems([]string{"tsmc", "n3"}, items, 5)
+
+	if len(ranked) != 2 {
+		t.Fatalf("RankFeedItems() returned %d items, want 2", len(ranked))
+	}
+	if ranked[0].ID != 2 {
+		t.Errorf("best match = #%d, want #2", ranked[0].ID)
+	}
+	if ranked[1].ID != 3 {
+		t.Errorf("second match = #%d, want #3", ranked[1].ID)
+	}
+
+	limited := RankFeedItems([]string{"tsmc", "n3"}, items, 1)
+	if len(limited) != 1 {
+		t.Errorf("RankFeedItems() with limit returned %d items, want 1", len(limited))
+	}
+
+	if RankFeedItems(nil, items, 5) != nil {
+		t.Error("RankFeedItems() without keywords should return nil")
+	}
+}
+
+func TestFeedItemToSource(t *testing.T) {
+	item := &models.FeedItem{
+		ID:         42,
+		Title:      "Title",
+		Content:    "Body text",
+		SourceName: "SemiAnalysis",
+		SourceURL:  "https://semianalysis.com/post",
+	}
+
+	src := FeedItemToSource(item)
+
+	if src.ItemID != 42 {
+		t.Errorf("ItemID = %d, want 42", src.ItemID)
+	}
+	if src.Snippet != "Body text" {
+		t.Errorf("Snippet = %q, want content fallback when summary is empty", src.Snippet)
+	}
+	if src.URL != item.SourceURL {
+		t.Errorf("URL = %q, want %q", src.URL, item.SourceURL)
+	}
+}
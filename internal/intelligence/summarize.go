@@ -19,7 +19,7 @@ Content: %s
 
 Summary:`, topic, title, content)
 
-	resp, err := provider.Complete(ctx, llm.CompletionRequest{
+	resp, err := provider.Complete(llm.WithPurpose(ctx, llm.PurposeSummarize), llm.CompletionRequest{
 		Messages: []llm.Message{
 			{Role: "user", Content: prompt},
 		},
@@ -49,7 +49,7 @@ Rate the relevance from 0.0 to 1.0 where:
 
 Respond with only a number between 0.0 and 1.0.`, topic, title, snippet)
 
-	resp, err := provider.Complete(ctx, llm.CompletionRequest{
+	resp, err := provider.Complete(llm.WithPurpose(ctx, llm.PurposeScore), llm.CompletionRequest{
 		Messages: []llm.Message{
 			{Role: "user", Content: prompt},
 		},
@@ -65,13 +65,16 @@ Respond with only a number between 0.0 and 1.0.`, topic, title, snippet)
 		return 0.5, nil // Default to neutral if parsing fails
 	}
 
+	return clampScore(score), nil
+}
+
+func clampScore(score float64) float64 {
 	if score < 0 {
-		score = 0
+		return 0
 	} else if score > 1 {
-		score = 1
+		return 1
 	}
-
-	return score, nil
+	return score
 }
 
 // ExtractTags extracts relevant tags from content
@@ -83,7 +86,7 @@ Content: %s
 
 Tags:`, title, content)
 
-	resp, err := provider.Complete(ctx, llm.CompletionRequest{
+	resp, err := provider.Complete(llm.WithPurpose(ctx, llm.PurposeTags), llm.CompletionRequest{
 		Messages: []llm.Message{
 			{Role: "user", Content: prompt},
 		},
@@ -95,16 +98,18 @@ Tags:`, title, content)
 	}
 
 	tagStr := strings.TrimSpace(resp.Content)
-	tags := strings.Split(tagStr, ",")
+	return cleanTags(strings.Split(tagStr, ",")), nil
+}
 
-	var cleanTags []string
+// cleanTags lowercases tags and drops leading '#' and empty entries.
+func cleanTags(tags []string) []string {
+	var cleaned []string
 	for _, tag := range tags {
 		tag = strings.TrimSpace(strings.ToLower(tag))
 		tag = strings.TrimPrefix(tag, "#")
 		if tag != "" {
-			cleanTags = append(cleanTags, tag)
+			cleaned = append(cleaned, tag)
 		}
 	}
-
-	return cleanTags, nil
+	return cleaned
 }
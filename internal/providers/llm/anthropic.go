@@ -5,8 +5,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"strings"
 )
@@ -34,6 +32,10 @@ func (p *AnthropicProvider) Name() string {
 	return "anthropic"
 }
 
+func (p *AnthropicProvider) Model() string {
+	return p.model
+}
+
 func (p *AnthropicProvider) Available() bool {
 	return p.apiKey != ""
 }
@@ -76,6 +78,30 @@ type anthropicStreamEvent struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"delta"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicStreamStatus maps the errors Anthropic reports in the middle of a
+// stream to the HTTP statuses it uses for them before one starts.
+var anthropicStreamStatus = map[string]int{
+	"invalid_request_error": http.StatusBadRequest,
+	"authentication_error":  http.StatusUnauthorized,
+	"permission_error":      http.StatusForbidden,
+	"not_found_error":       http.StatusNotFound,
+	"rate_limit_error":      http.StatusTooManyRequests,
+	"api_error":             http.StatusInternalServerError,
+	"overloaded_error":      529,
 }
 
 func (p *AnthropicProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
@@ -119,8 +145,7 @@ func (p *AnthropicProvider) Complete(ctx context.Context, req CompletionRequest)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Anthropic API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, newAPIError("Anthropic", resp)
 	}
 
 	var anthropicResp anthropicResponse
@@ -187,9 +212,9 @@ func (p *AnthropicProvider) Stream(ctx context.Context, req CompletionRequest) (
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
+		apiErr := newAPIError("Anthropic", resp)
 		resp.Body.Close()
-		return nil, fmt.Errorf("Anthropic API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, apiErr
 	}
 
 	chunks := make(chan StreamChunk)
@@ -198,6 +223,8 @@ func (p *AnthropicProvider) Stream(ctx context.Context, req CompletionRequest) (
 		defer close(chunks)
 		defer resp.Body.Close()
 
+		var usage Usage
+
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -214,12 +241,29 @@ func (p *AnthropicProvider) Stream(ctx context.Context, req CompletionRequest) (
 			}
 
 			switch event.Type {
+			case "message_start":
+				usage.PromptTokens = event.Message.Usage.InputTokens
 			case "content_block_delta":
 				if event.Delta.Text != "" {
 					chunks <- StreamChunk{Content: event.Delta.Text}
 				}
+			case "message_delta":
+				usage.CompletionTokens = event.Usage.OutputTokens
 			case "message_stop":
-				chunks <- StreamChunk{Done: true}
+				usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+				chunks <- StreamChunk{Done: true, Usage: &usage}
+				return
+			case "error":
+				status, ok := anthropicStreamStatus[event.Error.Type]
+				if !ok {
+					status = http.StatusInternalServerError
+				}
+				chunks <- StreamChunk{Error: &APIError{
+					Provider:   "Anthropic",
+					StatusCode: status,
+					Status:     event.Error.Type,
+					Body:       event.Error.Message,
+				}}
 				return
 			}
 		}
@@ -0,0 +1,236 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+)
+
+// ErrNoProvider is returned by a Chain none of whose providers is configured.
+var ErrNoProvider = errors.New("no LLM provider is configured")
+
+// Chain is a Provider that asks several in order, falling over to the next
+// when one isn't configured, can't be reached, rejects its credentials or
+// keeps failing with retryable errors. Responses and stream chunks name the
+// provider that answered.
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain returns a Chain trying providers in order. A single provider is
+// returned unchanged.
+func NewChain(providers ...Provider) Provider {
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	return &Chain{providers: providers}
+}
+
+// GetChain returns the named providers, built as GetProvider does, chained in
+// order.
+func GetChain(names []string, cfg *config.Config) (Provider, error) {
+	if len(names) == 0 {
+		return nil, ErrNoProvider
+	}
+
+	providers := make([]Provider, len(names))
+	for i, name := range names {
+		p, err := GetProvider(strings.TrimSpace(name), cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = p
+	}
+	return NewChain(providers...), nil
+}
+
+// Providers returns the chained providers that are configured, in order.
+func (c *Chain) Providers() []Provider {
+	var available []Provider
+	for _, p := range c.providers {
+		if p.Available() {
+			available = append(available, p)
+		}
+	}
+	return available
+}
+
+// primary is the provider asked first.
+func (c *Chain) primary() Provider {
+	if available := c.Providers(); len(available) > 0 {
+		return available[0]
+	}
+	return c.providers[0]
+}
+
+func (c *Chain) Name() string {
+	return c.primary().Name()
+}
+
+func (c *Chain) Model() string {
+	return c.primary().Model()
+}
+
+func (c *Chain) Available() bool {
+	return len(c.Providers()) > 0
+}
+
+func (c *Chain) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var errs []error
+	for _, p := range c.Providers() {
+		resp, err := p.Complete(ctx, req)
+		if err == nil {
+			if resp.Provider == "" {
+				resp.Provider, resp.Model = p.Name(), p.Model()
+			}
+			return resp, nil
+		}
+		if !canFallOver(ctx, err) {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return nil, chainError(errs)
+}
+
+// Stream streams from the first provider that starts answering. If it fails
+// part way with an error another provider might not hit, the next one is
+// asked instead: when content was already sent, a Restart chunk tells the
+// caller to discard it before the new answer arrives.
+func (c *Chain) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	providers := c.Providers()
+
+	chunks, i, err := startStream(ctx, req, providers)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+
+		send := func(chunk StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		sent := false
+		for {
+			p := providers[i]
+			failure, done := error(nil), false
+
+			for chunk := range chunks {
+				if chunk.Error != nil {
+					failure = chunk.Error
+					break
+				}
+				chunk.Provider, chunk.Model = p.Name(), p.Model()
+				sent = sent || chunk.Content != ""
+				done = chunk.Done
+				if !send(chunk) {
+					go drain(chunks)
+					return
+				}
+			}
+			go drain(chunks)
+
+			last := i == len(providers)-1
+			if done || (failure == nil && last) {
+				return
+			}
+			if failure == nil {
+				// The stream ended without finishing the answer
+				failure = io.ErrUnexpectedEOF
+			}
+			if last || !canFallOver(ctx, failure) {
+				send(StreamChunk{Error: failure, Provider: p.Name(), Model: p.Model()})
+				return
+			}
+
+			var next int
+			chunks, next, err = startStream(ctx, req, providers[i+1:])
+			if err != nil {
+				send(StreamChunk{Error: chainError([]error{fmt.Errorf("%s: %w", p.Name(), failure), err})})
+				return
+			}
+			i += 1 + next
+
+			if sent {
+				if !send(StreamChunk{Restart: true, Provider: providers[i].Name(), Model: providers[i].Model()}) {
+					go drain(chunks)
+					return
+				}
+				sent = false
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// startStream starts a stream from the first of providers that will, and
+// returns it with that provider's index.
+func startStream(ctx context.Context, req CompletionRequest, providers []Provider) (<-chan StreamChunk, int, error) {
+	var errs []error
+	for i, p := range providers {
+		chunks, err := p.Stream(ctx, req)
+		if err == nil {
+			return chunks, i, nil
+		}
+		if !canFallOver(ctx, err) {
+			return nil, 0, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return nil, 0, chainError(errs)
+}
+
+func chainError(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return ErrNoProvider
+	case 1:
+		return errs[0]
+	default:
+		return fmt.Errorf("every provider failed: %w", errors.Join(errs...))
+	}
+}
+
+// drain reads what's left of an abandoned stream so its goroutine can exit.
+func drain(chunks <-chan StreamChunk) {
+	for range chunks {
+	}
+}
+
+// canFallOver reports whether another provider might succeed where one failed
+// with err: it's unreachable, refused its credentials or model, or failed in
+// a way worth retrying. Nothing falls over once the caller has given up.
+func canFallOver(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if IsRetryable(err) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusUnauthorized ||
+			apiErr.StatusCode == http.StatusForbidden ||
+			apiErr.StatusCode == http.StatusNotFound
+	}
+
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return (errors.As(err, &opErr) && opErr.Op == "dial") || errors.As(err, &dnsErr)
+}
@@ -0,0 +1,284 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type GeminiProvider struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func NewGeminiProvider(apiKey, baseURL, model string) *GeminiProvider {
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	if model == "" {
+		model = "gemini-2.5-flash"
+	}
+	return &GeminiProvider{
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+func (p *GeminiProvider) Name() string {
+	return "gemini"
+}
+
+func (p *GeminiProvider) Model() string {
+	return p.model
+}
+
+func (p *GeminiProvider) Available() bool {
+	return p.apiKey != ""
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+	// Thought marks a part of the model's reasoning rather than its answer
+	Thought bool `json:"thought,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	Temperature     float64 `json:"temperature,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	// Error is sent in place of a chunk when a stream fails part way
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// text returns the answer in the first candidate, leaving out thoughts.
+func (r *geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		if !part.Thought {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+func (r *geminiResponse) finishReason() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	return r.Candidates[0].FinishReason
+}
+
+// usage reports thinking tokens as completion tokens, since they're billed
+// as output.
+func (r *geminiResponse) usage() *Usage {
+	if r.UsageMetadata == nil {
+		return nil
+	}
+	m := r.UsageMetadata
+	return &Usage{
+		PromptTokens:     m.PromptTokenCount,
+		CompletionTokens: m.CandidatesTokenCount + m.ThoughtsTokenCount,
+		TotalTokens:      m.TotalTokenCount,
+	}
+}
+
+// newGeminiRequest moves system messages into the system instruction and
+// names the assistant's turns "model", as Gemini expects.
+func newGeminiRequest(req CompletionRequest) geminiRequest {
+	var body geminiRequest
+	for _, m := range req.Messages {
+		switch m.Role {
+		case "system":
+			if body.SystemInstruction == nil {
+				body.SystemInstruction = &geminiContent{}
+			}
+			body.SystemInstruction.Parts = append(body.SystemInstruction.Parts, geminiPart{Text: m.Content})
+		case "assistant":
+			body.Contents = append(body.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: m.Content}}})
+		default:
+			body.Contents = append(body.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: m.Content}}})
+		}
+	}
+
+	if req.MaxTokens > 0 || req.Temperature > 0 {
+		body.GenerationConfig = &geminiGenerationConfig{
+			MaxOutputTokens: req.MaxTokens,
+			Temperature:     req.Temperature,
+		}
+	}
+	return body
+}
+
+func (p *GeminiProvider) newRequest(ctx context.Context, method string, req CompletionRequest) (*http.Request, error) {
+	jsonBody, err := json.Marshal(newGeminiRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/models/%s:%s", p.baseURL, p.model, method)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+	return httpReq, nil
+}
+
+func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	httpReq, err := p.newRequest(ctx, "generateContent", req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("Gemini", resp)
+	}
+
+	var geminiResp geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&geminiResp); err != nil {
+		return nil, err
+	}
+
+	if len(geminiResp.Candidates) == 0 {
+		if geminiResp.PromptFeedback != nil && geminiResp.PromptFeedback.BlockReason != "" {
+			return nil, fmt.Errorf("prompt blocked by Gemini: %s", geminiResp.PromptFeedback.BlockReason)
+		}
+		return nil, fmt.Errorf("no response from Gemini")
+	}
+
+	completion := &CompletionResponse{
+		Content:      geminiResp.text(),
+		FinishReason: geminiResp.finishReason(),
+	}
+	if usage := geminiResp.usage(); usage != nil {
+		completion.Usage = *usage
+	}
+	return completion, nil
+}
+
+// Stream reads server-sent events from streamGenerateContent. Each event is a
+// whole response holding the next piece of the answer; the last has a finish
+// reason and the final token counts.
+func (p *GeminiProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	httpReq, err := p.newRequest(ctx, "streamGenerateContent?alt=sse", req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.client.Do(httpReq) //nolint:bodyclose // closed in goroutine
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := newAPIError("Gemini", resp)
+		resp.Body.Close()
+		return nil, apiErr
+	}
+
+	chunks := make(chan StreamChunk)
+
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var usage *Usage
+		finished := false
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+
+			data := strings.TrimPrefix(line, "data: ")
+
+			var event geminiResponse
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+
+			if event.Error != nil {
+				chunks <- StreamChunk{Error: &APIError{
+					Provider:   "Gemini",
+					StatusCode: event.Error.Code,
+					Status:     event.Error.Status,
+					Body:       event.Error.Message,
+				}}
+				return
+			}
+
+			if text := event.text(); text != "" {
+				chunks <- StreamChunk{Content: text}
+			}
+			if u := event.usage(); u != nil {
+				usage = u
+			}
+			if event.finishReason() != "" {
+				finished = true
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			chunks <- StreamChunk{Error: err}
+			return
+		}
+		if finished {
+			chunks <- StreamChunk{Done: true, Usage: usage}
+		}
+	}()
+
+	return chunks, nil
+}
@@ -20,8 +20,13 @@ func NewLocalProvider(baseURL, model string) *LocalProvider {
 
 	// Local providers don't need an API key, but we set a dummy one
 	// to satisfy the OpenAI client
+	openai := NewOpenAIProvider("local", baseURL, model)
+
+	// Not every OpenAI-compatible server understands stream_options
+	openai.streamUsage = false
+
 	return &LocalProvider{
-		OpenAIProvider: NewOpenAIProvider("local", baseURL, model),
+		OpenAIProvider: openai,
 	}
 }
 
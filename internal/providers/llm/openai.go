@@ -6,16 +6,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 )
 
 type OpenAIProvider struct {
-	apiKey  string
-	baseURL string
-	model   string
-	client  *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	client      *http.Client
+	streamUsage bool
 }
 
 func NewOpenAIProvider(apiKey, baseURL, model string) *OpenAIProvider {
@@ -26,10 +26,11 @@ func NewOpenAIProvider(apiKey, baseURL, model string) *OpenAIProvider {
 		model = "gpt-4o"
 	}
 	return &OpenAIProvider{
-		apiKey:  apiKey,
-		baseURL: strings.TrimSuffix(baseURL, "/"),
-		model:   model,
-		client:  &http.Client{},
+		apiKey:      apiKey,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		model:       model,
+		client:      &http.Client{},
+		streamUsage: true,
 	}
 }
 
@@ -37,16 +38,25 @@ func (p *OpenAIProvider) Name() string {
 	return "openai"
 }
 
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
 func (p *OpenAIProvider) Available() bool {
 	return p.apiKey != ""
 }
 
 type openAIRequest struct {
-	Model       string          `json:"model"`
-	Messages    []openAIMessage `json:"messages"`
-	MaxTokens   int             `json:"max_tokens,omitempty"`
-	Temperature float64         `json:"temperature,omitempty"`
-	Stream      bool            `json:"stream,omitempty"`
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Temperature   float64              `json:"temperature,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openAIMessage struct {
@@ -63,7 +73,7 @@ type openAIResponse struct {
 			Content string `json:"content"`
 		} `json:"delta"`
 	} `json:"choices"`
-	Usage struct {
+	Usage *struct {
 		PromptTokens     int `json:"prompt_tokens"`
 		CompletionTokens int `json:"completion_tokens"`
 		TotalTokens      int `json:"total_tokens"`
@@ -104,8 +114,7 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, newAPIError("OpenAI", resp)
 	}
 
 	var openAIResp openAIResponse
@@ -117,15 +126,19 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		return nil, fmt.Errorf("no response from OpenAI")
 	}
 
-	return &CompletionResponse{
+	completion := &CompletionResponse{
 		Content:      openAIResp.Choices[0].Message.Content,
 		FinishReason: openAIResp.Choices[0].FinishReason,
-		Usage: Usage{
+	}
+	if openAIResp.Usage != nil {
+		completion.Usage = Usage{
 			PromptTokens:     openAIResp.Usage.PromptTokens,
 			CompletionTokens: openAIResp.Usage.CompletionTokens,
 			TotalTokens:      openAIResp.Usage.TotalTokens,
-		},
-	}, nil
+		}
+	}
+
+	return completion, nil
 }
 
 func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
@@ -141,6 +154,9 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-c
 		Temperature: req.Temperature,
 		Stream:      true,
 	}
+	if p.streamUsage {
+		body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
 
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
@@ -162,9 +178,9 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-c
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
+		apiErr := newAPIError("OpenAI", resp)
 		resp.Body.Close()
-		return nil, fmt.Errorf("OpenAI API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, apiErr
 	}
 
 	chunks := make(chan StreamChunk)
@@ -173,6 +189,8 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-c
 		defer close(chunks)
 		defer resp.Body.Close()
 
+		var usage *Usage
+
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -183,7 +201,7 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-c
 
 			data := strings.TrimPrefix(line, "data: ")
 			if data == "[DONE]" {
-				chunks <- StreamChunk{Done: true}
+				chunks <- StreamChunk{Done: true, Usage: usage}
 				return
 			}
 
@@ -192,6 +210,15 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-c
 				continue
 			}
 
+			// With include_usage the final chunk carries usage and no choices
+			if streamResp.Usage != nil {
+				usage = &Usage{
+					PromptTokens:     streamResp.Usage.PromptTokens,
+					CompletionTokens: streamResp.Usage.CompletionTokens,
+					TotalTokens:      streamResp.Usage.TotalTokens,
+				}
+			}
+
 			if len(streamResp.Choices) > 0 {
 				content := streamResp.Choices[0].Delta.Content
 				if content != "" {
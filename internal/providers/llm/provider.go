@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/oluoyefeso/termiflow/internal/config"
 )
@@ -23,6 +24,9 @@ type CompletionResponse struct {
 	Content      string
 	FinishReason string
 	Usage        Usage
+	// Provider and Model name who answered when it came through a Chain
+	Provider string
+	Model    string
 }
 
 type Usage struct {
@@ -35,34 +39,59 @@ type StreamChunk struct {
 	Content string
 	Done    bool
 	Error   error
+	// Usage is set on the final chunk when the provider reports token counts
+	Usage *Usage
+	// Provider and Model name who sent the chunk when it came through a Chain
+	Provider string
+	Model    string
+	// Restart means the provider failed part way and another is answering
+	// from the start: content received so far should be discarded
+	Restart bool
 }
 
 type Provider interface {
 	Name() string
+	Model() string
 	Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
 	Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error)
 	Available() bool
 }
 
+// GetProvider returns the named provider, limited to its requests per minute
+// and retrying failed calls as providers.retry says.
 func GetProvider(name string, cfg *config.Config) (Provider, error) {
+	var p Provider
 	switch name {
 	case "openai":
-		return NewOpenAIProvider(
+		p = WithRateLimit(NewOpenAIProvider(
 			cfg.Providers.OpenAI.APIKey,
 			cfg.Providers.OpenAI.BaseURL,
 			cfg.Providers.OpenAI.Model,
-		), nil
+		), cfg.Providers.OpenAI.RequestsPerMinute)
 	case "anthropic":
-		return NewAnthropicProvider(
+		p = WithRateLimit(NewAnthropicProvider(
 			cfg.Providers.Anthropic.APIKey,
 			cfg.Providers.Anthropic.Model,
-		), nil
+		), cfg.Providers.Anthropic.RequestsPerMinute)
+	case "gemini":
+		p = WithRateLimit(NewGeminiProvider(
+			cfg.Providers.Gemini.APIKey,
+			cfg.Providers.Gemini.BaseURL,
+			cfg.Providers.Gemini.Model,
+		), cfg.Providers.Gemini.RequestsPerMinute)
 	case "local":
-		return NewLocalProvider(
+		p = WithRateLimit(NewLocalProvider(
 			cfg.Providers.Local.BaseURL,
 			cfg.Providers.Local.Model,
-		), nil
+		), cfg.Providers.Local.RequestsPerMinute)
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", name)
 	}
+
+	// Every retry waits for a request slot like the first attempt
+	return WithRetry(p, RetryOptions{
+		MaxAttempts: cfg.Providers.Retry.MaxAttempts,
+		BaseDelay:   time.Duration(cfg.Providers.Retry.BaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.Providers.Retry.MaxDelayMs) * time.Millisecond,
+	}), nil
 }
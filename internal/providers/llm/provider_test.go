@@ -3,11 +3,19 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
 func TestGetProvider(t *testing.T) {
@@ -22,6 +30,10 @@ func TestGetProvider(t *testing.T) {
 				APIKey: "test-anthropic-key",
 				Model:  "claude-3-opus",
 			},
+			Gemini: config.GeminiConfig{
+				APIKey: "test-gemini-key",
+				Model:  "gemini-2.5-flash",
+			},
 			Local: config.LocalConfig{
 				BaseURL: "http://localhost:11434/v1",
 				Model:   "llama3",
@@ -37,6 +49,7 @@ func TestGetProvider(t *testing.T) {
 	}{
 		{"openai", "openai", false, "openai"},
 		{"anthropic", "anthropic", false, "anthropic"},
+		{"gemini", "gemini", false, "gemini"},
 		{"local", "local", false, "local"},
 		{"unknown", "unknown", true, ""},
 	}
@@ -323,3 +336,834 @@ func TestStreamChunk(t *testing.T) {
 		t.Error("Error should be nil")
 	}
 }
+
+func TestProvider_Model(t *testing.T) {
+	if got := NewOpenAIProvider("key", "", "gpt-4o-mini").Model(); got != "gpt-4o-mini" {
+		t.Errorf("OpenAIProvider.Model() = %q, want %q", got, "gpt-4o-mini")
+	}
+	if got := NewAnthropicProvider("key", "").Model(); got != "claude-sonnet-4-20250514" {
+		t.Errorf("AnthropicProvider.Model() = %q, want default", got)
+	}
+	if got := NewLocalProvider("", "").Model(); got != "llama3" {
+		t.Errorf("LocalProvider.Model() = %q, want %q", got, "llama3")
+	}
+}
+
+func TestOpenAIProvider_StreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if _, ok := req["stream_options"]; !ok {
+			t.Error("Expected stream_options in streaming request")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\", world\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3,\"total_tokens\":15}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key", server.URL, "gpt-4o")
+
+	chunks, err := p.Stream(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	var content string
+	var usage *Usage
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			t.Fatalf("Stream() chunk error = %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.Done {
+			usage = chunk.Usage
+		}
+	}
+
+	if content != "Hello, world" {
+		t.Errorf("content = %q, want %q", content, "Hello, world")
+	}
+	if usage == nil {
+		t.Fatal("final chunk should carry usage")
+	}
+	if usage.TotalTokens != 15 {
+		t.Errorf("TotalTokens = %d, want 15", usage.TotalTokens)
+	}
+}
+
+func TestLocalProvider_StreamOmitsUsageOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if _, ok := req["stream_options"]; ok {
+			t.Error("Local provider should not send stream_options")
+		}
+
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	p := NewLocalProvider(server.URL, "llama3")
+
+	chunks, err := p.Stream(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	for range chunks {
+	}
+}
+
+func TestGeminiProvider_Defaults(t *testing.T) {
+	p := NewGeminiProvider("key", "", "")
+	if p.baseURL != "https://generativelanguage.googleapis.com/v1beta" {
+		t.Errorf("baseURL = %q, want default", p.baseURL)
+	}
+	if p.Model() != "gemini-2.5-flash" {
+		t.Errorf("Model() = %q, want default", p.Model())
+	}
+	if p.Name() != "gemini" {
+		t.Errorf("Name() = %q, want %q", p.Name(), "gemini")
+	}
+	if !p.Available() {
+		t.Error("GeminiProvider with a key should be available")
+	}
+	if NewGeminiProvider("", "", "").Available() {
+		t.Error("GeminiProvider without a key should not be available")
+	}
+}
+
+func TestGeminiProvider_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:generateContent" {
+			t.Errorf("Expected /models/gemini-2.5-flash:generateContent, got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			t.Errorf("Wrong x-goog-api-key header")
+		}
+
+		var req geminiRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if req.SystemInstruction == nil || len(req.SystemInstruction.Parts) != 1 || req.SystemInstruction.Parts[0].Text != "Be brief." {
+			t.Errorf("systemInstruction = %+v, want the system message", req.SystemInstruction)
+		}
+		var roles []string
+		for _, c := range req.Contents {
+			roles = append(roles, c.Role)
+		}
+		if fmt.Sprint(roles) != "[user model user]" {
+			t.Errorf("roles = %v, want [user model user]", roles)
+		}
+		if req.GenerationConfig == nil || req.GenerationConfig.MaxOutputTokens != 100 {
+			t.Errorf("generationConfig = %+v, want maxOutputTokens 100", req.GenerationConfig)
+		}
+
+		w.Write([]byte(`{
+			"candidates": [{
+				"content": {"role": "model", "parts": [{"text": "Weighing it up", "thought": true}, {"text": "Hello, "}, {"text": "world!"}]},
+				"finishReason": "STOP"
+			}],
+			"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "thoughtsTokenCount": 3, "totalTokenCount": 18}
+		}`))
+	}))
+	defer server.Close()
+
+	p := NewGeminiProvider("test-key", server.URL+"/", "gemini-2.5-flash")
+
+	resp, err := p.Complete(context.Background(), CompletionRequest{
+		Messages: []Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Hi"},
+			{Role: "assistant", Content: "Hello"},
+			{Role: "user", Content: "Say hello to the world"},
+		},
+		MaxTokens: 100,
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if resp.Content != "Hello, world!" {
+		t.Errorf("Content = %q, want %q", resp.Content, "Hello, world!")
+	}
+	if resp.FinishReason != "STOP" {
+		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "STOP")
+	}
+	if resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 8 || resp.Usage.TotalTokens != 18 {
+		t.Errorf("Usage = %+v, want 10 prompt and 8 completion tokens, thoughts included", resp.Usage)
+	}
+}
+
+func TestGeminiProvider_CompleteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"bad status", http.StatusTooManyRequests, `{"error": {"code": 429, "status": "RESOURCE_EXHAUSTED"}}`},
+		{"blocked prompt", http.StatusOK, `{"promptFeedback": {"blockReason": "SAFETY"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := NewGeminiProvider("test-key", server.URL, "")
+			_, err := p.Complete(context.Background(), CompletionRequest{
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			})
+			if err == nil {
+				t.Fatal("Complete() should return error")
+			}
+			if tt.status != http.StatusOK && !IsRetryable(err) {
+				t.Errorf("Complete() error = %v, want a retryable API error", err)
+			}
+		})
+	}
+}
+
+func TestGeminiProvider_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:streamGenerateContent" {
+			t.Errorf("Expected /models/gemini-2.5-flash:streamGenerateContent, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("alt = %q, want sse", r.URL.Query().Get("alt"))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hello\"}]}}]}\r\n\r\n"))
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\", world\"}]},\"finishReason\":\"STOP\"}]," +
+			"\"usageMetadata\":{\"promptTokenCount\":12,\"candidatesTokenCount\":3,\"totalTokenCount\":15}}\r\n\r\n"))
+	}))
+	defer server.Close()
+
+	p := NewGeminiProvider("test-key", server.URL, "")
+
+	chunks, err := p.Stream(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	var content string
+	var usage *Usage
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			t.Fatalf("Stream() chunk error = %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.Done {
+			usage = chunk.Usage
+		}
+	}
+
+	if content != "Hello, world" {
+		t.Errorf("content = %q, want %q", content, "Hello, world")
+	}
+	if usage == nil {
+		t.Fatal("final chunk should carry usage")
+	}
+	if usage.TotalTokens != 15 {
+		t.Errorf("TotalTokens = %d, want 15", usage.TotalTokens)
+	}
+}
+
+func TestGeminiProvider_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hel\"}]}}]}\n\n"))
+		w.Write([]byte("data: {\"error\":{\"code\":503,\"message\":\"The model is overloaded.\",\"status\":\"UNAVAILABLE\"}}\n\n"))
+	}))
+	defer server.Close()
+
+	p := NewGeminiProvider("test-key", server.URL, "")
+
+	chunks, err := p.Stream(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	var failure error
+	for chunk := range chunks {
+		if chunk.Done {
+			t.Error("a failed stream should not finish")
+		}
+		if chunk.Error != nil {
+			failure = chunk.Error
+		}
+	}
+
+	var apiErr *APIError
+	if !errors.As(failure, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("stream error = %v, want a 503 APIError", failure)
+	}
+	if !IsRetryable(failure) {
+		t.Error("an overloaded model should be retryable")
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	p := NewLocalProvider("", "")
+
+	if got := WithRateLimit(p, 0); got != Provider(p) {
+		t.Error("WithRateLimit(p, 0) should return p unchanged")
+	}
+
+	limited := WithRateLimit(p, 60)
+	if limited.Name() != "local" {
+		t.Errorf("Name() = %q, want %q", limited.Name(), "local")
+	}
+	if limited.Model() != p.Model() {
+		t.Errorf("Model() = %q, want %q", limited.Model(), p.Model())
+	}
+
+	// A cancelled context gives up waiting for a slot without calling through
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limited.Complete(ctx, CompletionRequest{}); err != context.Canceled {
+		t.Errorf("Complete() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// flakyServer answers each request with the next handler in turn, repeating
+// the last one, and counts the requests.
+func flakyServer(t *testing.T, hits *atomic.Int32, handlers ...http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		handlers[min(n, len(handlers))-1](w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func failWith(status int, header ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error": "try later"}`))
+	}
+}
+
+func answer(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []map[string]interface{}{
+			{"message": map[string]string{"role": "assistant", "content": "ok"}, "finish_reason": "stop"},
+		},
+	})
+}
+
+// hangUp drops the connection without answering, like a reset.
+func hangUp(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func TestWithRetry_Complete(t *testing.T) {
+	fast := RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name      string
+		handlers  []http.HandlerFunc
+		wantErr   bool
+		wantHits  int32
+		wantDelay time.Duration
+	}{
+		{"success", []http.HandlerFunc{answer}, false, 1, 0},
+		{"rate limited", []http.HandlerFunc{failWith(429, "Retry-After-Ms", "50"), answer}, false, 2, 50 * time.Millisecond},
+		{"overloaded then reset", []http.HandlerFunc{failWith(529), hangUp, answer}, false, 3, 0},
+		{"server errors exhaust attempts", []http.HandlerFunc{failWith(500)}, true, 3, 0},
+		{"bad request", []http.HandlerFunc{failWith(400)}, true, 1, 0},
+		{"retry-after too long", []http.HandlerFunc{failWith(429, "Retry-After", "120"), answer}, true, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			server := flakyServer(t, &hits, tt.handlers...)
+			p := WithRetry(NewOpenAIProvider("test-key", server.URL, "gpt-4o"), fast)
+
+			start := time.Now()
+			resp, err := p.Complete(context.Background(), CompletionRequest{Messages: []Message{{Role: "user", Content: "Hello"}}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.Content != "ok" {
+				t.Errorf("Content = %q, want %q", resp.Content, "ok")
+			}
+			if n := hits.Load(); n != tt.wantHits {
+				t.Errorf("server saw %d requests, want %d", n, tt.wantHits)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantDelay {
+				t.Errorf("Complete() took %v, want at least the %v asked for", elapsed, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestWithRetry_Stream(t *testing.T) {
+	var hits atomic.Int32
+	server := flakyServer(t, &hits, failWith(503), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n"))
+	})
+	p := WithRetry(NewOpenAIProvider("test-key", server.URL, "gpt-4o"), RetryOptions{BaseDelay: time.Millisecond})
+
+	chunks, err := p.Stream(context.Background(), CompletionRequest{Messages: []Message{{Role: "user", Content: "Hello"}}})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	var content string
+	for chunk := range chunks {
+		content += chunk.Content
+	}
+	if content != "ok" || hits.Load() != 2 {
+		t.Errorf("Stream() = %q after %d requests, want %q after 2", content, hits.Load(), "ok")
+	}
+}
+
+func TestWithRetry_Cancel(t *testing.T) {
+	var hits atomic.Int32
+	server := flakyServer(t, &hits, failWith(503, "Retry-After", "5"))
+	p := WithRetry(NewOpenAIProvider("test-key", server.URL, "gpt-4o"), RetryOptions{MaxDelay: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := p.Complete(ctx, CompletionRequest{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Complete() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Complete() took %v after the context was cancelled", elapsed)
+	}
+
+	// A deadline that would pass during the wait fails without waiting
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var apiErr *APIError
+	if _, err := p.Complete(ctx, CompletionRequest{}); !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Errorf("Complete() error = %v, want the 503", err)
+	}
+
+	if got := WithRetry(p, RetryOptions{MaxAttempts: 1}); got != p {
+		t.Error("WithRetry(p, MaxAttempts 1) should return p unchanged")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, 250 * time.Millisecond},
+		{"date", http.Header{"Retry-After": {"Fri, 01 Mar 2024 12:00:10 GMT"}}, 10 * time.Second},
+		{"past date", http.Header{"Retry-After": {"Fri, 01 Mar 2024 11:00:00 GMT"}}, 0},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.header, now); got != tt.want {
+			t.Errorf("retryAfter(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", &APIError{StatusCode: 429}, true},
+		{"overloaded", fmt.Errorf("curating: %w", &APIError{StatusCode: 529}), true},
+		{"server error", &APIError{StatusCode: 502}, true},
+		{"unauthorized", &APIError{StatusCode: 401}, false},
+		{"reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"cancelled", context.Canceled, false},
+		{"other", errors.New("no response from OpenAI"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// scriptedProvider answers with fixed results, for testing wrappers.
+type scriptedProvider struct {
+	name        string
+	unavailable bool
+	err         error
+	// chunks are streamed as given; Stream fails with err when it's set
+	chunks []StreamChunk
+	// usage is reported by Complete
+	usage Usage
+	calls atomic.Int32
+}
+
+func (p *scriptedProvider) Name() string    { return p.name }
+func (p *scriptedProvider) Model() string   { return p.name + "-model" }
+func (p *scriptedProvider) Available() bool { return !p.unavailable }
+
+func (p *scriptedProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	p.calls.Add(1)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &CompletionResponse{Content: "from " + p.name, Usage: p.usage}, nil
+}
+
+func (p *scriptedProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	p.calls.Add(1)
+	if p.err != nil {
+		return nil, p.err
+	}
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		for _, c := range p.chunks {
+			chunks <- c
+		}
+	}()
+	return chunks, nil
+}
+
+func TestChain_Complete(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []*scriptedProvider
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "skips unavailable and unreachable",
+			providers: []*scriptedProvider{
+				{name: "a", unavailable: true},
+				{name: "b", err: &APIError{StatusCode: 503}},
+				{name: "c", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}},
+				{name: "d"},
+			},
+			want: "d",
+		},
+		{
+			name: "bad credentials fall over",
+			providers: []*scriptedProvider{
+				{name: "a", err: &APIError{StatusCode: 401}},
+				{name: "b"},
+			},
+			want: "b",
+		},
+		{
+			name: "bad request stops",
+			providers: []*scriptedProvider{
+				{name: "a", err: &APIError{StatusCode: 400}},
+				{name: "b"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "every provider fails",
+			providers: []*scriptedProvider{
+				{name: "a", err: &APIError{StatusCode: 429}},
+				{name: "b", err: &APIError{StatusCode: 500}},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "none configured",
+			providers: []*scriptedProvider{{name: "a", unavailable: true}, {name: "b", unavailable: true}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]Provider, len(tt.providers))
+			for i, p := range tt.providers {
+				providers[i] = p
+			}
+			chain := NewChain(providers...)
+
+			resp, err := chain.Complete(context.Background(), CompletionRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if resp.Provider != tt.want || resp.Model != tt.want+"-model" || resp.Content != "from "+tt.want {
+				t.Errorf("Complete() = %+v, want an answer from %s", resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestChain_Stream(t *testing.T) {
+	reset := &net.OpError{Op: "read", Err: syscall.ECONNRESET}
+
+	tests := []struct {
+		name      string
+		providers []*scriptedProvider
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "fails part way",
+			providers: []*scriptedProvider{
+				{name: "a", chunks: []StreamChunk{{Content: "Hel"}, {Error: reset}}},
+				{name: "b", chunks: []StreamChunk{{Content: "Hello"}, {Done: true}}},
+			},
+			want: []string{"a:Hel", "restart:b", "b:Hello", "b:done"},
+		},
+		{
+			name: "fails before any content",
+			providers: []*scriptedProvider{
+				{name: "a", chunks: []StreamChunk{{Error: &APIError{StatusCode: 529}}}},
+				{name: "b", err: &APIError{StatusCode: 503}},
+				{name: "c", chunks: []StreamChunk{{Content: "Hi"}, {Done: true}}},
+			},
+			want: []string{"c:Hi", "c:done"},
+		},
+		{
+			name: "cut off without an error",
+			providers: []*scriptedProvider{
+				{name: "a", chunks: []StreamChunk{{Content: "Hel"}}},
+				{name: "b", chunks: []StreamChunk{{Content: "Hello"}, {Done: true}}},
+			},
+			want: []string{"a:Hel", "restart:b", "b:Hello", "b:done"},
+		},
+		{
+			name: "last provider fails",
+			providers: []*scriptedProvider{
+				{name: "a", err: &APIError{StatusCode: 401}},
+				{name: "b", chunks: []StreamChunk{{Content: "Hel"}, {Error: reset}}},
+			},
+			want:    []string{"b:Hel"},
+			wantErr: true,
+		},
+		{
+			name: "error that won't fall over",
+			providers: []*scriptedProvider{
+				{name: "a", chunks: []StreamChunk{{Error: &APIError{StatusCode: 400}}}},
+				{name: "b", chunks: []StreamChunk{{Content: "Hello"}, {Done: true}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]Provider, len(tt.providers))
+			for i, p := range tt.providers {
+				providers[i] = p
+			}
+
+			chunks, err := NewChain(providers...).Stream(context.Background(), CompletionRequest{})
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+
+			var got []string
+			var streamErr error
+			for chunk := range chunks {
+				switch {
+				case chunk.Error != nil:
+					streamErr = chunk.Error
+				case chunk.Restart:
+					got = append(got, "restart:"+chunk.Provider)
+				case chunk.Done:
+					got = append(got, chunk.Provider+":done")
+				default:
+					got = append(got, chunk.Provider+":"+chunk.Content)
+				}
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Stream() chunks = %v, want %v", got, tt.want)
+			}
+			if (streamErr != nil) != tt.wantErr {
+				t.Errorf("Stream() error chunk = %v, wantErr %v", streamErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetChain(t *testing.T) {
+	var hits atomic.Int32
+	server := flakyServer(t, &hits, answer)
+
+	cfg := &config.Config{
+		Providers: config.ProvidersConfig{
+			Anthropic: config.AnthropicConfig{Model: "claude-3-opus"},
+			Local:     config.LocalConfig{BaseURL: server.URL, Model: "llama3"},
+		},
+	}
+
+	single, err := GetChain([]string{"local"}, cfg)
+	if err != nil {
+		t.Fatalf("GetChain(local) error = %v", err)
+	}
+	if _, ok := single.(*Chain); ok {
+		t.Error("GetChain() of one provider should return it unchained")
+	}
+
+	if _, err := GetChain([]string{"anthropic", "nope"}, cfg); err == nil {
+		t.Error("GetChain() with an unknown provider should return an error")
+	}
+
+	// Anthropic has no API key, so local answers
+	chain, err := GetChain([]string{"anthropic", "local"}, cfg)
+	if err != nil {
+		t.Fatalf("GetChain() error = %v", err)
+	}
+	if chain.Name() != "local" {
+		t.Errorf("Name() = %q, want the first configured provider", chain.Name())
+	}
+	resp, err := chain.Complete(context.Background(), CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Provider != "local" || resp.Model != "llama3" {
+		t.Errorf("Complete() answered by %s (%s), want local (llama3)", resp.Provider, resp.Model)
+	}
+}
+
+// usageLog keeps what WithUsage records.
+type usageLog struct {
+	records []*models.UsageRecord
+}
+
+func (l *usageLog) RecordUsage(u *models.UsageRecord) error {
+	l.records = append(l.records, u)
+	return nil
+}
+
+func TestPriceTable(t *testing.T) {
+	prices := append(PriceTable{{Model: "gpt-4o", Prompt: 1, Completion: 1}}, DefaultPrices...)
+
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"gpt-4o-mini-2024-07-18", 0.15 + 0.60},
+		{"gpt-4o-2024-08-06", 2},
+		{"claude-sonnet-4-20250514", 3 + 15},
+		{"llama3", 0},
+	}
+
+	for _, tt := range tests {
+		if got := prices.Cost(tt.model, 1_000_000, 1_000_000); fmt.Sprintf("%.4f", got) != fmt.Sprintf("%.4f", tt.want) {
+			t.Errorf("Cost(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestWithUsage_Complete(t *testing.T) {
+	var log usageLog
+	prices := PriceTable{{Model: "a-model", Prompt: 1, Completion: 2}}
+
+	ctx := WithTopic(WithPurpose(context.Background(), PurposeSummarize), "rust")
+	reported := WithUsage(&scriptedProvider{name: "a", usage: Usage{PromptTokens: 1000, CompletionTokens: 500}}, &log, prices)
+	if _, err := reported.Complete(ctx, CompletionRequest{}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	// No usage reported: estimated from 16 characters in, 6 out
+	silent := WithUsage(&scriptedProvider{name: "b"}, &log, prices)
+	req := CompletionRequest{Messages: []Message{{Role: "user", Content: "sixteen chars..."}}}
+	if _, err := silent.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if len(log.records) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(log.records))
+	}
+	got := *log.records[0]
+	want := models.UsageRecord{Provider: "a", Model: "a-model", Purpose: PurposeSummarize, Topic: "rust", PromptTokens: 1000, CompletionTokens: 500, Cost: 0.002}
+	if got != want {
+		t.Errorf("recorded %+v, want %+v", got, want)
+	}
+	got = *log.records[1]
+	want = models.UsageRecord{Provider: "b", Model: "b-model", Purpose: PurposeOther, PromptTokens: 4, CompletionTokens: 2, Estimated: true}
+	if got != want {
+		t.Errorf("recorded %+v, want %+v", got, want)
+	}
+}
+
+func TestWithUsage_Stream(t *testing.T) {
+	var log usageLog
+
+	// A chain fell over part way: only the answer that finished counts
+	p := WithUsage(&scriptedProvider{name: "chain", chunks: []StreamChunk{
+		{Content: "abandoned answer", Provider: "a", Model: "a-model"},
+		{Restart: true, Provider: "b", Model: "b-model"},
+		{Content: "12345678", Provider: "b", Model: "b-model"},
+		{Done: true, Provider: "b", Model: "b-model"},
+	}}, &log, DefaultPrices)
+
+	ctx := WithPurpose(context.Background(), PurposeAsk)
+	chunks, err := p.Stream(ctx, CompletionRequest{Messages: []Message{{Content: "1234"}}})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	n := 0
+	for range chunks {
+		n++
+	}
+	if n != 4 {
+		t.Errorf("Stream() sent %d chunks, want 4", n)
+	}
+
+	if len(log.records) != 1 {
+		t.Fatalf("recorded %d calls, want 1", len(log.records))
+	}
+	got := *log.records[0]
+	want := models.UsageRecord{Provider: "b", Model: "b-model", Purpose: PurposeAsk, PromptTokens: 1, CompletionTokens: 2, Estimated: true}
+	if got != want {
+		t.Errorf("recorded %+v, want %+v", got, want)
+	}
+
+	// Usage on the final chunk is used as reported
+	log.records = nil
+	p = WithUsage(&scriptedProvider{name: "a", chunks: []StreamChunk{
+		{Content: "hi"},
+		{Done: true, Usage: &Usage{PromptTokens: 10, CompletionTokens: 1}},
+	}}, &log, DefaultPrices)
+	chunks, _ = p.Stream(ctx, CompletionRequest{})
+	for range chunks {
+	}
+	if len(log.records) != 1 || log.records[0].PromptTokens != 10 || log.records[0].Estimated {
+		t.Errorf("recorded %+v, want reported usage", log.records)
+	}
+}
@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/oluoyefeso/termiflow/internal/ratelimit"
+)
+
+// rateLimitedProvider waits for a request slot before every call, so
+// concurrent refreshes stay within the provider's requests-per-minute limit.
+type rateLimitedProvider struct {
+	Provider
+	limiter *ratelimit.Limiter
+}
+
+// WithRateLimit wraps p so it makes at most perMinute requests per minute.
+// A perMinute of zero or less returns p unchanged.
+func WithRateLimit(p Provider, perMinute int) Provider {
+	limiter := ratelimit.New(perMinute)
+	if limiter == nil {
+		return p
+	}
+	return &rateLimitedProvider{Provider: p, limiter: limiter}
+}
+
+func (p *rateLimitedProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *rateLimitedProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.Stream(ctx, req)
+}
@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// APIError is a response from a provider's API that wasn't a success.
+type APIError struct {
+	// Provider names the API in messages, e.g. "OpenAI"
+	Provider   string
+	StatusCode int
+	Status     string
+	Body       string
+	// RetryAfter is how long the API asked callers to wait, if it said
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error: %s - %s", e.Provider, e.Status, e.Body)
+}
+
+// newAPIError reads a failed response's body and any wait it asked for.
+func newAPIError(provider string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+		RetryAfter: retryAfter(resp.Header, time.Now()),
+	}
+}
+
+// retryAfter reads retry-after-ms, which OpenAI sends, or Retry-After in
+// seconds or as a date.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := strings.TrimSpace(h.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// IsRetryable reports whether err is worth trying again: rate limits,
+// overloaded or failing servers, and connections that dropped or timed out.
+// Cancellation by the caller isn't.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= 500
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryOptions tunes WithRetry. Zero values pick the defaults.
+type RetryOptions struct {
+	// MaxAttempts counts the first try
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubling after each
+	BaseDelay time.Duration
+	// MaxDelay caps each wait. A Retry-After longer than this fails the
+	// call rather than holding it up.
+	MaxDelay time.Duration
+}
+
+const (
+	defaultRetryAttempts  = 4
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// retryProvider calls again after retryable errors, so a brief rate limit or
+// outage doesn't lose an answer or a curated item.
+type retryProvider struct {
+	Provider
+	opts RetryOptions
+}
+
+// WithRetry wraps p so failed calls are retried with exponential backoff and
+// jitter, waiting as long as a Retry-After asks. A MaxAttempts of 1 returns p
+// unchanged.
+func WithRetry(p Provider, opts RetryOptions) Provider {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultRetryAttempts
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultRetryBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultRetryMaxDelay
+	}
+	if opts.MaxAttempts == 1 {
+		return p
+	}
+	return &retryProvider{Provider: p, opts: opts}
+}
+
+func (p *retryProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var resp *CompletionResponse
+	err := p.retry(ctx, func() error {
+		var err error
+		resp, err = p.Provider.Complete(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Stream retries starting the stream. Once chunks are flowing a failure is
+// reported on the channel as usual, since the caller may have shown them.
+func (p *retryProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	var chunks <-chan StreamChunk
+	err := p.retry(ctx, func() error {
+		var err error
+		chunks, err = p.Provider.Stream(ctx, req)
+		return err
+	})
+	return chunks, err
+}
+
+func (p *retryProvider) retry(ctx context.Context, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= p.opts.MaxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		delay, ok := p.backoff(attempt, err)
+		if !ok {
+			return err
+		}
+		if deadline, set := ctx.Deadline(); set && time.Until(deadline) < delay {
+			// The caller would give up before the retry
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w while retrying: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// backoff returns how long to wait after the given failed attempt: what the
+// API asked for, or a jittered exponential delay. It returns false if the API
+// asked for longer than MaxDelay.
+func (p *retryProvider) backoff(attempt int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= p.opts.MaxDelay
+	}
+
+	delay := p.opts.BaseDelay
+	for i := 1; i < attempt && delay < p.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.opts.MaxDelay)
+	// Somewhere in the upper half, so concurrent callers don't retry in step
+	return delay/2 + rand.N(delay/2+1), true
+}
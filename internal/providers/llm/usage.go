@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// Purposes of LLM calls, as recorded in the usage ledger.
+const (
+	PurposeAsk       = "ask"
+	PurposeScore     = "score"
+	PurposeSummarize = "summarize"
+	PurposeTags      = "tags"
+	// PurposeCurate scores, summarizes and tags several results in one call
+	PurposeCurate = "curate"
+	// PurposeOther is recorded for calls made without a purpose
+	PurposeOther = "other"
+)
+
+type purposeKey struct{}
+
+type topicKey struct{}
+
+// WithPurpose returns a context whose LLM calls are recorded as being for
+// purpose.
+func WithPurpose(ctx context.Context, purpose string) context.Context {
+	return context.WithValue(ctx, purposeKey{}, purpose)
+}
+
+// WithTopic returns a context whose LLM calls are recorded against a
+// subscription's topic.
+func WithTopic(ctx context.Context, topic string) context.Context {
+	return context.WithValue(ctx, topicKey{}, topic)
+}
+
+func purposeFrom(ctx context.Context) string {
+	if purpose, ok := ctx.Value(purposeKey{}).(string); ok && purpose != "" {
+		return purpose
+	}
+	return PurposeOther
+}
+
+func topicFrom(ctx context.Context) string {
+	topic, _ := ctx.Value(topicKey{}).(string)
+	return topic
+}
+
+// UsageRecorder stores a record of each LLM call.
+type UsageRecorder interface {
+	RecordUsage(u *models.UsageRecord) error
+}
+
+// Price is what a model costs in US dollars per million tokens.
+type Price struct {
+	// Model is matched as a prefix, so "gpt-4o" prices dated snapshots too
+	Model      string
+	Prompt     float64
+	Completion float64
+}
+
+// PriceTable prices calls by model. The longest matching prefix wins.
+type PriceTable []Price
+
+// DefaultPrices are list prices for the models termiflow is usually run
+// with. Models not listed, such as local ones, cost nothing.
+var DefaultPrices = PriceTable{
+	{Model: "gpt-4o", Prompt: 2.50, Completion: 10},
+	{Model: "gpt-4o-mini", Prompt: 0.15, Completion: 0.60},
+	{Model: "gpt-4.1", Prompt: 2, Completion: 8},
+	{Model: "gpt-4.1-mini", Prompt: 0.40, Completion: 1.60},
+	{Model: "gpt-4.1-nano", Prompt: 0.10, Completion: 0.40},
+	{Model: "claude-opus-4", Prompt: 15, Completion: 75},
+	{Model: "claude-sonnet-4", Prompt: 3, Completion: 15},
+	{Model: "claude-3-7-sonnet", Prompt: 3, Completion: 15},
+	{Model: "claude-3-5-sonnet", Prompt: 3, Completion: 15},
+	{Model: "claude-3-5-haiku", Prompt: 0.80, Completion: 4},
+	{Model: "claude-3-opus", Prompt: 15, Completion: 75},
+	{Model: "gemini-2.5-pro", Prompt: 1.25, Completion: 10},
+	{Model: "gemini-2.5-flash", Prompt: 0.30, Completion: 2.50},
+	{Model: "gemini-2.5-flash-lite", Prompt: 0.10, Completion: 0.40},
+	{Model: "gemini-2.0-flash", Prompt: 0.10, Completion: 0.40},
+}
+
+// Lookup returns the price of model and whether it has one. Of equally long
+// matches the first listed wins, so a table can override entries after it.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	var best Price
+	found := false
+	for _, p := range t {
+		if p.Model == "" || !strings.HasPrefix(model, p.Model) {
+			continue
+		}
+		if !found || len(p.Model) > len(best.Model) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// Cost returns what a call to model using the given tokens cost in US
+// dollars.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6
+}
+
+// usageProvider records every call it makes, so the cost of asking and of
+// refreshing subscriptions can be reported.
+type usageProvider struct {
+	Provider
+	recorder UsageRecorder
+	prices   PriceTable
+}
+
+// WithUsage wraps p so every answered call is recorded with its purpose,
+// topic, token counts and cost. Where p doesn't report token counts they're
+// estimated from the text. Failing to record doesn't fail the call.
+func WithUsage(p Provider, recorder UsageRecorder, prices PriceTable) Provider {
+	return &usageProvider{Provider: p, recorder: recorder, prices: prices}
+}
+
+func (p *usageProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, model := resp.Provider, resp.Model
+	if provider == "" {
+		provider, model = p.Name(), p.Model()
+	}
+	usage := &resp.Usage
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		usage = nil
+	}
+	p.record(ctx, provider, model, req, usage, len(resp.Content))
+
+	return resp, nil
+}
+
+// Stream records the call once the stream ends, against whichever provider
+// finished the answer.
+func (p *usageProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	chunks, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+
+		provider, model := p.Name(), p.Model()
+		var usage *Usage
+		length := 0
+		defer func() {
+			if usage != nil || length > 0 {
+				p.record(ctx, provider, model, req, usage, length)
+			}
+		}()
+
+		for chunk := range chunks {
+			if chunk.Provider != "" {
+				provider, model = chunk.Provider, chunk.Model
+			}
+			if chunk.Restart {
+				// The abandoned answer is a guess, so only the new one counts
+				usage, length = nil, 0
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			length += len(chunk.Content)
+
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go drain(chunks)
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// record stores a call. Without usage from the provider the tokens are
+// estimated from the request and the length of the answer.
+func (p *usageProvider) record(ctx context.Context, provider, model string, req CompletionRequest, usage *Usage, answerLength int) {
+	u := &models.UsageRecord{
+		Provider: provider,
+		Model:    model,
+		Purpose:  purposeFrom(ctx),
+		Topic:    topicFrom(ctx),
+	}
+
+	if usage != nil {
+		u.PromptTokens, u.CompletionTokens = usage.PromptTokens, usage.CompletionTokens
+	} else {
+		promptLength := 0
+		for _, m := range req.Messages {
+			promptLength += len(m.Content)
+		}
+		u.PromptTokens = estimateTokens(promptLength)
+		u.CompletionTokens = estimateTokens(answerLength)
+		u.Estimated = true
+	}
+	u.Cost = p.prices.Cost(model, u.PromptTokens, u.CompletionTokens)
+
+	p.recorder.RecordUsage(u)
+}
+
+// estimateTokens approximates the tokens in text of the given length at
+// about four characters each.
+func estimateTokens(length int) int {
+	return (length + 3) / 4
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmcdole/gofeed"
+
+	"github.com/oluoyefeso/termiflow/internal/workpool"
+)
+
+// feedLinkTypes are the <link rel="alternate"> types that announce a feed.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// commonFeedPaths are tried next to the page and at the site root when the
+// page doesn't link its feeds.
+var commonFeedPaths = []string{"feed", "rss.xml", "atom.xml", "feed.xml", "index.xml", "rss"}
+
+// DiscoveredFeed is a feed found for a website.
+type DiscoveredFeed struct {
+	URL   string
+	Title string
+	// Type is "rss", "atom" or "json"
+	Type  string
+	Items int
+}
+
+// DiscoverFeeds finds the feeds of the page at pageURL. If pageURL is a feed
+// itself it's the only result. Otherwise the page's <link rel="alternate">
+// feeds come first, followed by feeds at common paths such as /feed and
+// /rss.xml. Every candidate is fetched and parsed, and only working feeds
+// are returned.
+func (s *Scraper) DiscoverFeeds(ctx context.Context, pageURL string) ([]DiscoveredFeed, error) {
+	body, base, err := s.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if feed, err := gofeed.NewParser().Parse(bytes.NewReader(body)); err == nil {
+		return []DiscoveredFeed{newDiscoveredFeed(base.String(), feed)}, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := feedCandidates(doc, base)
+
+	found := make([]*DiscoveredFeed, len(candidates))
+	workpool.Run(ctx, feedConcurrency, len(candidates), func(i int) {
+		found[i] = s.validateFeed(ctx, candidates[i])
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var feeds []DiscoveredFeed
+	for _, f := range found {
+		if f != nil && !seen[f.URL] {
+			seen[f.URL] = true
+			feeds = append(feeds, *f)
+		}
+	}
+
+	return feeds, nil
+}
+
+// feedCandidates lists feed URLs to try for a page, linked feeds first.
+func feedCandidates(doc *goquery.Document, base *url.URL) []string {
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(ref string) {
+		u, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			candidates = append(candidates, u.String())
+		}
+	}
+
+	doc.Find("link[href]").Each(func(i int, link *goquery.Selection) {
+		rel := strings.Fields(strings.ToLower(link.AttrOr("rel", "")))
+		typ := strings.ToLower(strings.TrimSpace(link.AttrOr("type", "")))
+		if containsField(rel, "alternate") && feedLinkTypes[typ] {
+			add(link.AttrOr("href", ""))
+		}
+	})
+
+	// Relative paths resolve next to the page; absolute ones at the root
+	for _, path := range commonFeedPaths {
+		add(path)
+	}
+	for _, path := range commonFeedPaths {
+		add("/" + path)
+	}
+
+	return candidates
+}
+
+// validateFeed returns the feed at feedURL, or nil if it isn't one.
+func (s *Scraper) validateFeed(ctx context.Context, feedURL string) *DiscoveredFeed {
+	body, final, err := s.fetch(ctx, feedURL)
+	if err != nil {
+		return nil
+	}
+
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	f := newDiscoveredFeed(final.String(), feed)
+	return &f
+}
+
+func newDiscoveredFeed(feedURL string, feed *gofeed.Feed) DiscoveredFeed {
+	return DiscoveredFeed{
+		URL:   feedURL,
+		Title: strings.TrimSpace(feed.Title),
+		Type:  feed.FeedType,
+		Items: len(feed.Items),
+	}
+}
+
+func containsField(fields []string, want string) bool {
+	for _, f := range fields {
+		if f == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/workpool"
+)
+
+// EnrichOptions tunes an Enricher. Zero values pick the defaults.
+type EnrichOptions struct {
+	// Concurrency is how many pages are fetched at once
+	Concurrency int
+	// PerDomain is how many of those may be on the same host
+	PerDomain int
+	// Timeout bounds each page, including the wait for its host
+	Timeout time.Duration
+	// MaxContent caps the article text kept per result, in bytes
+	MaxContent int
+}
+
+const (
+	defaultEnrichConcurrency = 4
+	defaultEnrichPerDomain   = 2
+	defaultEnrichTimeout     = 10 * time.Second
+	defaultEnrichMaxContent  = 4000
+)
+
+// Enricher fills in the article text of search results, which often come
+// with a snippet only, by scraping their pages.
+type Enricher struct {
+	scraper *Scraper
+	opts    EnrichOptions
+
+	mu      sync.Mutex
+	domains map[string]chan struct{}
+}
+
+func NewEnricher(scraper *Scraper, opts EnrichOptions) *Enricher {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultEnrichConcurrency
+	}
+	if opts.PerDomain <= 0 {
+		opts.PerDomain = defaultEnrichPerDomain
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultEnrichTimeout
+	}
+	if opts.MaxContent <= 0 {
+		opts.MaxContent = defaultEnrichMaxContent
+	}
+
+	return &Enricher{
+		scraper: scraper,
+		opts:    opts,
+		domains: make(map[string]chan struct{}),
+	}
+}
+
+// Enrich fetches the page of every result without content and fills in its
+// article text, and its snippet and publish date when those are missing.
+// Pages that can't be fetched in time keep what they had. It returns how many
+// results were enriched.
+func (e *Enricher) Enrich(ctx context.Context, results []SearchResult) int {
+	enriched := make([]bool, len(results))
+
+	workpool.Run(ctx, e.opts.Concurrency, len(results), func(i int) {
+		if results[i].Content != "" || results[i].URL == "" {
+			return
+		}
+
+		article, err := e.extract(ctx, results[i].URL)
+		if err != nil || article.Content == "" {
+			return
+		}
+
+		results[i].Content = truncateText(article.Content, e.opts.MaxContent)
+		if results[i].Snippet == "" {
+			results[i].Snippet = article.Description
+		}
+		if results[i].PublishedAt.IsZero() {
+			results[i].PublishedAt = article.PublishedAt
+		}
+		enriched[i] = true
+	})
+
+	n := 0
+	for _, ok := range enriched {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (e *Enricher) extract(ctx context.Context, pageURL string) (*Article, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	defer cancel()
+
+	slot := e.domainSlot(pageURL)
+	select {
+	case slot <- struct{}{}:
+		defer func() { <-slot }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return e.scraper.Extract(ctx, pageURL)
+}
+
+// domainSlot returns the semaphore limiting requests to pageURL's host.
+func (e *Enricher) domainSlot(pageURL string) chan struct{} {
+	host := pageURL
+	if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+		host = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	slot, ok := e.domains[host]
+	if !ok {
+		slot = make(chan struct{}, e.opts.PerDomain)
+		e.domains[host] = slot
+	}
+	return slot
+}
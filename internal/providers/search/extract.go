@@ -0,0 +1,594 @@
+package search
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Article is the readable part of a web page and what the page says about
+// itself.
+type Article struct {
+	Title        string
+	Author       string
+	PublishedAt  time.Time
+	CanonicalURL string
+	Description  string
+	SiteName     string
+	// Content is the article text with paragraphs separated by blank lines
+	Content string
+}
+
+// Elements that never hold article text.
+const junkSelector = "script, style, noscript, template, iframe, svg, canvas, object, embed, " +
+	"form, button, input, select, textarea, nav, aside, footer, dialog, " +
+	"[hidden], [aria-hidden='true'], [role='navigation'], [role='complementary'], [role='contentinfo'], [role='dialog']"
+
+var (
+	// unlikelyPattern marks class and id names of page furniture
+	unlikelyPattern = regexp.MustCompile(`(?i)-ad-|banner|breadcrumb|byline|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental`)
+	// maybePattern rescues elements unlikelyPattern would drop
+	maybePattern = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+
+	positivePattern = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativePattern = regexp.MustCompile(`(?i)-ad-|hidden|banner|combx|comment|com-|contact|foot|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// articleTypes are the schema.org types whose JSON-LD describes an article.
+var articleTypes = map[string]bool{
+	"Article":              true,
+	"NewsArticle":          true,
+	"BlogPosting":          true,
+	"TechArticle":          true,
+	"ScholarlyArticle":     true,
+	"Report":               true,
+	"AnalysisNewsArticle":  true,
+	"OpinionNewsArticle":   true,
+	"ReportageNewsArticle": true,
+}
+
+// ExtractArticle finds the article in an HTML page. Metadata comes from
+// OpenGraph and other meta tags, JSON-LD and the page itself, in that order
+// of preference, except that JSON-LD wins for the author and published date.
+// The text is taken from the element whose paragraphs score highest for
+// length and punctuation, less the share of its text that is links, together
+// with sibling elements that score well too.
+func ExtractArticle(r io.Reader, pageURL string) (*Article, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	base, _ := url.Parse(pageURL)
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok && base != nil {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	article := extractMetadata(doc, base, pageURL)
+	article.Content = extractContent(doc, article.Title)
+
+	return article, nil
+}
+
+func extractMetadata(doc *goquery.Document, base *url.URL, pageURL string) *Article {
+	meta := make(map[string]string)
+	doc.Find("meta[content]").Each(func(i int, s *goquery.Selection) {
+		key := s.AttrOr("property", s.AttrOr("name", s.AttrOr("itemprop", "")))
+		key = strings.ToLower(strings.TrimSpace(key))
+		content := normalizeSpace(s.AttrOr("content", ""))
+		if key != "" && content != "" && meta[key] == "" {
+			meta[key] = content
+		}
+	})
+	ld := readLinkedData(doc)
+
+	a := &Article{
+		Title:       firstNonEmpty(meta["og:title"], ld.headline, meta["twitter:title"], normalizeSpace(doc.Find("title").First().Text()), normalizeSpace(doc.Find("h1").First().Text())),
+		Author:      firstNonEmpty(ld.author, meta["author"], meta["article:author"], meta["parsely-author"], normalizeSpace(doc.Find("[rel='author']").First().Text())),
+		Description: firstNonEmpty(meta["og:description"], meta["description"], ld.description, meta["twitter:description"]),
+		SiteName:    firstNonEmpty(meta["og:site_name"], ld.publisher, meta["application-name"]),
+	}
+
+	// Author meta tags sometimes hold a profile URL rather than a name
+	if strings.HasPrefix(a.Author, "http://") || strings.HasPrefix(a.Author, "https://") {
+		a.Author = ""
+	}
+
+	published := firstNonEmpty(ld.datePublished, meta["article:published_time"], meta["datepublished"], meta["date"], meta["pubdate"], doc.Find("time[datetime]").First().AttrOr("datetime", ""))
+	a.PublishedAt = parseDate(published)
+
+	canonical := firstNonEmpty(doc.Find("link[rel='canonical']").First().AttrOr("href", ""), meta["og:url"], ld.url)
+	a.CanonicalURL = resolveURL(base, canonical, pageURL)
+
+	a.Title = trimSiteName(a.Title, a.SiteName)
+
+	return a
+}
+
+// linkedData holds the fields read from a page's JSON-LD article.
+type linkedData struct {
+	headline      string
+	author        string
+	datePublished string
+	url           string
+	description   string
+	publisher     string
+}
+
+func readLinkedData(doc *goquery.Document) linkedData {
+	var ld linkedData
+	doc.Find("script[type='application/ld+json']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+
+		obj := findArticleObject(data)
+		if obj == nil {
+			return true
+		}
+
+		ld = linkedData{
+			headline:      firstNonEmpty(jsonString(obj["headline"]), jsonString(obj["name"])),
+			author:        jsonName(obj["author"]),
+			datePublished: jsonString(obj["datePublished"]),
+			url:           firstNonEmpty(jsonString(obj["url"]), jsonName(obj["mainEntityOfPage"])),
+			description:   jsonString(obj["description"]),
+			publisher:     jsonName(obj["publisher"]),
+		}
+		return false
+	})
+	return ld
+}
+
+// findArticleObject searches JSON-LD, including @graph lists, for the first
+// object with an article type.
+func findArticleObject(data any) map[string]any {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if obj := findArticleObject(item); obj != nil {
+				return obj
+			}
+		}
+	case map[string]any:
+		if isArticleType(v["@type"]) {
+			return v
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findArticleObject(graph)
+		}
+	}
+	return nil
+}
+
+func isArticleType(t any) bool {
+	switch v := t.(type) {
+	case string:
+		return articleTypes[v]
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && articleTypes[s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonString(v any) string {
+	s, _ := v.(string)
+	return normalizeSpace(s)
+}
+
+// jsonName reads a person or organization, given as a name, an object with a
+// name or @id, or a list of those.
+func jsonName(v any) string {
+	switch v := v.(type) {
+	case string:
+		return normalizeSpace(v)
+	case map[string]any:
+		return firstNonEmpty(jsonString(v["name"]), jsonString(v["@id"]))
+	case []any:
+		var names []string
+		for _, item := range v {
+			if name := jsonName(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+}
+
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func resolveURL(base *url.URL, ref, fallback string) string {
+	if ref == "" || base == nil {
+		return fallback
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fallback
+	}
+	return u.String()
+}
+
+// trimSiteName removes a " | Site" or " - Site" suffix from a title.
+func trimSiteName(title, site string) string {
+	if site == "" {
+		return title
+	}
+	for _, sep := range []string{" | ", " - ", " – ", " — ", " · "} {
+		if trimmed, ok := strings.CutSuffix(title, sep+site); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return title
+}
+
+func extractContent(doc *goquery.Document, title string) string {
+	doc.Find(junkSelector).Remove()
+	removeUnlikely(doc)
+
+	nodes := articleNodes(doc)
+
+	var blocks []string
+	for _, n := range nodes {
+		blocks = appendBlocks(blocks, n)
+	}
+
+	// The title usually opens the article as well
+	if len(blocks) > 0 && strings.EqualFold(blocks[0], title) {
+		blocks = blocks[1:]
+	}
+
+	// Items of a list stay together in one paragraph
+	var b strings.Builder
+	for i, block := range blocks {
+		if i > 0 {
+			if strings.HasPrefix(block, "- ") && strings.HasPrefix(blocks[i-1], "- ") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(block)
+	}
+
+	return b.String()
+}
+
+func removeUnlikely(doc *goquery.Document) {
+	doc.Find("body *").Each(func(i int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "article", "main", "body", "a":
+			return
+		}
+		names := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if unlikelyPattern.MatchString(names) && !maybePattern.MatchString(names) {
+			s.Remove()
+		}
+	})
+
+	// Page headers carry navigation; article headers carry the byline
+	doc.Find("header").Each(func(i int, s *goquery.Selection) {
+		if s.Closest("article, main").Length() == 0 {
+			s.Remove()
+		}
+	})
+}
+
+// candidate is an element scored for how likely it is to hold the article.
+type candidate struct {
+	node  *html.Node
+	score float64
+}
+
+// articleNodes returns the element most likely to hold the article, preceded
+// and followed by any of its siblings that look like part of it. Pages with
+// no paragraphs fall back to <body>.
+func articleNodes(doc *goquery.Document) []*html.Node {
+	scores := scoreCandidates(doc)
+
+	var top *candidate
+	for _, c := range scores {
+		if top == nil || c.score > top.score {
+			top = c
+		}
+	}
+
+	if top == nil {
+		body := doc.Find("body")
+		if body.Length() == 0 {
+			return nil
+		}
+		return body.Nodes
+	}
+
+	if top.node.Parent == nil {
+		return []*html.Node{top.node}
+	}
+
+	// Articles split across sibling elements are gathered back together
+	threshold := math.Max(10, top.score*0.2)
+	topClass := attr(top.node, "class")
+
+	var nodes []*html.Node
+	for n := top.node.Parent.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if n == top.node {
+			nodes = append(nodes, n)
+			continue
+		}
+
+		bonus := 0.0
+		if topClass != "" && attr(n, "class") == topClass {
+			bonus = top.score * 0.2
+		}
+		if c := findCandidate(scores, n); c != nil && c.score+bonus >= threshold {
+			nodes = append(nodes, n)
+			continue
+		}
+
+		if n.Data == "p" {
+			s := goquery.NewDocumentFromNode(n).Selection
+			text := normalizeSpace(s.Text())
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(s)
+			if (length > 80 && density < 0.25) || (length > 0 && density == 0 && strings.Contains(text, ". ")) {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	return nodes
+}
+
+// scoreCandidates credits the parent of every paragraph with the paragraph's
+// score, by length and punctuation, and its grandparent with half, then
+// discounts each candidate by its link density. Candidates are in document
+// order so ties go to the first.
+func scoreCandidates(doc *goquery.Document) []*candidate {
+	byNode := make(map[*html.Node]*candidate)
+	var scores []*candidate
+
+	add := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 || s.Get(0).Type != html.ElementNode {
+			return
+		}
+		n := s.Get(0)
+		c, ok := byNode[n]
+		if !ok {
+			c = &candidate{node: n, score: initialScore(s)}
+			byNode[n] = c
+			scores = append(scores, c)
+		}
+		c.score += score
+	}
+
+	doc.Find("p, pre, td, blockquote").Each(func(i int, s *goquery.Selection) {
+		text := normalizeSpace(s.Text())
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(length/100), 3)
+		add(s.Parent(), score)
+		add(s.Parent().Parent(), score/2)
+	})
+
+	for _, c := range scores {
+		c.score *= 1 - linkDensity(goquery.NewDocumentFromNode(c.node).Selection)
+	}
+
+	return scores
+}
+
+func findCandidate(scores []*candidate, n *html.Node) *candidate {
+	for _, c := range scores {
+		if c.node == n {
+			return c
+		}
+	}
+	return nil
+}
+
+func initialScore(s *goquery.Selection) float64 {
+	score := classWeight(s)
+	switch goquery.NodeName(s) {
+	case "div", "article", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+func classWeight(s *goquery.Selection) float64 {
+	weight := 0.0
+	for _, name := range []string{"class", "id"} {
+		value := s.AttrOr(name, "")
+		if value == "" {
+			continue
+		}
+		if negativePattern.MatchString(value) {
+			weight -= 25
+		}
+		if positivePattern.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is the share of an element's text that is inside links.
+func linkDensity(s *goquery.Selection) float64 {
+	length := utf8.RuneCountInString(normalizeSpace(s.Text()))
+	if length == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	s.Find("a").Each(func(i int, a *goquery.Selection) {
+		linkLength += utf8.RuneCountInString(normalizeSpace(a.Text()))
+	})
+	return float64(linkLength) / float64(length)
+}
+
+// blockElements start a new paragraph of text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "figcaption": true, "figure": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "tr": true, "ul": true,
+}
+
+// appendBlocks renders n as paragraphs of plain text. List items are marked
+// with dashes and preformatted text keeps its lines. Lists, tables and
+// sections that are mostly links, or are named like page furniture, are left
+// out.
+func appendBlocks(blocks []string, n *html.Node) []string {
+	var current strings.Builder
+
+	flush := func() {
+		if text := normalizeSpace(current.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		current.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			current.WriteString(n.Data)
+			return
+		case html.ElementNode:
+		default:
+			return
+		}
+
+		switch n.Data {
+		case "br":
+			current.WriteString(" ")
+			return
+		case "img":
+			return
+		case "pre":
+			flush()
+			if text := strings.Trim(goquery.NewDocumentFromNode(n).Text(), "\n"); strings.TrimSpace(text) != "" {
+				blocks = append(blocks, text)
+			}
+			return
+		case "ul", "ol", "table", "div", "section":
+			s := goquery.NewDocumentFromNode(n).Selection
+			if linkDensity(s) > 0.5 || classWeight(s) < 0 {
+				return
+			}
+		}
+
+		block := blockElements[n.Data]
+		if block {
+			flush()
+		}
+		if n.Data == "li" {
+			current.WriteString("- ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			flush()
+		}
+	}
+
+	walk(n)
+	flush()
+
+	return blocks
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// normalizeSpace collapses runs of whitespace into single spaces.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// truncateText shortens s to at most max bytes without splitting a
+// character, cutting at the last space when there is one and marking the cut
+// with an ellipsis.
+func truncateText(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	const ellipsis = "…"
+	cut := max - len(ellipsis)
+	if cut <= 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	if space := strings.LastIndexAny(s[:cut], " \n"); space > cut/2 {
+		cut = space
+	}
+
+	return strings.TrimRight(s[:cut], " \n") + ellipsis
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/articles")
+
+// TestExtractArticle_Golden extracts every page in testdata/articles and
+// compares the result with the .golden file next to it. Run with -update to
+// rewrite the golden files after a deliberate change.
+func TestExtractArticle_Golden(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "articles", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("no pages in testdata/articles")
+	}
+
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			article, err := ExtractArticle(f, "https://example.com/articles/"+name)
+			if err != nil {
+				t.Fatalf("ExtractArticle() error = %v", err)
+			}
+			got := formatArticle(article)
+
+			golden := strings.TrimSuffix(page, ".html") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("ExtractArticle(%s) =\n%s\nwant\n%s", page, got, want)
+			}
+		})
+	}
+}
+
+func formatArticle(a *Article) string {
+	published := ""
+	if !a.PublishedAt.IsZero() {
+		published = a.PublishedAt.UTC().Format(time.RFC3339)
+	}
+
+	var b strings.Builder
+	for _, field := range [][2]string{
+		{"title", a.Title},
+		{"author", a.Author},
+		{"published", published},
+		{"canonical", a.CanonicalURL},
+		{"description", a.Description},
+		{"site", a.SiteName},
+	} {
+		fmt.Fprintf(&b, "%s: %s\n", field[0], field[1])
+	}
+	fmt.Fprintf(&b, "\n%s\n", a.Content)
+
+	// Golden files shouldn't depend on editors keeping trailing spaces
+	return strings.ReplaceAll(b.String(), ": \n", ":\n")
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"short", "hello world", 20, "hello world"},
+		{"exact", "hello", 5, "hello"},
+		{"word boundary", "the quick brown fox", 14, "the quick…"},
+		{"multibyte", "日本語のテキスト", 12, "日本語…"},
+		{"no room", "hello", 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateText(tt.in, tt.max)
+			if got != tt.want {
+				t.Errorf("truncateText(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+			}
+			if len(got) > tt.max || !utf8.ValidString(got) {
+				t.Errorf("truncateText(%q, %d) = %q, want valid UTF-8 of at most %d bytes", tt.in, tt.max, got, tt.max)
+			}
+		})
+	}
+}
+
+func TestScraper_Scrape(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "articles", "blog.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pinning" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	s := NewScraper("termiflow-test", 5, nil)
+
+	result, err := s.Scrape(context.Background(), server.URL+"/pinning")
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	if result.Title != "Pinning in async Rust, explained" {
+		t.Errorf("Scrape() Title = %q", result.Title)
+	}
+	if result.URL != "https://notes.example.com/posts/pinning/" {
+		t.Errorf("Scrape() URL = %q, want the canonical URL", result.URL)
+	}
+	if result.PublishedAt.IsZero() {
+		t.Error("Scrape() PublishedAt is zero")
+	}
+	if strings.Contains(result.Content, "Popular posts") || strings.Contains(result.Content, "cookies") {
+		t.Errorf("Scrape() Content includes page furniture: %q", result.Content)
+	}
+
+	if _, err := s.Scrape(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("Scrape() of a missing page should return an error")
+	}
+}
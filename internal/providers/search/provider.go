@@ -12,6 +12,7 @@ type SearchResult struct {
 	Content     string
 	PublishedAt time.Time
 	Source      string
+	ItemID      int64 // set when the result comes from a stored feed item
 }
 
 type SearchRequest struct {
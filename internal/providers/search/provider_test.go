@@ -3,10 +3,16 @@ package search
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
 func TestSearchResult(t *testing.T) {
@@ -273,3 +279,455 @@ func TestContextCancellation(t *testing.T) {
 		t.Log("Context cancellation test passed - context was canceled")
 	}
 }
+
+type memFeedStates struct {
+	states map[string]*models.FeedState
+}
+
+func (m *memFeedStates) LoadFeedState(url string) (*models.FeedState, error) {
+	return m.states[url], nil
+}
+
+func (m *memFeedStates) SaveFeedState(state *models.FeedState) error {
+	m.states[state.URL] = state
+	return nil
+}
+
+func rssDocument(guids ...string) string {
+	var items strings.Builder
+	for _, g := range guids {
+		fmt.Fprintf(&items, "<item><title>Post %s</title><link>https://example.com/%s</link><guid>%s</guid></item>", g, g, g)
+	}
+	return `<?xml version="1.0"?><rss version="2.0"><channel><title>Example</title>` + items.String() + `</channel></rss>`
+}
+
+func TestRSSProvider_ConditionalFetch(t *testing.T) {
+	body := rssDocument("a", "b")
+	etag := `"v1"`
+	var requests, notModified int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("User-Agent") != "termiflow-test" {
+			t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), "termiflow-test")
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Wed, 12 Jun 2024 09:00:00 GMT")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	p := NewRSSProvider("termiflow-test", 5, nil)
+	states := &memFeedStates{states: make(map[string]*models.FeedState)}
+	ctx := WithFeedStates(context.Background(), states)
+
+	// First fetch returns everything and records the validators
+	results, err := p.FetchFeed(ctx, server.URL, nil)
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("first FetchFeed() returned %d items, want 2", len(results))
+	}
+	state := states.states[server.URL]
+	if state == nil || state.ETag != etag || state.LastModified == "" || len(state.GUIDs) != 2 {
+		t.Fatalf("saved state = %+v, want ETag, Last-Modified and 2 GUIDs", state)
+	}
+
+	// Unchanged feed answers 304 and yields nothing
+	results, err = p.FetchFeed(ctx, server.URL, nil)
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+	if len(results) != 0 || notModified != 1 {
+		t.Errorf("FetchFeed() of unchanged feed = %d items, %d 304s, want 0 items, 1 304", len(results), notModified)
+	}
+
+	// A new item is detected by GUID even though no item has a date
+	body = rssDocument("c", "a", "b")
+	etag = `"v2"`
+	results, err = p.FetchFeed(ctx, server.URL, nil)
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+	if len(results) != 1 || results[0].URL != "https://example.com/c" {
+		t.Errorf("FetchFeed() after update = %+v, want only item c", results)
+	}
+	if got := states.states[server.URL].GUIDs; strings.Join(got, ",") != "c,a,b" {
+		t.Errorf("GUIDs = %v, want [c a b]", got)
+	}
+
+	// Without feed state every fetch is a full download
+	results, _ = p.FetchFeed(context.Background(), server.URL, nil)
+	if len(results) != 3 || requests != 4 {
+		t.Errorf("stateless FetchFeed() = %d items after %d requests, want 3 after 4", len(results), requests)
+	}
+}
+
+func TestRSSProvider_FetchFeedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewRSSProvider("", 0, nil).FetchFeed(context.Background(), server.URL, nil); err == nil {
+		t.Error("FetchFeed() should fail on 404")
+	}
+}
+
+func TestMergeGUIDs(t *testing.T) {
+	previous := &models.FeedState{GUIDs: []string{"b", "old"}}
+	if got := mergeGUIDs([]string{"a", "b"}, previous); strings.Join(got, ",") != "a,b,old" {
+		t.Errorf("mergeGUIDs() = %v, want [a b old]", got)
+	}
+
+	many := make([]string, maxFeedGUIDs+10)
+	for i := range many {
+		many[i] = fmt.Sprint(i)
+	}
+	if got := mergeGUIDs(many, previous); len(got) != maxFeedGUIDs {
+		t.Errorf("mergeGUIDs() kept %d GUIDs, want %d", len(got), maxFeedGUIDs)
+	}
+}
+
+func TestScraper_DiscoverFeeds(t *testing.T) {
+	atom := `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Example Atom</title>` +
+		`<entry><title>Post</title><id>urn:1</id><link href="https://example.com/1"/></entry></feed>`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blog/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+			<link rel="alternate" type="application/atom+xml" href="atom.xml">
+			<link rel="alternate" type="application/rss+xml" href="/missing.xml">
+			<link rel="stylesheet" href="/style.css">
+		</head><body>Blog</body></html>`))
+	})
+	mux.HandleFunc("/blog/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(atom))
+	})
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(rssDocument("a", "b")))
+	})
+	mux.HandleFunc("/rss", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>not a feed</html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	s := NewScraper("termiflow-test", 5, nil)
+
+	feeds, err := s.DiscoverFeeds(context.Background(), server.URL+"/blog/")
+	if err != nil {
+		t.Fatalf("DiscoverFeeds() error = %v", err)
+	}
+
+	want := []DiscoveredFeed{
+		{URL: server.URL + "/blog/atom.xml", Title: "Example Atom", Type: "atom", Items: 1},
+		{URL: server.URL + "/feed", Title: "Example", Type: "rss", Items: 2},
+	}
+	if len(feeds) != len(want) {
+		t.Fatalf("DiscoverFeeds() = %+v, want %+v", feeds, want)
+	}
+	for i := range want {
+		if feeds[i] != want[i] {
+			t.Errorf("DiscoverFeeds()[%d] = %+v, want %+v", i, feeds[i], want[i])
+		}
+	}
+
+	// A feed URL is returned as the only result
+	feeds, err = s.DiscoverFeeds(context.Background(), server.URL+"/feed")
+	if err != nil {
+		t.Fatalf("DiscoverFeeds() error = %v", err)
+	}
+	if len(feeds) != 1 || feeds[0].URL != server.URL+"/feed" {
+		t.Errorf("DiscoverFeeds() of a feed = %+v, want just the feed", feeds)
+	}
+
+	if _, err := s.DiscoverFeeds(context.Background(), server.URL+"/nothing-here"); err == nil {
+		t.Error("DiscoverFeeds() of a missing page should return an error")
+	}
+}
+
+func TestEnricher_Enrich(t *testing.T) {
+	page := `<html><head><meta property="og:description" content="About the post">` +
+		`<meta property="article:published_time" content="2024-06-01T10:00:00Z"></head>` +
+		`<body><article><p>The article has a first paragraph that is long enough, with commas, to be scored.</p>` +
+		`<p>And a second paragraph that adds more detail, so the extractor keeps both of them.</p></article></body></html>`
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		switch r.URL.Path {
+		case "/slow":
+			// Hold the request until the enricher gives up on it
+			<-r.Context().Done()
+			return
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		default:
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	results := []SearchResult{
+		{URL: server.URL + "/a", Snippet: "Tavily snippet"},
+		{URL: server.URL + "/b"},
+		{URL: server.URL + "/c", Content: "Already have it"},
+		{URL: server.URL + "/d"},
+		{URL: server.URL + "/missing", Snippet: "Kept"},
+		{URL: server.URL + "/slow", Snippet: "Too slow"},
+	}
+
+	e := NewEnricher(NewScraper("termiflow-test", 5, nil), EnrichOptions{
+		Concurrency: 6,
+		PerDomain:   2,
+		Timeout:     300 * time.Millisecond,
+	})
+
+	if n := e.Enrich(context.Background(), results); n != 3 {
+		t.Errorf("Enrich() = %d, want 3", n)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Enrich() made %d requests to one host at once, want at most 2", maxInFlight)
+	}
+
+	if !strings.Contains(results[0].Content, "first paragraph") || results[0].Snippet != "Tavily snippet" {
+		t.Errorf("results[0] = %+v, want content and the original snippet", results[0])
+	}
+	if results[1].Snippet != "About the post" || results[1].PublishedAt.IsZero() {
+		t.Errorf("results[1] = %+v, want the page's description and date filled in", results[1])
+	}
+	if results[2].Content != "Already have it" {
+		t.Errorf("results[2].Content = %q, want it untouched", results[2].Content)
+	}
+	if results[4].Content != "" || results[5].Content != "" {
+		t.Errorf("failed pages should keep their content empty, got %q and %q", results[4].Content, results[5].Content)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/open
+Crawl-delay: 1
+
+User-agent: BadBot
+User-agent: OtherBot
+Disallow: /
+
+User-agent: termiflow
+Disallow: /drafts/
+Disallow: /*.pdf$
+Allow: /drafts/public
+Crawl-delay: 2.5
+`
+
+	tests := []struct {
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{"termiflow/1.0", "/posts/1", true},
+		{"termiflow/1.0", "/drafts/2", false},
+		{"termiflow/1.0", "/drafts/public/3", true},
+		{"termiflow/1.0", "/papers/a.pdf", false},
+		{"termiflow/1.0", "/papers/a.pdf?download=1", true},
+		// The termiflow group replaces the * group entirely
+		{"termiflow/1.0", "/private/x", true},
+		{"Termiflow", "/drafts/2", false},
+		{"somebot/2.0", "/private/x", false},
+		{"somebot/2.0", "/private/open/y", true},
+		{"somebot/2.0", "/drafts/2", true},
+		{"OtherBot", "/anything", false},
+		{"OtherBot", "/robots.txt", true},
+	}
+
+	for _, tt := range tests {
+		rules := parseRobots(strings.NewReader(robots), tt.userAgent)
+		if got := rules.allowed(tt.path); got != tt.want {
+			t.Errorf("parseRobots(%q).allowed(%q) = %v, want %v", tt.userAgent, tt.path, got, tt.want)
+		}
+	}
+
+	if d := parseRobots(strings.NewReader(robots), "termiflow/1.0").delay; d != 2500*time.Millisecond {
+		t.Errorf("termiflow crawl delay = %v, want 2.5s", d)
+	}
+	if d := parseRobots(strings.NewReader(robots), "somebot").delay; d != time.Second {
+		t.Errorf("* crawl delay = %v, want 1s", d)
+	}
+	if rules := parseRobots(strings.NewReader(""), "termiflow"); !rules.allowed("/anything") {
+		t.Error("an empty robots.txt should allow everything")
+	}
+}
+
+func TestPoliteness(t *testing.T) {
+	var mu sync.Mutex
+	robotsFetches := 0
+	var pageTimes []time.Time
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		robotsFetches++
+		mu.Unlock()
+		if r.Header.Get("User-Agent") != "termiflow-test/1.0" {
+			t.Errorf("robots.txt User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		w.Write([]byte("User-agent: termiflow-test\nDisallow: /private\nCrawl-delay: 0.1\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pageTimes = append(pageTimes, time.Now())
+		mu.Unlock()
+		if r.URL.Path == "/feed" {
+			w.Write([]byte(rssDocument("a")))
+			return
+		}
+		w.Write([]byte("<html><body><article><p>A page that is allowed to be fetched, with enough text.</p></article></body></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	polite := NewPoliteness("termiflow-test/1.0", 5, true, 0)
+	s := NewScraper("termiflow-test/1.0", 5, polite)
+	rss := NewRSSProvider("termiflow-test/1.0", 5, polite)
+
+	if _, err := s.Scrape(context.Background(), server.URL+"/private/page"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("Scrape() of a disallowed page error = %v, want %v", err, ErrDisallowed)
+	}
+
+	for _, path := range []string{"/a", "/b"} {
+		if _, err := s.Scrape(context.Background(), server.URL+path); err != nil {
+			t.Fatalf("Scrape(%s) error = %v", path, err)
+		}
+	}
+	if _, err := rss.FetchFeed(context.Background(), server.URL+"/feed", nil); err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+
+	if robotsFetches != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", robotsFetches)
+	}
+	if len(pageTimes) != 3 {
+		t.Fatalf("server saw %d page requests, want 3", len(pageTimes))
+	}
+	// The Crawl-delay spaces out the scraper and the feed reader alike
+	for i := 1; i < len(pageTimes); i++ {
+		if gap := pageTimes[i].Sub(pageTimes[i-1]); gap < 90*time.Millisecond {
+			t.Errorf("request %d came %v after the previous one, want at least the 100ms crawl delay", i+1, gap)
+		}
+	}
+}
+
+func TestPoliteness_CancelledRobotsFetch(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			return
+		}
+		// The first fetch hangs until its request is cancelled
+		first := false
+		once.Do(func() { first = true })
+		if first {
+			<-release
+			return
+		}
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	polite := NewPoliteness("termiflow-test", 5, true, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() { firstErr <- polite.WaitPage(ctx, server.URL+"/a") }()
+
+	// Wait for the first request to start fetching robots.txt
+	for {
+		polite.robots.mu.Lock()
+		fetching := len(polite.robots.hosts) > 0
+		polite.robots.mu.Unlock()
+		if fetching {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	waiterErr := make(chan error)
+	go func() { waiterErr <- polite.WaitPage(context.Background(), server.URL+"/b") }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled WaitPage() error = %v, want %v", err, context.Canceled)
+	}
+	if err := <-waiterErr; err != nil {
+		t.Errorf("waiting WaitPage() error = %v, want robots.txt fetched again", err)
+	}
+	if err := polite.WaitPage(context.Background(), server.URL+"/private/c"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("WaitPage() of a disallowed page error = %v, want %v", err, ErrDisallowed)
+	}
+}
+
+func TestPoliteness_RobotsStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, nil},
+		{http.StatusForbidden, nil},
+		{http.StatusServiceUnavailable, ErrDisallowed},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				w.WriteHeader(tt.status)
+			}
+		}))
+
+		polite := NewPoliteness("termiflow-test", 5, true, 0)
+		if err := polite.WaitPage(context.Background(), server.URL+"/page"); err != tt.want {
+			t.Errorf("WaitPage() with robots.txt status %d error = %v, want %v", tt.status, err, tt.want)
+		}
+		server.Close()
+	}
+
+	// Robots checks can be turned off, and a nil Politeness allows everything
+	if err := NewPoliteness("termiflow-test", 5, false, 0).WaitPage(context.Background(), "http://127.0.0.1:1/page"); err != nil {
+		t.Errorf("WaitPage() without robots checks error = %v", err)
+	}
+	var nilPolite *Politeness
+	if err := nilPolite.WaitFeed(context.Background(), "http://127.0.0.1:1/feed"); err != nil {
+		t.Errorf("nil Politeness WaitFeed() error = %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package search
+
+import (
+	"context"
+
+	"github.com/oluoyefeso/termiflow/internal/ratelimit"
+)
+
+type rateLimitedProvider struct {
+	Provider
+	limiter *ratelimit.Limiter
+}
+
+// WithRateLimit wraps p so it makes at most perMinute searches per minute.
+// A perMinute of zero or less returns p unchanged.
+func WithRateLimit(p Provider, perMinute int) Provider {
+	limiter := ratelimit.New(perMinute)
+	if limiter == nil {
+		return p
+	}
+	return &rateLimitedProvider{Provider: p, limiter: limiter}
+}
+
+func (p *rateLimitedProvider) Search(ctx context.Context, req SearchRequest) ([]SearchResult, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.Search(ctx, req)
+}
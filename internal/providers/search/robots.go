@@ -0,0 +1,397 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/ratelimit"
+)
+
+// ErrDisallowed is returned for pages a site's robots.txt asks crawlers not
+// to fetch.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+const (
+	// maxRobotsSize is how much of a robots.txt file is read, as RFC 9309 allows
+	maxRobotsSize = 500 << 10
+	// robotsTTL is how long a host's robots.txt is trusted
+	robotsTTL = 24 * time.Hour
+	// robotsRetry is how long an unreachable robots.txt blocks the host before
+	// it's tried again
+	robotsRetry = 10 * time.Minute
+	// maxCrawlDelay caps the Crawl-delay a site can ask for
+	maxCrawlDelay = time.Minute
+)
+
+// Politeness keeps termiflow a good citizen of the sites it reads: pages are
+// checked against robots.txt, and requests to the same host are spaced out,
+// by at least the site's Crawl-delay. One Politeness should be shared by
+// everything that fetches from the web so the spacing holds across them. A
+// nil *Politeness allows everything at once.
+type Politeness struct {
+	robots *robotsCache
+	hosts  *ratelimit.HostLimiter
+}
+
+// NewPoliteness returns a Politeness that leaves delay between requests to a
+// host. With respectRobots, pages are checked against the robots.txt rules
+// for userAgent, which is also used to fetch robots.txt.
+func NewPoliteness(userAgent string, timeout int, respectRobots bool, delay time.Duration) *Politeness {
+	p := &Politeness{hosts: ratelimit.NewHostLimiter(delay)}
+
+	if respectRobots {
+		if userAgent == "" {
+			userAgent = "termiflow/1.0"
+		}
+		if timeout == 0 {
+			timeout = 30
+		}
+		p.robots = &robotsCache{
+			client:    &http.Client{Timeout: time.Duration(timeout) * time.Second},
+			userAgent: userAgent,
+			hosts:     make(map[string]*robotsEntry),
+		}
+	}
+
+	return p
+}
+
+// WaitPage returns ErrDisallowed if robots.txt forbids fetching pageURL, and
+// otherwise blocks until it's the host's turn.
+func (p *Politeness) WaitPage(ctx context.Context, pageURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return err
+	}
+
+	if p.robots != nil {
+		rules, err := p.robots.get(ctx, u)
+		if err != nil {
+			return err
+		}
+		if !rules.allowed(robotsPath(u)) {
+			return ErrDisallowed
+		}
+		p.hosts.SetDelay(u.Hostname(), rules.delay)
+	}
+
+	return p.hosts.Wait(ctx, u.Hostname())
+}
+
+// WaitFeed blocks until it's the turn of feedURL's host. Feeds are fetched
+// because someone subscribed to them, so robots.txt isn't consulted, but a
+// Crawl-delay already learned for the host still applies.
+func (p *Politeness) WaitFeed(ctx context.Context, feedURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return err
+	}
+	return p.hosts.Wait(ctx, u.Hostname())
+}
+
+// transport returns a RoundTripper that waits for p before every request it
+// sends to base, redirects included. With pages, requests are checked against
+// robots.txt as WaitPage does; otherwise they're treated as feeds. A nil
+// *Politeness returns base.
+func (p *Politeness) transport(base http.RoundTripper, pages bool) http.RoundTripper {
+	if p == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &politeTransport{polite: p, pages: pages, base: base}
+}
+
+type politeTransport struct {
+	polite *Politeness
+	pages  bool
+	base   http.RoundTripper
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := t.polite.WaitFeed
+	if t.pages {
+		wait = t.polite.WaitPage
+	}
+
+	if err := wait(req.Context(), req.URL.String()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// robotsCache fetches each host's robots.txt once and keeps its rules for
+// robotsTTL.
+type robotsCache struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	// ready is closed once rules and expires are set. rules stays nil if the
+	// fetch was cancelled.
+	ready   chan struct{}
+	rules   *robotsRules
+	expires time.Time
+}
+
+func (c *robotsCache) get(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	for {
+		c.mu.Lock()
+		e, ok := c.hosts[key]
+		if ok {
+			select {
+			case <-e.ready:
+				if time.Now().After(e.expires) {
+					ok = false
+				}
+			default:
+				// Another request is fetching it
+			}
+		}
+		if !ok {
+			e = &robotsEntry{ready: make(chan struct{})}
+			c.hosts[key] = e
+			c.mu.Unlock()
+
+			rules, expires := c.fetch(ctx, key)
+			if err := ctx.Err(); err != nil {
+				// A cancelled request says nothing about the host, so
+				// whoever is waiting fetches it again
+				c.mu.Lock()
+				if c.hosts[key] == e {
+					delete(c.hosts, key)
+				}
+				c.mu.Unlock()
+				close(e.ready)
+				return nil, err
+			}
+			e.rules, e.expires = rules, expires
+			close(e.ready)
+			return e.rules, nil
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-e.ready:
+			if e.rules != nil {
+				return e.rules, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fetch reads a host's robots.txt. A missing file allows everything; a host
+// that can't be reached or answers with a server error is off limits until
+// robotsRetry has passed.
+func (c *robotsCache) fetch(ctx context.Context, origin string) (*robotsRules, time.Time) {
+	unreachable := &robotsRules{rules: []robotsRule{{allow: false, pattern: regexp.MustCompile("^/")}}}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return unreachable, time.Now().Add(robotsRetry)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return unreachable, time.Now().Add(robotsRetry)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), c.userAgent), time.Now().Add(robotsTTL)
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		return &robotsRules{}, time.Now().Add(robotsTTL)
+	default:
+		return unreachable, time.Now().Add(robotsRetry)
+	}
+}
+
+// robotsRules are the robots.txt rules that apply to one user agent.
+type robotsRules struct {
+	rules []robotsRule
+	delay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsGroup is a run of user-agent lines and the rules that follow them.
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+	delay  time.Duration
+}
+
+// parseRobots reads a robots.txt file and keeps the rules of the groups
+// naming userAgent's product token, or of the * groups when none does.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if value == "" {
+				// An empty Disallow allows everything
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compileRobotsPattern(value),
+			})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.delay = min(time.Duration(seconds*float64(time.Second)), maxCrawlDelay)
+			}
+		}
+	}
+
+	token := productToken(userAgent)
+	rules := collectGroups(groups, func(agent string) bool { return agent == token })
+	if rules == nil {
+		rules = collectGroups(groups, func(agent string) bool { return agent == "*" })
+	}
+	if rules == nil {
+		rules = &robotsRules{}
+	}
+	return rules
+}
+
+func collectGroups(groups []*robotsGroup, match func(agent string) bool) *robotsRules {
+	var rules *robotsRules
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if !match(agent) {
+				continue
+			}
+			if rules == nil {
+				rules = &robotsRules{}
+			}
+			rules.rules = append(rules.rules, g.rules...)
+			rules.delay = max(rules.delay, g.delay)
+			break
+		}
+	}
+	return rules
+}
+
+// productToken is the name robots.txt files use for a user agent:
+// "termiflow/1.0 (+https://...)" is "termiflow".
+func productToken(userAgent string) string {
+	token := strings.ToLower(strings.TrimSpace(userAgent))
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	return token
+}
+
+// compileRobotsPattern turns a path pattern, where * matches anything and a
+// trailing $ anchors the end, into a regular expression.
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed applies the most specific matching rule to path, preferring Allow
+// when an Allow and a Disallow rule are equally specific.
+func (r *robotsRules) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allow, length := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > length || (rule.length == length && rule.allow) {
+			allow, length = rule.allow, rule.length
+		}
+	}
+	return allow
+}
+
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
@@ -2,21 +2,74 @@ package search
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/mmcdole/gofeed"
+
+	"github.com/oluoyefeso/termiflow/internal/httpcache"
+	"github.com/oluoyefeso/termiflow/internal/workpool"
+	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
+// feedConcurrency is how many feeds FetchMultipleFeeds downloads at once.
+const feedConcurrency = 4
+
+// maxFeedGUIDs caps how many item GUIDs are remembered per feed.
+const maxFeedGUIDs = 500
+
+// FeedStateStore remembers what earlier fetches of each feed returned.
+type FeedStateStore interface {
+	// LoadFeedState returns nil if the feed was never fetched
+	LoadFeedState(url string) (*models.FeedState, error)
+	SaveFeedState(state *models.FeedState) error
+}
+
+type feedStatesKey struct{}
+
+// WithFeedStates returns a context under which FetchFeed makes conditional
+// requests and returns only items it hasn't returned before, recording what
+// it saw in states.
+func WithFeedStates(ctx context.Context, states FeedStateStore) context.Context {
+	return context.WithValue(ctx, feedStatesKey{}, states)
+}
+
+func feedStatesFrom(ctx context.Context) FeedStateStore {
+	states, _ := ctx.Value(feedStatesKey{}).(FeedStateStore)
+	return states
+}
+
 type RSSProvider struct {
-	parser *gofeed.Parser
+	client    *http.Client
+	userAgent string
 }
 
-func NewRSSProvider() *RSSProvider {
+// NewRSSProvider returns a provider that fetches feeds as userAgent. Feeds
+// are fetched as polite allows; it may be nil.
+func NewRSSProvider(userAgent string, timeout int, polite *Politeness) *RSSProvider {
+	if userAgent == "" {
+		userAgent = "termiflow/1.0"
+	}
+	if timeout == 0 {
+		timeout = 30
+	}
+
 	return &RSSProvider{
-		parser: gofeed.NewParser(),
+		client: &http.Client{
+			Timeout:   time.Duration(timeout) * time.Second,
+			Transport: polite.transport(nil, false),
+		},
+		userAgent: userAgent,
 	}
 }
 
+// UseCache serves feeds from cache as policy says. A feed served from cache
+// returns only items that weren't seen before, like a fresh download would.
+func (p *RSSProvider) UseCache(cache *httpcache.Cache, policy httpcache.Policy) {
+	p.client.Transport = cache.Transport(p.client.Transport, policy)
+}
+
 func (p *RSSProvider) Name() string {
 	return "rss"
 }
@@ -30,16 +83,71 @@ func (p *RSSProvider) Search(ctx context.Context, req SearchRequest) ([]SearchRe
 	return nil, nil
 }
 
+// FetchFeed returns the feed's items. Under WithFeedStates it sends the
+// feed's ETag and Last-Modified so an unchanged feed isn't downloaded again,
+// and returns only items whose GUIDs weren't in the previous fetch. Otherwise,
+// or on the first fetch, items published before since are skipped.
 func (p *RSSProvider) FetchFeed(ctx context.Context, feedURL string, since *time.Time) ([]SearchResult, error) {
-	feed, err := p.parser.ParseURLWithContext(feedURL, ctx)
+	states := feedStatesFrom(ctx)
+
+	var state *models.FeedState
+	if states != nil {
+		var err error
+		if state, err = states.LoadFeedState(feedURL); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", p.userAgent)
+	if state != nil {
+		if state.ETag != "" {
+			req.Header.Set("If-None-Match", state.ETag)
+		}
+		if state.LastModified != "" {
+			req.Header.Set("If-Modified-Since", state.LastModified)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && state != nil {
+		checked := *state
+		checked.CheckedAt = time.Now()
+		return nil, states.SaveFeedState(&checked)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", feedURL, resp.Status)
+	}
+
+	// gofeed parsers keep per-document state, so each fetch gets its own
+	feed, err := gofeed.NewParser().Parse(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	var results []SearchResult
+	var guids []string
 	for _, item := range feed.Items {
-		// Skip items older than 'since'
-		if since != nil && item.PublishedParsed != nil && item.PublishedParsed.Before(*since) {
+		guid := itemGUID(item)
+		guids = append(guids, guid)
+
+		if state != nil && len(state.GUIDs) > 0 {
+			// GUIDs identify new items even in feeds without dates
+			if state.HasSeen(guid) {
+				continue
+			}
+		} else if since != nil && item.PublishedParsed != nil && item.PublishedParsed.Before(*since) {
+			// Skip items older than 'since'
 			continue
 		}
 
@@ -60,20 +168,79 @@ func (p *RSSProvider) FetchFeed(ctx context.Context, feedURL string, since *time
 		results = append(results, result)
 	}
 
+	if states != nil {
+		next := &models.FeedState{
+			URL:          feedURL,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			GUIDs:        mergeGUIDs(guids, state),
+			CheckedAt:    time.Now(),
+		}
+		if err := states.SaveFeedState(next); err != nil {
+			return nil, err
+		}
+	}
+
 	return results, nil
 }
 
 func (p *RSSProvider) FetchMultipleFeeds(ctx context.Context, feedURLs []string, since *time.Time) ([]SearchResult, error) {
-	var allResults []SearchResult
+	perFeed := make([][]SearchResult, len(feedURLs))
 
-	for _, url := range feedURLs {
-		results, err := p.FetchFeed(ctx, url, since)
+	workpool.Run(ctx, feedConcurrency, len(feedURLs), func(i int) {
+		results, err := p.FetchFeed(ctx, feedURLs[i], since)
 		if err != nil {
 			// Log error but continue with other feeds
-			continue
+			return
 		}
+		perFeed[i] = results
+	})
+
+	// Keep results in feed order regardless of which finished first
+	var allResults []SearchResult
+	for _, results := range perFeed {
 		allResults = append(allResults, results...)
 	}
 
 	return allResults, nil
 }
+
+// itemGUID identifies an item across fetches. Items without a GUID are
+// identified by link, then by title.
+func itemGUID(item *gofeed.Item) string {
+	switch {
+	case item.GUID != "":
+		return item.GUID
+	case item.Link != "":
+		return item.Link
+	default:
+		return item.Title
+	}
+}
+
+// mergeGUIDs keeps the current items' GUIDs followed by the most recent ones
+// from earlier fetches, so items that briefly drop out of a feed aren't taken
+// for new ones when they come back.
+func mergeGUIDs(current []string, previous *models.FeedState) []string {
+	seen := make(map[string]bool)
+	var merged []string
+
+	add := func(guids []string) {
+		for _, g := range guids {
+			if len(merged) == maxFeedGUIDs {
+				return
+			}
+			if !seen[g] {
+				seen[g] = true
+				merged = append(merged, g)
+			}
+		}
+	}
+
+	add(current)
+	if previous != nil {
+		add(previous.GUIDs)
+	}
+
+	return merged
+}
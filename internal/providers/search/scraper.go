@@ -1,20 +1,31 @@
 package search
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/oluoyefeso/termiflow/internal/httpcache"
 )
 
+// maxPageSize caps how much of a page or feed is read.
+const maxPageSize = 5 << 20
+
+// maxScrapedContent caps the article text Scrape returns, in bytes.
+const maxScrapedContent = 2000
+
 type Scraper struct {
 	client    *http.Client
 	userAgent string
 }
 
-func NewScraper(userAgent string, timeout int) *Scraper {
+// NewScraper returns a scraper that fetches pages as userAgent. Pages are
+// fetched as polite allows; it may be nil.
+func NewScraper(userAgent string, timeout int, polite *Politeness) *Scraper {
 	if userAgent == "" {
 		userAgent = "termiflow/1.0"
 	}
@@ -24,12 +35,19 @@ func NewScraper(userAgent string, timeout int) *Scraper {
 
 	return &Scraper{
 		client: &http.Client{
-			Timeout: time.Duration(timeout) * time.Second,
+			Timeout:   time.Duration(timeout) * time.Second,
+			Transport: polite.transport(nil, true),
 		},
 		userAgent: userAgent,
 	}
 }
 
+// UseCache serves pages from cache as policy says. Pages found in the cache
+// don't wait for politeness.
+func (s *Scraper) UseCache(cache *httpcache.Cache, policy httpcache.Policy) {
+	s.client.Transport = cache.Transport(s.client.Transport, policy)
+}
+
 func (s *Scraper) Name() string {
 	return "scraper"
 }
@@ -38,82 +56,57 @@ func (s *Scraper) Available() bool {
 	return true
 }
 
-func (s *Scraper) Scrape(ctx context.Context, url string) (*SearchResult, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// Scrape fetches a page and extracts its article. Content is cut to
+// maxScrapedContent bytes.
+func (s *Scraper) Scrape(ctx context.Context, pageURL string) (*SearchResult, error) {
+	article, err := s.Extract(ctx, pageURL)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", s.userAgent)
+	return &SearchResult{
+		Title:       article.Title,
+		URL:         article.CanonicalURL,
+		Snippet:     article.Description,
+		Content:     truncateText(article.Content, maxScrapedContent),
+		PublishedAt: article.PublishedAt,
+		Source:      "scraper",
+	}, nil
+}
 
-	resp, err := s.client.Do(req)
+// Extract fetches a page and returns its article in full.
+func (s *Scraper) Extract(ctx context.Context, pageURL string) (*Article, error) {
+	body, final, err := s.fetch(ctx, pageURL)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	return ExtractArticle(bytes.NewReader(body), final.String())
+}
+
+// fetch downloads a URL and returns its body and the URL it redirected to.
+func (s *Scraper) fetch(ctx context.Context, target string) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Extract title
-	title := doc.Find("title").First().Text()
-	title = strings.TrimSpace(title)
-
-	// Try to extract meta description
-	description := ""
-	doc.Find("meta[name='description']").Each(func(i int, s *goquery.Selection) {
-		if content, exists := s.Attr("content"); exists {
-			description = content
-		}
-	})
-
-	// Try to extract article content
-	content := ""
-
-	// Try common article selectors
-	articleSelectors := []string{
-		"article",
-		".article-content",
-		".post-content",
-		".entry-content",
-		"main",
-		".content",
-	}
+	req.Header.Set("User-Agent", s.userAgent)
 
-	for _, selector := range articleSelectors {
-		articleEl := doc.Find(selector).First()
-		if articleEl.Length() > 0 {
-			content = articleEl.Text()
-			break
-		}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer resp.Body.Close()
 
-	// Clean up content
-	content = cleanText(content)
-	if len(content) > 2000 {
-		content = content[:2000]
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("%s: %s", target, resp.Status)
 	}
 
-	return &SearchResult{
-		Title:   title,
-		URL:     url,
-		Snippet: description,
-		Content: content,
-		Source:  "scraper",
-	}, nil
-}
-
-func cleanText(text string) string {
-	// Remove excessive whitespace
-	lines := strings.Split(text, "\n")
-	var cleaned []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleaned = append(cleaned, line)
-		}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, nil, err
 	}
-	return strings.Join(cleaned, " ")
+
+	return body, resp.Request.URL, nil
 }
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/oluoyefeso/termiflow/internal/httpcache"
 )
 
 const tavilyAPIURL = "https://api.tavily.com/search"
@@ -23,6 +25,12 @@ func NewTavilyProvider(apiKey string) *TavilyProvider {
 	}
 }
 
+// UseCache serves search results from cache as policy says, so repeating a
+// search doesn't spend API credits.
+func (p *TavilyProvider) UseCache(cache *httpcache.Cache, policy httpcache.Policy) {
+	p.client.Transport = cache.Transport(p.client.Transport, policy)
+}
+
 func (p *TavilyProvider) Name() string {
 	return "tavily"
 }
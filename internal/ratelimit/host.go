@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimiter spaces out requests to each host on its own, so fetching from
+// many sites at once stays fast while no single site is hammered. A nil
+// *HostLimiter never blocks.
+type HostLimiter struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	delays map[string]time.Duration
+	next   map[string]time.Time
+}
+
+// NewHostLimiter returns a limiter that leaves at least interval between
+// requests to the same host.
+func NewHostLimiter(interval time.Duration) *HostLimiter {
+	return &HostLimiter{
+		interval: interval,
+		delays:   make(map[string]time.Duration),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// SetDelay asks for at least d between requests to host, such as a
+// robots.txt Crawl-delay. A delay shorter than the limiter's interval has no
+// effect.
+func (l *HostLimiter) SetDelay(host string, d time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delays[strings.ToLower(host)] = d
+}
+
+// Wait blocks until the caller may make its request to host or ctx is done.
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	host = strings.ToLower(host)
+
+	l.mu.Lock()
+	interval := l.interval
+	if d := l.delays[host]; d > interval {
+		interval = d
+	}
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewUnlimited(t *testing.T) {
+	if New(0) != nil || New(-5) != nil {
+		t.Error("New() with no limit should return nil")
+	}
+
+	var l *Limiter
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("nil Limiter Wait() error = %v", err)
+	}
+}
+
+func TestWaitSpacesRequests(t *testing.T) {
+	l := New(600) // one every 100ms
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	// The first request goes immediately, the other three wait their turn
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least 300ms", elapsed)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	l := New(1)
+	l.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestHostLimiterSpacesEachHost(t *testing.T) {
+	l := NewHostLimiter(100 * time.Millisecond)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, host := range []string{"a.example", "A.example", "a.example"} {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			l.Wait(context.Background(), host)
+		}(host)
+	}
+
+	// Another host doesn't queue behind a.example
+	time.Sleep(10 * time.Millisecond)
+	l.Wait(context.Background(), "b.example")
+	if elapsed := time.Since(start); elapsed > 90*time.Millisecond {
+		t.Errorf("request to b.example waited %v, want no wait", elapsed)
+	}
+
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("3 requests to a.example took %v, want at least 200ms", elapsed)
+	}
+}
+
+func TestHostLimiterSetDelay(t *testing.T) {
+	l := NewHostLimiter(0)
+	l.SetDelay("slow.example", 100*time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		l.Wait(context.Background(), "slow.example")
+		l.Wait(context.Background(), "fast.example")
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("two requests to a host with a 100ms delay took %v", elapsed)
+	}
+
+	var nilLimiter *HostLimiter
+	nilLimiter.SetDelay("x", time.Second)
+	if err := nilLimiter.Wait(context.Background(), "x"); err != nil {
+		t.Errorf("nil HostLimiter Wait() error = %v", err)
+	}
+}
@@ -0,0 +1,390 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/intelligence"
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// What a refresh that would go over budget does instead.
+const (
+	// BudgetSkipSummaries only scores results, leaving out summaries and tags
+	BudgetSkipSummaries = "skip_summaries"
+	// BudgetCheaperModel curates with BudgetOptions.Cheap
+	BudgetCheaperModel = "cheaper_model"
+	// BudgetStop leaves the results for a later refresh
+	BudgetStop = "stop"
+)
+
+// ErrOverBudget is returned for refreshes stopped to stay within budget.
+var ErrOverBudget = errors.New("over LLM budget")
+
+// BudgetOptions limit what refreshes spend on LLM calls.
+type BudgetOptions struct {
+	// Global caps every LLM call, ask and chat included. Subscriptions can
+	// set their own budget on top.
+	Global models.Budget
+	// OnExceed is what a refresh that would go over does. If cutting back
+	// still wouldn't fit, it stops.
+	OnExceed string
+	// Cheap curates instead under BudgetCheaperModel
+	Cheap llm.Provider
+	// Prices estimate what a refresh will cost. Models without a price only
+	// count against token budgets.
+	Prices llm.PriceTable
+	// Spent totals the usage since a time against a topic, or all usage for
+	// an empty topic. It defaults to the usage ledger.
+	Spent func(topic string, since time.Time) (*models.UsageSummary, error)
+}
+
+// Throttle describes a refresh cut back to stay within a budget.
+type Throttle struct {
+	// Action is BudgetSkipSummaries, BudgetCheaperModel or BudgetStop
+	Action string
+	// Budget names the limit, e.g. "daily budget of $1.00"
+	Budget string
+	// Model is what curated under BudgetCheaperModel
+	Model string
+	// Partial means the budget ran out during curation, so later results
+	// went without summaries or were left for a later refresh
+	Partial bool
+}
+
+func (t *Throttle) String() string {
+	switch {
+	case t.Partial:
+		return fmt.Sprintf("reached the %s part way; some results were left uncurated", t.Budget)
+	case t.Action == BudgetSkipSummaries:
+		return fmt.Sprintf("skipped summaries to stay within the %s", t.Budget)
+	case t.Action == BudgetCheaperModel:
+		return fmt.Sprintf("curated with %s to stay within the %s", t.Model, t.Budget)
+	default:
+		return fmt.Sprintf("stopped: curating would exceed the %s", t.Budget)
+	}
+}
+
+// spend is an amount of LLM usage, spent or expected.
+type spend struct {
+	cost   float64
+	tokens int64
+}
+
+func (s spend) add(o spend) spend {
+	return spend{cost: s.cost + o.cost, tokens: s.tokens + o.tokens}
+}
+
+// limit is one cap of a budget, in dollars or tokens, and what has been
+// spent against it.
+type limit struct {
+	period string
+	topic  string
+	cost   float64
+	tokens int64
+	spent  spend
+}
+
+// fits reports whether spending e more stays within the limit. Spending
+// nothing in dollars, as unpriced models do, fits a dollar limit however
+// much of it is spent.
+func (l limit) fits(e spend) bool {
+	if l.cost > 0 {
+		return e.cost == 0 || l.spent.cost+e.cost <= l.cost
+	}
+	return l.spent.tokens+e.tokens <= l.tokens
+}
+
+func (l limit) String() string {
+	amount := fmt.Sprintf("$%.2f", l.cost)
+	if l.cost == 0 {
+		amount = fmt.Sprintf("%d tokens", l.tokens)
+	}
+	if l.topic != "" {
+		return fmt.Sprintf("%s budget of %s for %s", l.period, amount, l.topic)
+	}
+	return fmt.Sprintf("%s budget of %s", l.period, amount)
+}
+
+// limits returns the caps a budget sets, with what was spent since the start
+// of each period.
+func (o BudgetOptions) limits(b models.Budget, topic string, now time.Time) ([]limit, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	periods := []struct {
+		name   string
+		start  time.Time
+		cost   float64
+		tokens int64
+	}{
+		{"daily", day, b.DailyCost, b.DailyTokens},
+		{"monthly", month, b.MonthlyCost, b.MonthlyTokens},
+	}
+
+	var limits []limit
+	for _, p := range periods {
+		if p.cost <= 0 && p.tokens <= 0 {
+			continue
+		}
+		spent, err := o.Spent(topic, p.start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LLM usage: %w", err)
+		}
+		used := spend{cost: spent.Cost, tokens: spent.TotalTokens()}
+		if p.cost > 0 {
+			limits = append(limits, limit{period: p.name, topic: topic, cost: p.cost, spent: used})
+		}
+		if p.tokens > 0 {
+			limits = append(limits, limit{period: p.name, topic: topic, tokens: p.tokens, spent: used})
+		}
+	}
+	return limits, nil
+}
+
+// planCuration picks how to curate within limits: as usual, or cut back as
+// onExceed says, each option given with what it's expected to spend. It
+// returns the chosen action, "" for none, and the first limit that curating
+// as usual would exceed.
+func planCuration(limits []limit, onExceed string, full spend, options map[string]spend) (string, *limit) {
+	var over *limit
+	for i := range limits {
+		if !limits[i].fits(full) {
+			over = &limits[i]
+			break
+		}
+	}
+	if over == nil {
+		return "", nil
+	}
+
+	if e, ok := options[onExceed]; ok && fitsAll(limits, e) {
+		return onExceed, over
+	}
+	return BudgetStop, over
+}
+
+func fitsAll(limits []limit, e spend) bool {
+	for _, l := range limits {
+		if !l.fits(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// headroom is what can be spent before reaching the first limit.
+func headroom(limits []limit) spend {
+	room := spend{cost: math.Inf(1), tokens: math.MaxInt64}
+	for _, l := range limits {
+		if l.cost > 0 {
+			room.cost = min(room.cost, l.cost-l.spent.cost)
+		} else {
+			room.tokens = min(room.tokens, l.tokens-l.spent.tokens)
+		}
+	}
+	return room
+}
+
+// budgetLimits returns the limits of a subscription's budget and of the
+// global one, with what has been spent against each.
+func (s *Scheduler) budgetLimits(sub *models.Subscription, now time.Time) (subLimits, global []limit, err error) {
+	subBudget := models.Budget{}
+	if sub.Budget != nil {
+		subBudget = *sub.Budget
+	}
+
+	subLimits, err = s.budget.limits(subBudget, sub.Topic, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	global, err = s.budget.limits(s.budget.Global, "", now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return subLimits, global, nil
+}
+
+// unpriced reports whether p's calls cost nothing, so only token limits
+// apply to them.
+func (o BudgetOptions) unpriced(p llm.Provider) bool {
+	return o.Prices.Cost(p.Model(), 1e6, 1e6) == 0
+}
+
+// checkBudget fails a refresh before it fetches anything if a budget is
+// already spent, since nothing fetched could be curated. An unpriced model,
+// or an unpriced cheap one under BudgetCheaperModel, can still curate once
+// dollar limits are spent, so only token limits count then.
+func (s *Scheduler) checkBudget(sub *models.Subscription) (*Throttle, error) {
+	if s.budget.Global.IsZero() && (sub.Budget == nil || sub.Budget.IsZero()) {
+		return nil, nil
+	}
+
+	subLimits, global, err := s.budgetLimits(sub, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	free := s.budget.unpriced(s.llmProvider) ||
+		(s.budget.OnExceed == BudgetCheaperModel && s.budget.Cheap != nil && s.budget.unpriced(s.budget.Cheap))
+
+	for _, l := range append(subLimits, global...) {
+		if l.cost > 0 && (free || l.spent.cost < l.cost) {
+			continue
+		}
+		if l.cost == 0 && l.spent.tokens < l.tokens {
+			continue
+		}
+		throttle := &Throttle{Action: BudgetStop, Budget: l.String()}
+		return throttle, fmt.Errorf("%w: the %s is spent", ErrOverBudget, throttle.Budget)
+	}
+	return nil, nil
+}
+
+// budgetCurator returns the curator to use on a subscription's results
+// within budget, with how the refresh was cut back if it was. It reserves
+// what the refresh is expected to spend against the global budget until
+// release is called, so subscriptions refreshed at once share it. release
+// reports if the budget ran out during curation.
+func (s *Scheduler) budgetCurator(sub *models.Subscription, results []search.SearchResult) (curator *intelligence.Curator, throttle *Throttle, release func() *Throttle, err error) {
+	release = func() *Throttle { return nil }
+	curator = s.curator
+
+	if s.budget.Global.IsZero() && (sub.Budget == nil || sub.Budget.IsZero()) {
+		return curator, nil, release, nil
+	}
+
+	limits, global, err := s.budgetLimits(sub, time.Now())
+	if err != nil {
+		return nil, nil, release, err
+	}
+
+	s.budgetMu.Lock()
+	defer s.budgetMu.Unlock()
+
+	// Other refreshes running now will spend from the global budget too
+	for i := range global {
+		global[i].spent = global[i].spent.add(s.reserved)
+	}
+	limits = append(limits, global...)
+
+	estimate := func(c *intelligence.Curator, p llm.Provider) spend {
+		prompt, completion := c.Estimate(results)
+		return spend{
+			cost:   s.budget.Prices.Cost(p.Model(), prompt, completion),
+			tokens: int64(prompt + completion),
+		}
+	}
+
+	full := estimate(curator, s.llmProvider)
+	options := map[string]spend{
+		BudgetSkipSummaries: estimate(curator.WithoutSummaries(), s.llmProvider),
+	}
+	if s.budget.Cheap != nil {
+		options[BudgetCheaperModel] = estimate(curator, s.budget.Cheap)
+	}
+
+	action, over := planCuration(limits, s.budget.OnExceed, full, options)
+	expected, provider := full, s.llmProvider
+	switch action {
+	case BudgetStop:
+		throttle = &Throttle{Action: action, Budget: over.String()}
+		return nil, throttle, release, fmt.Errorf("%w: curating would exceed the %s", ErrOverBudget, throttle.Budget)
+	case BudgetSkipSummaries:
+		curator = curator.WithoutSummaries()
+		expected = options[action]
+		throttle = &Throttle{Action: action, Budget: over.String()}
+	case BudgetCheaperModel:
+		curator = curator.WithProvider(s.budget.Cheap)
+		expected, provider = options[action], s.budget.Cheap
+		throttle = &Throttle{Action: action, Budget: over.String(), Model: s.budget.Cheap.Model()}
+	}
+
+	// Estimates are rough, so calls stop at the budget whatever was expected
+	room := headroom(limits)
+	if s.budget.unpriced(provider) {
+		room.cost = math.Inf(1)
+	}
+	guard := &budgetGuard{Provider: provider, prices: s.budget.Prices, limits: limits, room: room}
+	curator = curator.WithProvider(guard)
+
+	s.reserved = s.reserved.add(expected)
+	release = func() *Throttle {
+		s.budgetMu.Lock()
+		s.reserved = spend{cost: s.reserved.cost - expected.cost, tokens: s.reserved.tokens - expected.tokens}
+		s.budgetMu.Unlock()
+
+		guard.mu.Lock()
+		defer guard.mu.Unlock()
+		if guard.tripped == nil {
+			return nil
+		}
+		return &Throttle{Action: BudgetStop, Budget: guard.tripped.String(), Partial: true}
+	}
+	return curator, throttle, release, nil
+}
+
+// budgetGuard refuses calls once a refresh has spent its headroom. Refused
+// calls fail with ErrOverBudget and intelligence.ErrNotCurated, so results it
+// won't score are left for a later refresh.
+type budgetGuard struct {
+	llm.Provider
+	prices llm.PriceTable
+	limits []limit
+
+	mu      sync.Mutex
+	room    spend
+	spent   spend
+	tripped *limit
+}
+
+func (g *budgetGuard) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	g.mu.Lock()
+	if g.spent.cost >= g.room.cost || g.spent.tokens >= g.room.tokens {
+		if g.tripped == nil {
+			g.tripped = g.reached()
+		}
+		g.mu.Unlock()
+		return nil, fmt.Errorf("%w: %w", ErrOverBudget, intelligence.ErrNotCurated)
+	}
+	g.mu.Unlock()
+
+	resp, err := g.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, completion := resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	if prompt == 0 && completion == 0 {
+		for _, m := range req.Messages {
+			prompt += intelligence.EstimateTokens(m.Content)
+		}
+		completion = intelligence.EstimateTokens(resp.Content)
+	}
+	model := resp.Model
+	if model == "" {
+		model = g.Model()
+	}
+
+	g.mu.Lock()
+	g.spent = g.spent.add(spend{cost: g.prices.Cost(model, prompt, completion), tokens: int64(prompt + completion)})
+	g.mu.Unlock()
+
+	return resp, nil
+}
+
+// reached returns the limit the guard's spending ran into.
+func (g *budgetGuard) reached() *limit {
+	for i := range g.limits {
+		if !g.limits[i].fits(g.spent) {
+			return &g.limits[i]
+		}
+	}
+	return &g.limits[0]
+}
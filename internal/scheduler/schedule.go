@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/pkg/cron"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// Schedule decides when subscriptions are due. Daily subscriptions run at a
+// fixed local time of day and weekly ones on a fixed weekday at that time.
+// Subscriptions with a cron expression follow it instead.
+type Schedule struct {
+	Hour      int
+	Minute    int
+	WeeklyDay time.Weekday
+	Location  *time.Location
+}
+
+// DefaultSchedule runs daily at 08:00 and weekly on Mondays, in local time.
+func DefaultSchedule() Schedule {
+	return Schedule{Hour: 8, Minute: 0, WeeklyDay: time.Monday, Location: time.Local}
+}
+
+// NewSchedule builds a schedule from the [schedule] config section.
+func NewSchedule(cfg config.ScheduleConfig) (Schedule, error) {
+	s := DefaultSchedule()
+
+	if cfg.DailyTime != "" {
+		t, err := time.Parse("15:04", cfg.DailyTime)
+		if err != nil {
+			return s, fmt.Errorf("invalid schedule.daily_time %q: use HH:MM", cfg.DailyTime)
+		}
+		s.Hour, s.Minute = t.Hour(), t.Minute()
+	}
+
+	if cfg.WeeklyDay < 0 || cfg.WeeklyDay > 6 {
+		return s, fmt.Errorf("invalid schedule.weekly_day %d: use 0 (Sunday) to 6 (Saturday)", cfg.WeeklyDay)
+	}
+	s.WeeklyDay = time.Weekday(cfg.WeeklyDay)
+
+	return s, nil
+}
+
+// NextRun returns when the subscription should next be refreshed. A
+// subscription that has never been fetched is due immediately.
+func (s Schedule) NextRun(sub *models.Subscription, now time.Time) time.Time {
+	if sub.LastFetchedAt == nil {
+		return now
+	}
+	last := sub.LastFetchedAt.In(s.location())
+
+	if sub.Cron != "" {
+		// Expressions are validated at subscribe time; a broken one that
+		// slipped into the database falls back to the frequency below
+		if expr, err := cron.Parse(sub.Cron); err == nil {
+			if next := expr.Next(last); !next.IsZero() {
+				return next
+			}
+		}
+	}
+
+	switch sub.Frequency {
+	case "hourly":
+		return last.Add(time.Hour)
+	case "weekly":
+		return s.nextWeekly(last)
+	default:
+		return s.nextDaily(last)
+	}
+}
+
+// Due reports whether the subscription should be refreshed now.
+func (s Schedule) Due(sub *models.Subscription, now time.Time) bool {
+	return !s.NextRun(sub, now).After(now)
+}
+
+// nextDaily returns the first daily run time after t. Times are built with
+// time.Date on calendar days rather than by adding 24h, so runs stay at the
+// same wall-clock time across DST changes.
+func (s Schedule) nextDaily(t time.Time) time.Time {
+	next := s.at(t.Year(), t.Month(), t.Day())
+	if !next.After(t) {
+		next = s.at(t.Year(), t.Month(), t.Day()+1)
+	}
+	return next
+}
+
+func (s Schedule) nextWeekly(t time.Time) time.Time {
+	days := (int(s.WeeklyDay) - int(t.Weekday()) + 7) % 7
+	next := s.at(t.Year(), t.Month(), t.Day()+days)
+	if !next.After(t) {
+		next = s.at(t.Year(), t.Month(), t.Day()+days+7)
+	}
+	return next
+}
+
+// at returns the run time on the given day. A time that doesn't exist because
+// it falls in a DST gap runs just after the gap instead (02:30 becomes 03:30).
+func (s Schedule) at(year int, month time.Month, day int) time.Time {
+	t := time.Date(year, month, day, s.Hour, s.Minute, 0, 0, s.location())
+
+	// time.Date may resolve a skipped time to before the gap
+	wanted := time.Duration(s.Hour)*time.Hour + time.Duration(s.Minute)*time.Minute
+	got := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if got < wanted {
+		t = t.Add(wanted - got)
+	}
+	return t
+}
+
+func (s Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+	return s.Location
+}
@@ -2,66 +2,118 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/oluoyefeso/termiflow/internal/db"
 	"github.com/oluoyefeso/termiflow/internal/intelligence"
 	"github.com/oluoyefeso/termiflow/internal/providers/llm"
 	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/internal/workpool"
 	"github.com/oluoyefeso/termiflow/pkg/models"
 )
 
 type Scheduler struct {
-	llmProvider    llm.Provider
-	searchProvider search.Provider
-	rssProvider    *search.RSSProvider
-	curator        *intelligence.Curator
+	llmProvider llm.Provider
+	sources     *SourceRegistry
+	enricher    *search.Enricher
+	enrichAll   bool
+	curator     *intelligence.Curator
+	schedule    Schedule
+	concurrency int
+	budget      BudgetOptions
+
+	// budgetMu guards reserved, what refreshes running now are expected to
+	// spend from the global budget
+	budgetMu sync.Mutex
+	reserved spend
+
+	// saveMu serializes the duplicate check and insert of new items, so
+	// subscriptions refreshed in parallel don't both store the same URL
+	saveMu sync.Mutex
 }
 
-func New(llmProvider llm.Provider, searchProvider search.Provider) *Scheduler {
+// Options tune how a Scheduler refreshes subscriptions.
+type Options struct {
+	Schedule Schedule
+	// Concurrency is how many subscriptions are refreshed at once
+	Concurrency int
+	// CurationConcurrency is how many curation requests per subscription run at once
+	CurationConcurrency int
+	// CurationBatchSize is how many results each curation request covers
+	CurationBatchSize int
+	// Enricher fetches full articles for subscriptions using the scrape source
+	Enricher *search.Enricher
+	// EnrichAll fetches full articles for every subscription
+	EnrichAll bool
+	// Budget limits what curation spends on LLM calls
+	Budget BudgetOptions
+}
+
+func New(llmProvider llm.Provider, sources *SourceRegistry, opts Options) *Scheduler {
+	if opts.Budget.Spent == nil {
+		opts.Budget.Spent = db.UsageSince
+	}
+
 	return &Scheduler{
-		llmProvider:    llmProvider,
-		searchProvider: searchProvider,
-		rssProvider:    search.NewRSSProvider(),
-		curator:        intelligence.NewCurator(llmProvider),
+		llmProvider: llmProvider,
+		sources:     sources,
+		enricher:    opts.Enricher,
+		enrichAll:   opts.EnrichAll,
+		curator:     intelligence.NewCurator(llmProvider, opts.CurationConcurrency, opts.CurationBatchSize),
+		schedule:    opts.Schedule,
+		concurrency: opts.Concurrency,
+		budget:      opts.Budget,
 	}
 }
 
-// RefreshSubscription fetches and processes new items for a subscription
-func (s *Scheduler) RefreshSubscription(ctx context.Context, sub *models.Subscription) ([]*models.FeedItem, error) {
-	var allResults []search.SearchResult
-
-	// Fetch from search provider (Tavily)
-	if s.searchProvider != nil && s.searchProvider.Available() {
-		results, err := s.searchProvider.Search(ctx, search.SearchRequest{
-			Query:      sub.Topic,
-			MaxResults: 10,
-			TimeRange:  sub.GetTimeRange(),
-		})
-		if err == nil {
-			allResults = append(allResults, results...)
-		}
+// RefreshSubscription fetches and processes new items for a subscription. If
+// curation was cut back to stay within an LLM budget, the returned Throttle
+// says how; a refresh stopped by its budget fails with ErrOverBudget and
+// leaves its results for next time. A budget that's already spent stops the
+// refresh before anything is fetched, and one that runs out part way leaves
+// feeds to be read again, so the results it left out aren't lost.
+func (s *Scheduler) RefreshSubscription(ctx context.Context, sub *models.Subscription) ([]*models.FeedItem, *Throttle, error) {
+	// Feeds remember what they returned only once the new items are stored
+	states := db.NewFeedStateStore(sub.ID)
+	ctx = search.WithFeedStates(ctx, states)
+	// LLM usage is recorded against the topic
+	ctx = llm.WithTopic(ctx, sub.Topic)
+
+	if throttle, err := s.checkBudget(sub); err != nil {
+		return nil, throttle, err
 	}
 
-	// Fetch from RSS feeds if this is a category with default RSS
-	category := models.GetCategoryByName(sub.Topic)
-	if category != nil && len(category.DefaultRSS) > 0 {
-		results, err := s.rssProvider.FetchMultipleFeeds(ctx, category.DefaultRSS, sub.LastFetchedAt)
-		if err == nil {
-			allResults = append(allResults, results...)
-		}
+	allResults, err := s.fetchSources(ctx, sub)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Deduplicate by URL
-	allResults = deduplicateByURL(allResults)
+	// Results already stored were curated by an earlier refresh
+	allResults = withoutStored(allResults)
+
+	curator, throttle, release, err := s.budgetCurator(sub, allResults)
+	if err != nil {
+		return nil, throttle, err
+	}
 
 	// Curate results
-	items, err := s.curator.CurateResults(ctx, sub.Topic, allResults)
+	items, err := curator.CurateResults(ctx, sub.Topic, allResults)
+	if partial := release(); partial != nil {
+		throttle = partial
+	}
 	if err != nil {
-		return nil, err
+		return nil, throttle, err
 	}
 
-	// Set subscription ID and save to database
+	// Set subscription ID and save new items to database
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	var created []*models.FeedItem
 	for _, item := range items {
 		item.SubscriptionID = sub.ID
 
@@ -72,53 +124,174 @@ func (s *Scheduler) RefreshSubscription(ctx context.Context, sub *models.Subscri
 				// Log but continue
 				continue
 			}
+			created = append(created, item)
+		}
+	}
+
+	// Feeds remember their items as seen only if they were all curated
+	if throttle == nil || !throttle.Partial {
+		if err := states.Commit(); err != nil {
+			return nil, throttle, err
 		}
 	}
 
 	// Update last fetched time
 	if err := db.UpdateLastFetched(sub.ID); err != nil {
-		return nil, err
+		return nil, throttle, err
 	}
 
-	return items, nil
+	return created, throttle, nil
 }
 
-// RefreshAllSubscriptions refreshes all active subscriptions
-func (s *Scheduler) RefreshAllSubscriptions(ctx context.Context) error {
-	subs, err := db.GetActiveSubscriptions()
-	if err != nil {
-		return err
+// fetchSources gathers deduplicated results from the sources the subscription
+// selected. A failing source is skipped unless every source failed.
+func (s *Scheduler) fetchSources(ctx context.Context, sub *models.Subscription) ([]search.SearchResult, error) {
+	names := sub.Sources
+	if len(names) == 0 {
+		names = models.DefaultSources
 	}
 
-	for _, sub := range subs {
-		if shouldRefresh(sub) {
-			_, err := s.RefreshSubscription(ctx, sub)
-			if err != nil {
-				// Log error but continue with other subscriptions
-				continue
-			}
+	var results []search.SearchResult
+	var errs []error
+	scrape := false
+	fetched := 0
+
+	for _, name := range names {
+		if strings.EqualFold(name, ScrapeSource) {
+			scrape = true
+			continue
+		}
+
+		source, ok := s.sources.Lookup(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown source %q", name))
+			continue
+		}
+
+		sourceResults, err := source.Fetch(ctx, sub)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		fetched++
+		results = append(results, sourceResults...)
+	}
+
+	if fetched == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	results = deduplicateByURL(results)
+
+	if (scrape || s.enrichAll) && s.enricher != nil {
+		s.enrichContent(ctx, results)
+	}
+
+	return results, nil
+}
+
+// enrichContent fills in the article text of results that only came with a
+// snippet. Results already in the feed aren't fetched again, and pages that
+// can't be fetched keep what they had.
+func (s *Scheduler) enrichContent(ctx context.Context, results []search.SearchResult) {
+	var pending []search.SearchResult
+	var index []int
+	for i, r := range results {
+		if exists, _ := db.ItemExistsByURL(r.URL); !exists {
+			pending = append(pending, r)
+			index = append(index, i)
 		}
 	}
 
-	return nil
+	s.enricher.Enrich(ctx, pending)
+
+	for j, i := range index {
+		results[i] = pending[j]
+	}
+}
+
+// RefreshResult describes the outcome of refreshing one subscription.
+type RefreshResult struct {
+	Subscription *models.Subscription
+	NewItems     int
+	Duration     time.Duration
+	Err          error
+	// Throttle says how curation was cut back to stay within budget
+	Throttle *Throttle
 }
 
-func shouldRefresh(sub *models.Subscription) bool {
-	if sub.LastFetchedAt == nil {
-		return true
+// ProgressFunc is called when a subscription starts refreshing, with a nil
+// result, and again with its result when it finishes. It may be called from
+// several goroutines at once.
+type ProgressFunc func(sub *models.Subscription, result *RefreshResult)
+
+// RefreshSubscriptions refreshes subs in parallel, up to the configured
+// concurrency. Results are in the same order as subs. A failing subscription
+// doesn't stop the others; its error is reported in its result. Subscriptions
+// not yet started when ctx is cancelled are left out.
+func (s *Scheduler) RefreshSubscriptions(ctx context.Context, subs []*models.Subscription, progress ProgressFunc) []*RefreshResult {
+	results := make([]*RefreshResult, len(subs))
+
+	workpool.Run(ctx, s.concurrency, len(subs), func(i int) {
+		sub := subs[i]
+		if progress != nil {
+			progress(sub, nil)
+		}
+
+		start := time.Now()
+		items, throttle, err := s.RefreshSubscription(ctx, sub)
+		results[i] = &RefreshResult{
+			Subscription: sub,
+			NewItems:     len(items),
+			Duration:     time.Since(start),
+			Err:          err,
+			Throttle:     throttle,
+		}
+
+		if progress != nil {
+			progress(sub, results[i])
+		}
+	})
+
+	finished := results[:0]
+	for _, r := range results {
+		if r != nil {
+			finished = append(finished, r)
+		}
+	}
+	return finished
+}
+
+// RefreshAllSubscriptions refreshes the active subscriptions that are due.
+func (s *Scheduler) RefreshAllSubscriptions(ctx context.Context) ([]*RefreshResult, error) {
+	subs, err := db.GetActiveSubscriptions()
+	if err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
-	switch sub.Frequency {
-	case "hourly":
-		return now.Sub(*sub.LastFetchedAt) >= time.Hour
-	case "daily":
-		return now.Sub(*sub.LastFetchedAt) >= 24*time.Hour
-	case "weekly":
-		return now.Sub(*sub.LastFetchedAt) >= 7*24*time.Hour
-	default:
-		return now.Sub(*sub.LastFetchedAt) >= 24*time.Hour
+
+	var due []*models.Subscription
+	for _, sub := range subs {
+		if s.schedule.Due(sub, now) {
+			due = append(due, sub)
+		}
+	}
+
+	results := s.RefreshSubscriptions(ctx, due, nil)
+	return results, ctx.Err()
+}
+
+// withoutStored drops results whose URL is already a feed item.
+func withoutStored(results []search.SearchResult) []search.SearchResult {
+	var fresh []search.SearchResult
+	for _, r := range results {
+		if exists, _ := db.ItemExistsByURL(r.URL); !exists {
+			fresh = append(fresh, r)
+		}
 	}
+	return fresh
 }
 
 func deduplicateByURL(results []search.SearchResult) []search.SearchResult {
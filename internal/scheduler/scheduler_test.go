@@ -0,0 +1,564 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/oluoyefeso/termiflow/internal/config"
+	"github.com/oluoyefeso/termiflow/internal/providers/llm"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+func newYork(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	return loc
+}
+
+func fetchedAt(frequency string, last time.Time) *models.Subscription {
+	return &models.Subscription{Topic: "test", Frequency: frequency, LastFetchedAt: &last}
+}
+
+func TestNextRun(t *testing.T) {
+	loc := newYork(t)
+	s := Schedule{Hour: 8, Minute: 0, WeeklyDay: time.Monday, Location: loc}
+	date := func(y int, m time.Month, d, hh, mm int) time.Time {
+		return time.Date(y, m, d, hh, mm, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name     string
+		sub      *models.Subscription
+		expected time.Time
+	}{
+		{"hourly", fetchedAt("hourly", date(2024, 6, 12, 9, 15)), date(2024, 6, 12, 10, 15)},
+		{"daily before run time", fetchedAt("daily", date(2024, 6, 12, 6, 0)), date(2024, 6, 12, 8, 0)},
+		{"daily after run time", fetchedAt("daily", date(2024, 6, 12, 9, 0)), date(2024, 6, 13, 8, 0)},
+		{"daily exactly at run time", fetchedAt("daily", date(2024, 6, 12, 8, 0)), date(2024, 6, 13, 8, 0)},
+		{"daily end of month", fetchedAt("daily", date(2024, 6, 30, 20, 0)), date(2024, 7, 1, 8, 0)},
+		{"unknown frequency is daily", fetchedAt("sometimes", date(2024, 6, 12, 9, 0)), date(2024, 6, 13, 8, 0)},
+		// Wednesday -> following Monday
+		{"weekly midweek", fetchedAt("weekly", date(2024, 6, 12, 9, 0)), date(2024, 6, 17, 8, 0)},
+		{"weekly same day before run time", fetchedAt("weekly", date(2024, 6, 17, 7, 0)), date(2024, 6, 17, 8, 0)},
+		{"weekly same day after run time", fetchedAt("weekly", date(2024, 6, 17, 9, 0)), date(2024, 6, 24, 8, 0)},
+		// Clocks spring forward on 2024-03-10 and fall back on 2024-11-03
+		{"daily across spring forward", fetchedAt("daily", date(2024, 3, 9, 8, 30)), date(2024, 3, 10, 8, 0)},
+		{"daily across fall back", fetchedAt("daily", date(2024, 11, 2, 8, 30)), date(2024, 11, 3, 8, 0)},
+		{"weekly across spring forward", fetchedAt("weekly", date(2024, 3, 4, 8, 0)), date(2024, 3, 11, 8, 0)},
+	}
+
+	for _, tt := range tests {
+		got := s.NextRun(tt.sub, time.Now())
+		if !got.Equal(tt.expected) {
+			t.Errorf("%s: NextRun() = %v, want %v", tt.name, got, tt.expected)
+		}
+		if got.In(loc).Hour() != tt.expected.Hour() {
+			t.Errorf("%s: NextRun() wall clock = %v, want %v", tt.name, got.In(loc).Format("15:04"), tt.expected.Format("15:04"))
+		}
+	}
+}
+
+func TestNextRunCron(t *testing.T) {
+	loc := newYork(t)
+	s := Schedule{Hour: 8, Minute: 0, WeeklyDay: time.Monday, Location: loc}
+
+	// Friday evening run -> Monday morning
+	sub := fetchedAt("cron", time.Date(2024, 6, 14, 18, 0, 0, 0, loc))
+	sub.Cron = "0 7,18 * * 1-5"
+
+	got := s.NextRun(sub, time.Now())
+	expected := time.Date(2024, 6, 17, 7, 0, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("NextRun() = %v, want %v", got, expected)
+	}
+
+	// An invalid expression falls back to the frequency
+	sub.Cron = "bogus"
+	sub.Frequency = "daily"
+	got = s.NextRun(sub, time.Now())
+	expected = time.Date(2024, 6, 15, 8, 0, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("NextRun() with invalid cron = %v, want %v", got, expected)
+	}
+}
+
+func TestNextRunInDSTGap(t *testing.T) {
+	loc := newYork(t)
+	s := Schedule{Hour: 2, Minute: 30, WeeklyDay: time.Monday, Location: loc}
+
+	// 02:30 doesn't exist on 2024-03-10; the run moves to 03:30 EDT
+	got := s.NextRun(fetchedAt("daily", time.Date(2024, 3, 9, 3, 0, 0, 0, loc)), time.Now())
+	expected := time.Date(2024, 3, 10, 3, 30, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("NextRun() = %v, want %v", got, expected)
+	}
+}
+
+func TestNextRunNeverFetched(t *testing.T) {
+	now := time.Now()
+	sub := &models.Subscription{Topic: "new", Frequency: "weekly"}
+
+	if got := DefaultSchedule().NextRun(sub, now); !got.Equal(now) {
+		t.Errorf("NextRun() = %v, want now", got)
+	}
+	if !DefaultSchedule().Due(sub, now) {
+		t.Error("Due() should be true for a subscription never fetched")
+	}
+}
+
+func TestDue(t *testing.T) {
+	loc := newYork(t)
+	s := Schedule{Hour: 8, Minute: 0, WeeklyDay: time.Monday, Location: loc}
+	sub := fetchedAt("daily", time.Date(2024, 6, 12, 8, 5, 0, 0, loc))
+
+	if s.Due(sub, time.Date(2024, 6, 13, 7, 59, 0, 0, loc)) {
+		t.Error("Due() should be false before the run time")
+	}
+	if !s.Due(sub, time.Date(2024, 6, 13, 8, 0, 0, 0, loc)) {
+		t.Error("Due() should be true at the run time")
+	}
+}
+
+func TestNewSchedule(t *testing.T) {
+	s, err := NewSchedule(config.ScheduleConfig{DailyTime: "18:45", WeeklyDay: 5})
+	if err != nil {
+		t.Fatalf("NewSchedule() error = %v", err)
+	}
+	if s.Hour != 18 || s.Minute != 45 || s.WeeklyDay != time.Friday {
+		t.Errorf("NewSchedule() = %+v, want 18:45 on Friday", s)
+	}
+
+	invalid := []config.ScheduleConfig{
+		{DailyTime: "8am"},
+		{DailyTime: "25:00"},
+		{DailyTime: "08:00", WeeklyDay: 7},
+		{DailyTime: "08:00", WeeklyDay: -1},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewSchedule(cfg); err == nil {
+			t.Errorf("NewSchedule(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestDeduplicateByURL(t *testing.T) {
+	results := deduplicateByURL([]search.SearchResult{
+		{Title: "first", URL: "https://example.com/a"},
+		{Title: "second", URL: "https://example.com/b"},
+		{Title: "duplicate", URL: "https://example.com/a"},
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("deduplicateByURL() returned %d results, want 2", len(results))
+	}
+	if results[0].Title != "first" {
+		t.Errorf("deduplicateByURL() kept %q, want the first occurrence", results[0].Title)
+	}
+}
+
+func TestRefreshSubscriptionsCancelled(t *testing.T) {
+	s := New(nil, nil, Options{Schedule: DefaultSchedule(), Concurrency: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	started := 0
+	subs := []*models.Subscription{{ID: 1, Topic: "a"}, {ID: 2, Topic: "b"}}
+	results := s.RefreshSubscriptions(ctx, subs, func(sub *models.Subscription, result *RefreshResult) {
+		started++
+	})
+
+	if len(results) != 0 || started != 0 {
+		t.Errorf("RefreshSubscriptions() ran %d subscription(s) after cancel, want 0", len(results))
+	}
+}
+
+type stubSource struct {
+	name    string
+	results []search.SearchResult
+	err     error
+	calls   int
+}
+
+func (s *stubSource) Name() string { return s.name }
+
+func (s *stubSource) Fetch(ctx context.Context, sub *models.Subscription) ([]search.SearchResult, error) {
+	s.calls++
+	return s.results, s.err
+}
+
+func TestFetchSources(t *testing.T) {
+	tavily := &stubSource{name: "tavily", results: []search.SearchResult{{URL: "https://a.com"}, {URL: "https://b.com"}}}
+	rss := &stubSource{name: "rss", results: []search.SearchResult{{URL: "https://b.com"}, {URL: "https://c.com"}}}
+	hn := &stubSource{name: "hn", err: errors.New("timeout")}
+
+	registry := NewSourceRegistry()
+	registry.Register(tavily)
+	registry.Register(rss)
+	registry.Register(hn)
+
+	s := New(nil, registry, Options{Schedule: DefaultSchedule()})
+
+	tests := []struct {
+		name     string
+		sources  []string
+		wantURLs int
+		wantErr  bool
+	}{
+		{"defaults", nil, 3, false},
+		{"only rss", []string{"rss"}, 2, false},
+		{"failing source is skipped", []string{"HN", "tavily"}, 2, false},
+		{"every source failed", []string{"hn", "bogus"}, 0, true},
+		{"scrape alone finds nothing", []string{"scrape"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := s.fetchSources(context.Background(), &models.Subscription{Topic: "go", Sources: tt.sources})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(results) != tt.wantURLs {
+				t.Errorf("fetchSources() returned %d results, want %d", len(results), tt.wantURLs)
+			}
+		})
+	}
+
+	if hn.calls != 2 {
+		t.Errorf("hn fetched %d times, want 2", hn.calls)
+	}
+}
+
+func TestSourceRegistryNames(t *testing.T) {
+	registry := NewSourceRegistry()
+	for _, name := range []string{"tavily", "Lobsters", "rss"} {
+		if err := registry.Register(&stubSource{name: name}); err != nil {
+			t.Fatalf("Register(%q) error = %v", name, err)
+		}
+	}
+
+	want := []string{"lobsters", "rss", "tavily"}
+	if got := registry.Names(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+
+	if _, ok := registry.Lookup("LOBSTERS"); !ok {
+		t.Error("Lookup() should ignore case")
+	}
+
+	for _, name := range []string{"lobsters", "RSS", ScrapeSource} {
+		if err := registry.Register(&stubSource{name: name}); err == nil {
+			t.Errorf("Register(%q) should fail for a taken name", name)
+		}
+	}
+}
+
+func TestMatchesTopic(t *testing.T) {
+	tests := []struct {
+		title    string
+		keywords []string
+		phrases  []string
+		expected bool
+	}{
+		{"Quantum error correction milestone at Google", []string{"quantum", "error", "correction"}, nil, true},
+		{"Fixing an error in our billing code", []string{"quantum", "error", "correction"}, nil, false},
+		{"WebGPU ships in Firefox", []string{"webgpu"}, nil, true},
+		{"TSMC reports record quarter", nil, []string{"TSMC", "EUV lithography"}, true},
+		{"A recipe for sourdough", []string{"rust"}, []string{"rust lang"}, false},
+	}
+
+	for _, tt := range tests {
+		got := matchesTopic(search.SearchResult{Title: tt.title}, tt.keywords, tt.phrases)
+		if got != tt.expected {
+			t.Errorf("matchesTopic(%q) = %v, want %v", tt.title, got, tt.expected)
+		}
+	}
+}
+
+// meteredProvider answers every curation prompt as relevant, reporting the
+// same usage each call.
+type meteredProvider struct {
+	model string
+	usage llm.Usage
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *meteredProvider) Name() string    { return "metered" }
+func (p *meteredProvider) Model() string   { return p.model }
+func (p *meteredProvider) Available() bool { return true }
+
+func (p *meteredProvider) Stream(ctx context.Context, req llm.CompletionRequest) (<-chan llm.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *meteredProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	return &llm.CompletionResponse{Content: "0.9", Usage: p.usage}, nil
+}
+
+// spentSoFar reports fixed usage for every topic and period.
+func spentSoFar(cost float64, tokens int64) func(string, time.Time) (*models.UsageSummary, error) {
+	return func(topic string, since time.Time) (*models.UsageSummary, error) {
+		return &models.UsageSummary{Key: topic, Cost: cost, PromptTokens: tokens}, nil
+	}
+}
+
+func articles(n int) []search.SearchResult {
+	results := make([]search.SearchResult, n)
+	for i := range results {
+		results[i] = search.SearchResult{
+			Title:   fmt.Sprintf("Article %d", i),
+			URL:     fmt.Sprintf("https://example.com/%d", i),
+			Snippet: strings.Repeat("s", 400),
+			Content: strings.Repeat("c", 4000),
+		}
+	}
+	return results
+}
+
+func TestBudgetCurator(t *testing.T) {
+	// Curating ten articles as usual with gpt-4o costs about $0.07 and 24k
+	// tokens; scoring alone about $0.006, and gpt-4o-mini about $0.004
+	tests := []struct {
+		name       string
+		global     models.Budget
+		sub        *models.Budget
+		onExceed   string
+		spent      float64
+		wantAction string
+		wantBudget string
+		wantErr    bool
+	}{
+		{name: "no budget"},
+		{name: "within budget", global: models.Budget{DailyCost: 1}, onExceed: BudgetSkipSummaries, spent: 0.5},
+		{
+			name: "skips summaries", global: models.Budget{DailyCost: 1}, onExceed: BudgetSkipSummaries, spent: 0.97,
+			wantAction: BudgetSkipSummaries, wantBudget: "daily budget of $1.00",
+		},
+		{
+			name: "uses cheaper model", global: models.Budget{MonthlyCost: 20}, onExceed: BudgetCheaperModel, spent: 19.97,
+			wantAction: BudgetCheaperModel, wantBudget: "monthly budget of $20.00",
+		},
+		{
+			name: "stops when cutting back won't fit", global: models.Budget{DailyCost: 1}, onExceed: BudgetSkipSummaries, spent: 0.999,
+			wantAction: BudgetStop, wantBudget: "daily budget of $1.00", wantErr: true,
+		},
+		{
+			name: "stops when asked to", global: models.Budget{DailyCost: 1}, onExceed: BudgetStop, spent: 0.97,
+			wantAction: BudgetStop, wantBudget: "daily budget of $1.00", wantErr: true,
+		},
+		{
+			name: "subscription token budget", sub: &models.Budget{MonthlyTokens: 10000}, onExceed: BudgetCheaperModel,
+			wantAction: BudgetStop, wantBudget: "monthly budget of 10000 tokens for rust", wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(&meteredProvider{model: "gpt-4o"}, nil, Options{
+				CurationBatchSize: 1,
+				Budget: BudgetOptions{
+					Global:   tt.global,
+					OnExceed: tt.onExceed,
+					Cheap:    &meteredProvider{model: "gpt-4o-mini"},
+					Prices:   llm.DefaultPrices,
+					Spent:    spentSoFar(tt.spent, 0),
+				},
+			})
+			sub := &models.Subscription{Topic: "rust", Budget: tt.sub}
+
+			curator, throttle, release, err := s.budgetCurator(sub, articles(10))
+			defer release()
+
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrOverBudget)) {
+				t.Fatalf("budgetCurator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && curator == nil {
+				t.Fatal("budgetCurator() returned no curator")
+			}
+			if tt.wantAction == "" {
+				if throttle != nil {
+					t.Errorf("budgetCurator() throttle = %v, want none", throttle)
+				}
+				return
+			}
+			if throttle == nil || throttle.Action != tt.wantAction || throttle.Budget != tt.wantBudget {
+				t.Errorf("budgetCurator() throttle = %+v, want %s at the %s", throttle, tt.wantAction, tt.wantBudget)
+			}
+		})
+	}
+}
+
+func TestBudgetCuratorSharesGlobalBudget(t *testing.T) {
+	s := New(&meteredProvider{model: "gpt-4o"}, nil, Options{
+		CurationBatchSize: 1,
+		Budget: BudgetOptions{
+			Global:   models.Budget{DailyCost: 0.1},
+			OnExceed: BudgetSkipSummaries,
+			Prices:   llm.DefaultPrices,
+			Spent:    spentSoFar(0, 0),
+		},
+	})
+
+	_, first, release, _ := s.budgetCurator(&models.Subscription{Topic: "a"}, articles(10))
+	_, second, _, _ := s.budgetCurator(&models.Subscription{Topic: "b"}, articles(10))
+	if first != nil || second == nil || second.Action != BudgetSkipSummaries {
+		t.Errorf("concurrent refreshes throttled %v and %v, want only the second to skip summaries", first, second)
+	}
+
+	// Once the first has finished its spending is in the ledger instead
+	release()
+	s.budget.Spent = spentSoFar(0.07, 0)
+	if _, third, _, _ := s.budgetCurator(&models.Subscription{Topic: "c"}, articles(3)); third != nil {
+		t.Errorf("refresh after release throttled %v, want none", third)
+	}
+}
+
+func TestBudgetCuratorStopsCallsAtBudget(t *testing.T) {
+	// Each call reports 1000 tokens, far more than estimated
+	provider := &meteredProvider{model: "llama3", usage: llm.Usage{PromptTokens: 990, CompletionTokens: 10}}
+	s := New(provider, nil, Options{
+		CurationConcurrency: 1,
+		CurationBatchSize:   1,
+		Budget: BudgetOptions{
+			Global: models.Budget{DailyTokens: 5000},
+			Spent:  spentSoFar(0, 0),
+		},
+	})
+
+	results := []search.SearchResult{}
+	for i := 0; i < 5; i++ {
+		results = append(results, search.SearchResult{Title: fmt.Sprint(i), URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+
+	curator, throttle, release, err := s.budgetCurator(&models.Subscription{Topic: "rust"}, results)
+	if err != nil || throttle != nil {
+		t.Fatalf("budgetCurator() = %v, %v, want a full curation", throttle, err)
+	}
+	items, err := curator.CurateResults(context.Background(), "rust", results)
+	if err != nil {
+		t.Fatalf("CurateResults() error = %v", err)
+	}
+
+	// Results it couldn't score are left out rather than kept unscored
+	if len(items) != 2 {
+		t.Errorf("CurateResults() returned %d items, want the 2 scored before the budget ran out", len(items))
+	}
+	if provider.calls != 5 {
+		t.Errorf("provider answered %d calls, want 5 before the budget ran out", provider.calls)
+	}
+	partial := release()
+	if partial == nil || !partial.Partial || partial.Budget != "daily budget of 5000 tokens" {
+		t.Errorf("release() = %+v, want the daily token budget reached part way", partial)
+	}
+}
+
+func TestBudgetCuratorFreeCheaperModel(t *testing.T) {
+	// An unpriced cheap model curates however far past a dollar limit the
+	// spending went
+	for _, spent := range []float64{1, 1.5} {
+		t.Run(fmt.Sprint(spent), func(t *testing.T) {
+			cheap := &meteredProvider{model: "llama3", usage: llm.Usage{PromptTokens: 990, CompletionTokens: 10}}
+			s := New(&meteredProvider{model: "gpt-4o"}, nil, Options{
+				CurationBatchSize: 1,
+				Budget: BudgetOptions{
+					Global:   models.Budget{DailyCost: 1},
+					OnExceed: BudgetCheaperModel,
+					Cheap:    cheap,
+					Prices:   llm.DefaultPrices,
+					Spent:    spentSoFar(spent, 0),
+				},
+			})
+			sub := &models.Subscription{Topic: "rust"}
+
+			if throttle, err := s.checkBudget(sub); err != nil {
+				t.Fatalf("checkBudget() = %v, %v, want the refresh let through", throttle, err)
+			}
+			curator, throttle, release, err := s.budgetCurator(sub, articles(3))
+			if err != nil {
+				t.Fatalf("budgetCurator() error = %v", err)
+			}
+			if throttle == nil || throttle.Action != BudgetCheaperModel {
+				t.Errorf("budgetCurator() throttle = %+v, want %s", throttle, BudgetCheaperModel)
+			}
+
+			items, err := curator.CurateResults(context.Background(), "rust", articles(3))
+			if err != nil {
+				t.Fatalf("CurateResults() error = %v", err)
+			}
+			if len(items) != 3 || cheap.calls == 0 {
+				t.Errorf("CurateResults() curated %d items in %d calls, want all 3 curated", len(items), cheap.calls)
+			}
+			if partial := release(); partial != nil {
+				t.Errorf("release() = %+v, want no budget reached", partial)
+			}
+		})
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   models.Budget
+		onExceed string
+		cheap    string
+		spent    float64
+		tokens   int64
+		wantStop string
+	}{
+		{name: "no budget", spent: 100},
+		{name: "some left", global: models.Budget{DailyCost: 1}, onExceed: BudgetStop, spent: 0.99},
+		{name: "spent", global: models.Budget{DailyCost: 1}, onExceed: BudgetStop, spent: 1, wantStop: "daily budget of $1.00"},
+		{
+			name: "tokens spent", global: models.Budget{DailyCost: 1, MonthlyTokens: 5000}, onExceed: BudgetSkipSummaries, tokens: 5000,
+			wantStop: "monthly budget of 5000 tokens",
+		},
+		{name: "free cheaper model", global: models.Budget{DailyCost: 1}, onExceed: BudgetCheaperModel, cheap: "llama3", spent: 1},
+		{
+			name: "priced cheaper model", global: models.Budget{DailyCost: 1}, onExceed: BudgetCheaperModel, cheap: "gpt-4o-mini", spent: 1,
+			wantStop: "daily budget of $1.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(&meteredProvider{model: "gpt-4o"}, nil, Options{
+				Budget: BudgetOptions{
+					Global:   tt.global,
+					OnExceed: tt.onExceed,
+					Cheap:    &meteredProvider{model: tt.cheap},
+					Prices:   llm.DefaultPrices,
+					Spent:    spentSoFar(tt.spent, tt.tokens),
+				},
+			})
+
+			throttle, err := s.checkBudget(&models.Subscription{Topic: "rust"})
+			if tt.wantStop == "" {
+				if err != nil || throttle != nil {
+					t.Errorf("checkBudget() = %v, %v, want no throttle", throttle, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrOverBudget) {
+				t.Errorf("checkBudget() error = %v, want %v", err, ErrOverBudget)
+			}
+			if throttle == nil || throttle.Action != BudgetStop || throttle.Budget != tt.wantStop {
+				t.Errorf("checkBudget() throttle = %+v, want a stop at the %s", throttle, tt.wantStop)
+			}
+		})
+	}
+}
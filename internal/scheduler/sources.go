@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/oluoyefeso/termiflow/internal/db"
+	"github.com/oluoyefeso/termiflow/internal/intelligence"
+	"github.com/oluoyefeso/termiflow/internal/providers/search"
+	"github.com/oluoyefeso/termiflow/pkg/models"
+)
+
+// ScrapeSource is the source name that fetches the full article behind each
+// result found by a subscription's other sources.
+const ScrapeSource = "scrape"
+
+// Source fetches candidate results for a subscription.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, sub *models.Subscription) ([]search.SearchResult, error)
+}
+
+// SourceRegistry maps the names stored in Subscription.Sources to sources.
+type SourceRegistry struct {
+	sources map[string]Source
+}
+
+func NewSourceRegistry() *SourceRegistry {
+	return &SourceRegistry{sources: make(map[string]Source)}
+}
+
+// Register adds s under its name. It fails if the name is taken, by another
+// source or by ScrapeSource, which subscriptions select without a lookup.
+func (r *SourceRegistry) Register(s Source) error {
+	name := strings.ToLower(s.Name())
+	if name == ScrapeSource {
+		return fmt.Errorf("source name %q is reserved", name)
+	}
+	if _, ok := r.sources[name]; ok {
+		return fmt.Errorf("source %q is already registered", name)
+	}
+	r.sources[name] = s
+	return nil
+}
+
+// Lookup returns the source registered under name.
+func (r *SourceRegistry) Lookup(name string) (Source, bool) {
+	s, ok := r.sources[strings.ToLower(name)]
+	return s, ok
+}
+
+// Names returns the registered source names in alphabetical order.
+func (r *SourceRegistry) Names() []string {
+	names := make([]string, 0, len(r.sources))
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSearchSource queries a search provider such as Tavily for the topic.
+func NewSearchSource(provider search.Provider) Source {
+	return &searchSource{provider: provider}
+}
+
+type searchSource struct {
+	provider search.Provider
+}
+
+func (s *searchSource) Name() string {
+	return s.provider.Name()
+}
+
+func (s *searchSource) Fetch(ctx context.Context, sub *models.Subscription) ([]search.SearchResult, error) {
+	if !s.provider.Available() {
+		return nil, nil
+	}
+
+	return s.provider.Search(ctx, search.SearchRequest{
+		Query:      sub.Topic,
+		MaxResults: 10,
+		TimeRange:  sub.GetTimeRange(),
+	})
+}
+
+// NewRSSSource reads a subscription's feeds: its category's defaults and the
+// feeds added to it, plus the items from globalFeeds (search.rss.feeds) that
+// mention the topic.
+func NewRSSSource(rss *search.RSSProvider, globalFeeds []string) Source {
+	return &rssSource{rss: rss, globalFeeds: globalFeeds}
+}
+
+type rssSource struct {
+	rss         *search.RSSProvider
+	globalFeeds []string
+}
+
+func (s *rssSource) Name() string {
+	return "rss"
+}
+
+func (s *rssSource) Fetch(ctx context.Context, sub *models.Subscription) ([]search.SearchResult, error) {
+	var urls []string
+	var phrases []string
+
+	category := models.GetCategoryByName(sub.Topic)
+	if category != nil {
+		urls = append(urls, category.DefaultRSS...)
+		phrases = category.Keywords
+	}
+
+	feeds, err := db.GetSubscriptionFeeds(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, feed := range feeds {
+		urls = append(urls, feed.URL)
+	}
+
+	seen := make(map[string]bool)
+	var own []string
+	for _, url := range urls {
+		if !seen[url] {
+			seen[url] = true
+			own = append(own, url)
+		}
+	}
+
+	var global []string
+	for _, url := range s.globalFeeds {
+		if !seen[url] {
+			seen[url] = true
+			global = append(global, url)
+		}
+	}
+
+	results, err := s.rss.FetchMultipleFeeds(ctx, own, sub.LastFetchedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(global) > 0 {
+		globalResults, err := s.rss.FetchMultipleFeeds(ctx, global, sub.LastFetchedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		keywords := intelligence.Keywords(sub.Topic)
+		for _, r := range globalResults {
+			if matchesTopic(r, keywords, phrases) {
+				results = append(results, r)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// matchesTopic reports whether a result mentions a category phrase, or enough
+// of the topic's keywords, to be worth curating. Like intelligence.RankFeedItems
+// it needs two keywords when the topic has more than one.
+func matchesTopic(r search.SearchResult, keywords, phrases []string) bool {
+	text := strings.ToLower(r.Title + " " + r.Snippet)
+
+	for _, phrase := range phrases {
+		if strings.Contains(text, strings.ToLower(phrase)) {
+			return true
+		}
+	}
+
+	if len(keywords) == 0 {
+		return false
+	}
+
+	words := make(map[string]bool)
+	for _, w := range intelligence.Keywords(text) {
+		words[w] = true
+	}
+
+	matched := 0
+	for _, k := range keywords {
+		if words[k] {
+			matched++
+		}
+	}
+
+	return matched >= min(2, len(keywords))
+}
+
+// NewFeedSource reads a single feed configured under a name in
+// search.rss.named, so subscriptions can select it by that name.
+func NewFeedSource(name, url string, rss *search.RSSProvider) Source {
+	return &feedSource{name: name, url: url, rss: rss}
+}
+
+type feedSource struct {
+	name string
+	url  string
+	rss  *search.RSSProvider
+}
+
+func (s *feedSource) Name() string {
+	return s.name
+}
+
+func (s *feedSource) Fetch(ctx context.Context, sub *models.Subscription) ([]search.SearchResult, error) {
+	return s.rss.FetchFeed(ctx, s.url, sub.LastFetchedAt)
+}
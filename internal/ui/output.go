@@ -191,6 +191,37 @@ func CategoryRow(name, displayName string) string {
 	)
 }
 
+func HistoryRow(id int64, question, provider, timeAgo string) string {
+	return fmt.Sprintf("   %s %-44s %s\n",
+		MutedStyle.Render(fmt.Sprintf("#%-4d", id)),
+		question,
+		MutedStyle.Render(fmt.Sprintf("%s · %s", provider, timeAgo)),
+	)
+}
+
+func ScheduleRow(topic, frequency, lastRun, nextRun string) string {
+	return fmt.Sprintf("   %s %-24s %-28s %s\n      %s\n",
+		SuccessStyle.Render("●"),
+		topic,
+		MutedStyle.Render(frequency),
+		nextRun,
+		MutedStyle.Render("last refreshed "+lastRun),
+	)
+}
+
+func SearchResultRow(id int64, title, source, topic, timeAgo, snippet string) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("   %s %s\n", MutedStyle.Render(fmt.Sprintf("#%-4d", id)), BoldStyle.Render(title)))
+	b.WriteString(fmt.Sprintf("         %s\n", MutedStyle.Render(fmt.Sprintf("%s · %s · %s", source, topic, timeAgo))))
+
+	if snippet != "" {
+		b.WriteString(fmt.Sprintf("         %s\n", snippet))
+	}
+
+	return b.String()
+}
+
 func Tip(text string) string {
 	return fmt.Sprintf("\n %s %s\n",
 		MutedStyle.Render("Tip:"),
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+type taskState int
+
+const (
+	taskWaiting taskState = iota
+	taskRunning
+	taskDone
+	taskFailed
+)
+
+type progressTask struct {
+	name   string
+	state  taskState
+	detail string
+}
+
+// Progress shows one line per task and redraws them in place while tasks run
+// concurrently. When output is not a terminal, it prints a line as each task
+// finishes instead.
+type Progress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	live  bool
+	tasks []*progressTask
+	frame int
+	drawn int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewProgress creates a progress display for the named tasks, in order.
+func NewProgress(names []string) *Progress {
+	tasks := make([]*progressTask, len(names))
+	for i, name := range names {
+		tasks[i] = &progressTask{name: name}
+	}
+
+	return &Progress{
+		out:   os.Stdout,
+		live:  isTerminal(os.Stdout),
+		tasks: tasks,
+	}
+}
+
+// Start begins redrawing the display.
+func (p *Progress) Start() {
+	if !p.live {
+		return
+	}
+
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(80 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			p.mu.Lock()
+			p.frame++
+			p.redraw()
+			p.mu.Unlock()
+
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Begin marks task i as running.
+func (p *Progress) Begin(i int) {
+	p.set(i, taskRunning, "")
+}
+
+// Done marks task i as finished, with a short result such as "3 new items".
+func (p *Progress) Done(i int, detail string) {
+	p.set(i, taskDone, detail)
+}
+
+// Fail marks task i as failed.
+func (p *Progress) Fail(i int, detail string) {
+	p.set(i, taskFailed, detail)
+}
+
+// Stop draws the final state of every task and stops redrawing.
+func (p *Progress) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+		p.stop = nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.live {
+		p.redraw()
+	}
+}
+
+func (p *Progress) set(i int, state taskState, detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i < 0 || i >= len(p.tasks) {
+		return
+	}
+
+	task := p.tasks[i]
+	task.state = state
+	task.detail = detail
+
+	if !p.live && (state == taskDone || state == taskFailed) {
+		fmt.Fprint(p.out, p.line(task))
+	}
+}
+
+// redraw moves the cursor back over the previous frame and rewrites it.
+// The caller must hold p.mu.
+func (p *Progress) redraw() {
+	var b strings.Builder
+
+	if p.drawn > 0 {
+		fmt.Fprintf(&b, "\033[%dA", p.drawn)
+	}
+	for _, task := range p.tasks {
+		b.WriteString("\r\033[K")
+		b.WriteString(p.line(task))
+	}
+
+	fmt.Fprint(p.out, b.String())
+	p.drawn = len(p.tasks)
+}
+
+func (p *Progress) line(task *progressTask) string {
+	var icon, detail string
+
+	switch task.state {
+	case taskWaiting:
+		icon = MutedStyle.Render("·")
+		detail = MutedStyle.Render("waiting")
+	case taskRunning:
+		icon = TitleStyle.Render(spinnerFrames[p.frame%len(spinnerFrames)])
+		detail = MutedStyle.Render("fetching...")
+	case taskDone:
+		icon = SuccessStyle.Render("✓")
+		detail = task.detail
+	case taskFailed:
+		icon = ErrorStyle.Render("✗")
+		detail = ErrorStyle.Render(task.detail)
+	}
+
+	return fmt.Sprintf(" %s %-28s %s\n", icon, task.name, detail)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,46 @@
+// Package workpool runs indexed jobs on a bounded number of goroutines.
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Run calls fn(i) for every i in [0, n) with at most limit calls in flight,
+// and returns when all started calls have finished. Jobs that haven't started
+// when ctx is cancelled are skipped. Callers write results into a slice by
+// index so output order doesn't depend on scheduling.
+func Run(ctx context.Context, limit, n int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		// Don't start new work once cancelled, even if a slot was free
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,59 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	results := make([]int, 20)
+
+	Run(context.Background(), 3, len(results), func(i int) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		results[i] = i * i
+		atomic.AddInt32(&inFlight, -1)
+	})
+
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", peak)
+	}
+	for i, r := range results {
+		if r != i*i {
+			t.Errorf("results[%d] = %d, want %d", i, r, i*i)
+		}
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started int32
+
+	Run(ctx, 1, 10, func(i int) {
+		if atomic.AddInt32(&started, 1) == 2 {
+			cancel()
+		}
+	})
+
+	if started != 2 {
+		t.Errorf("started = %d jobs, want 2", started)
+	}
+}
+
+func TestRunZeroLimit(t *testing.T) {
+	var ran int32
+	Run(context.Background(), 0, 3, func(i int) { atomic.AddInt32(&ran, 1) })
+
+	if ran != 3 {
+		t.Errorf("ran = %d jobs, want 3", ran)
+	}
+}
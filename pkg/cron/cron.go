@@ -0,0 +1,268 @@
+// Package cron parses standard five-field cron expressions and computes when
+// they next fire.
+//
+// Fields are minute, hour, day of month, month and day of week. Each field
+// accepts *, numbers, ranges (1-5), lists (7,18) and steps (*/15, 9-17/2).
+// Months and weekdays may also be given by name (jan, mon), and 7 means
+// Sunday. The macros @hourly, @daily, @weekly and @monthly are supported.
+//
+// As in Vixie cron, when both day of month and day of week are restricted a
+// time matches if either of them does. A field starting with *, such as */2,
+// counts as unrestricted.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed cron expression.
+type Expression struct {
+	text   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// Parse parses a cron expression.
+func Parse(text string) (*Expression, error) {
+	text = strings.TrimSpace(text)
+
+	spec := text
+	if expanded, ok := macros[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day month weekday), got %d", text, len(fields))
+	}
+
+	e := &Expression{
+		text:   text,
+		anyDom: unrestricted(fields[2]),
+		anyDow: unrestricted(fields[4]),
+	}
+
+	var err error
+	if e.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if e.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if e.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if e.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if e.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+
+	// 7 is an alias for Sunday
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+
+	return e, nil
+}
+
+// unrestricted reports whether a day field leaves the day open, so that the
+// other day field alone decides. Vixie cron checks only for a leading *.
+func unrestricted(spec string) bool {
+	return strings.HasPrefix(spec, "*") || spec == "?"
+}
+
+// String returns the expression as it was written.
+func (e *Expression) String() string {
+	return e.text
+}
+
+// maxSearch bounds Next for expressions that can never fire, such as Feb 30.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t that matches the expression, in t's
+// location, or the zero time if there is none within five years.
+func (e *Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxSearch)
+
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for next.Before(limit) {
+		var candidate time.Time
+
+		switch {
+		case !has(e.month, int(next.Month())):
+			candidate = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !e.dayMatches(next):
+			candidate = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+		case !has(e.hour, next.Hour()):
+			candidate = next.Add(time.Duration(60-next.Minute()) * time.Minute)
+		case !has(e.minute, next.Minute()):
+			candidate = next.Add(time.Minute)
+		default:
+			return next
+		}
+
+		// Midnight can fall in a DST gap and resolve to the previous day
+		if !candidate.After(next) {
+			candidate = next.Add(time.Hour)
+		}
+		next = candidate
+	}
+
+	return time.Time{}
+}
+
+// maxIntervalRuns bounds the runs MaxInterval looks at. It covers a year of
+// an hourly schedule.
+const maxIntervalRuns = 10000
+
+// MaxInterval returns the longest gap between consecutive runs over the year
+// following t, looking at no more than the first 10,000 runs. It fails if the
+// expression doesn't fire within that year.
+func (e *Expression) MaxInterval(t time.Time) (time.Duration, error) {
+	limit := t.AddDate(1, 0, 0)
+
+	prev := e.Next(t)
+	if prev.IsZero() || !prev.Before(limit) {
+		return 0, fmt.Errorf("cron expression %q doesn't fire within a year", e.text)
+	}
+
+	var longest time.Duration
+	for runs := 0; prev.Before(limit) && runs < maxIntervalRuns; runs++ {
+		next := e.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(prev); gap > longest {
+			longest = gap
+		}
+		prev = next
+	}
+	return longest, nil
+}
+
+func (e *Expression) dayMatches(t time.Time) bool {
+	domMatch := has(e.dom, t.Day())
+	dowMatch := has(e.dow, int(t.Weekday()))
+
+	if e.anyDom || e.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+func (f field) parse(spec string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(spec, ",") {
+		bits, err := f.parsePart(part)
+		if err != nil {
+			return 0, err
+		}
+		set |= bits
+	}
+	return set, nil
+}
+
+func (f field) parsePart(part string) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepPart)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+		}
+		step = n
+	}
+
+	lo, hi := f.min, f.max
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		if f.name == dowField.name {
+			hi = 6
+		}
+	case strings.Contains(rangePart, "-"):
+		from, to, _ := strings.Cut(rangePart, "-")
+		var err error
+		if lo, err = f.value(from); err != nil {
+			return 0, err
+		}
+		if hi, err = f.value(to); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+		}
+	default:
+		v, err := f.value(rangePart)
+		if err != nil {
+			return 0, err
+		}
+		lo = v
+		if !hasStep {
+			hi = v
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s value %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,142 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@yearly",
+	}
+
+	for _, expr := range invalid {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(y int, m time.Month, d, hh, mm int) time.Time {
+		return time.Date(y, m, d, hh, mm, 0, 0, time.UTC)
+	}
+
+	// 2024-06-12 is a Wednesday
+	tests := []struct {
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{"* * * * *", utc(2024, 6, 12, 9, 15), utc(2024, 6, 12, 9, 16)},
+		{"*/15 * * * *", utc(2024, 6, 12, 9, 15), utc(2024, 6, 12, 9, 30)},
+		{"0 7,18 * * 1-5", utc(2024, 6, 12, 9, 0), utc(2024, 6, 12, 18, 0)},
+		{"0 7,18 * * 1-5", utc(2024, 6, 14, 18, 0), utc(2024, 6, 17, 7, 0)},
+		{"30 8 * * mon", utc(2024, 6, 12, 9, 0), utc(2024, 6, 17, 8, 30)},
+		{"0 0 * * 7", utc(2024, 6, 12, 9, 0), utc(2024, 6, 16, 0, 0)},
+		{"0 9 1 * *", utc(2024, 6, 12, 9, 0), utc(2024, 7, 1, 9, 0)},
+		{"0 0 29 feb *", utc(2024, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"0 12 * jan-mar/2 *", utc(2024, 1, 31, 13, 0), utc(2024, 3, 1, 12, 0)},
+		{"0 9-17/4 * * *", utc(2024, 6, 12, 13, 0), utc(2024, 6, 12, 17, 0)},
+		{"@daily", utc(2024, 12, 31, 23, 59), utc(2025, 1, 1, 0, 0)},
+		{"@weekly", utc(2024, 6, 12, 9, 0), utc(2024, 6, 16, 0, 0)},
+		// Day of month OR day of week when both are restricted
+		{"0 0 15 * fri", utc(2024, 6, 12, 9, 0), utc(2024, 6, 14, 0, 0)},
+		// A stepped * leaves the day open, so only odd days that are Mondays
+		{"0 0 */2 * 1", utc(2024, 6, 12, 9, 0), utc(2024, 6, 17, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		got := e.Next(tt.from)
+		if !got.Equal(tt.expected) {
+			t.Errorf("Parse(%q).Next(%v) = %v, want %v", tt.expr, tt.from, got, tt.expected)
+		}
+	}
+}
+
+func TestNextNeverFires(t *testing.T) {
+	e, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := e.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero time", got)
+	}
+	if _, err := e.MaxInterval(time.Now()); err == nil {
+		t.Error("MaxInterval() should return error")
+	}
+}
+
+func TestNextAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	// Clocks spring forward from 02:00 to 03:00 on 2024-03-10
+	e, _ := Parse("0 7 * * *")
+	got := e.Next(time.Date(2024, 3, 9, 8, 0, 0, 0, loc))
+	expected := time.Date(2024, 3, 10, 7, 0, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("Next() = %v, want %v", got, expected)
+	}
+
+	// 02:30 doesn't exist that day, so the next run is the following day
+	e, _ = Parse("30 2 * * *")
+	got = e.Next(time.Date(2024, 3, 9, 3, 0, 0, 0, loc))
+	expected = time.Date(2024, 3, 11, 2, 30, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("Next() in DST gap = %v, want %v", got, expected)
+	}
+}
+
+func TestMaxInterval(t *testing.T) {
+	from := time.Date(2024, 6, 12, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Duration
+	}{
+		{"0 * * * *", time.Hour},
+		{"0 8 * * *", 24 * time.Hour},
+		{"0 7,18 * * 1-5", 61 * time.Hour},
+		{"0 8 * * 1", 7 * 24 * time.Hour},
+		{"* * * * *", time.Minute},
+		{"0 0 1 1 *", 365 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		e, _ := Parse(tt.expr)
+		got, err := e.MaxInterval(from)
+		if err != nil {
+			t.Errorf("Parse(%q).MaxInterval() error = %v", tt.expr, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Parse(%q).MaxInterval() = %v, want %v", tt.expr, got, tt.expected)
+		}
+	}
+
+	// The next Feb 29 is more than a year away
+	e, _ := Parse("0 0 29 feb *")
+	if _, err := e.MaxInterval(from); err == nil {
+		t.Error("MaxInterval() should return error when the next run is over a year away")
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// SubscriptionFeed is an RSS or Atom feed a user attached to a subscription.
+type SubscriptionFeed struct {
+	ID             int64     `json:"id"`
+	SubscriptionID int64     `json:"subscription_id"`
+	URL            string    `json:"url"`
+	Title          string    `json:"title,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FeedState is what the last fetch of a feed returned: the validators for a
+// conditional GET and the GUIDs of the items it contained.
+type FeedState struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	GUIDs        []string  `json:"guids,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// HasSeen reports whether an item with guid was in an earlier fetch.
+func (s *FeedState) HasSeen(guid string) bool {
+	for _, g := range s.GUIDs {
+		if g == guid {
+			return true
+		}
+	}
+	return false
+}
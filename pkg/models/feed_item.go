@@ -41,8 +41,11 @@ func (f *FeedItem) TimeAgo() string {
 	if f.PublishedAt == nil {
 		return "unknown"
 	}
+	return timeAgo(*f.PublishedAt)
+}
 
-	duration := time.Since(*f.PublishedAt)
+func timeAgo(t time.Time) string {
+	duration := time.Since(t)
 
 	switch {
 	case duration < time.Minute:
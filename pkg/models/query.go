@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Query is a single `ask` round trip as stored in query_history. Follow-up
+// questions share the ThreadID of the first query in the conversation. An
+// Incomplete query's answer stopped part way.
+type Query struct {
+	ID               int64         `json:"id"`
+	ThreadID         int64         `json:"thread_id"`
+	Query            string        `json:"query"`
+	Response         string        `json:"response"`
+	Provider         string        `json:"provider"`
+	Model            string        `json:"model,omitempty"`
+	Sources          []QuerySource `json:"sources,omitempty"`
+	LatencyMs        int64         `json:"latency_ms"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	Incomplete       bool          `json:"incomplete,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// QuerySource is a source that was handed to the LLM when answering a query.
+type QuerySource struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+	ItemID  int64  `json:"item_id,omitempty"`
+}
+
+func (q *Query) GetSourcesJSON() string {
+	if len(q.Sources) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(q.Sources)
+	return string(data)
+}
+
+func (q *Query) SetSourcesFromJSON(data string) error {
+	if data == "" || data == "null" {
+		q.Sources = nil
+		return nil
+	}
+	return json.Unmarshal([]byte(data), &q.Sources)
+}
+
+func (q *Query) TotalTokens() int {
+	return q.PromptTokens + q.CompletionTokens
+}
+
+func (q *Query) TimeAgo() string {
+	if q.CreatedAt.IsZero() {
+		return "unknown"
+	}
+	return timeAgo(q.CreatedAt)
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuery_GetSourcesJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []QuerySource
+		expected string
+	}{
+		{
+			name:     "no sources",
+			sources:  nil,
+			expected: "[]",
+		},
+		{
+			name:     "single source",
+			sources:  []QuerySource{{Title: "Go 1.22", URL: "https://go.dev/blog"}},
+			expected: `[{"title":"Go 1.22","url":"https://go.dev/blog"}]`,
+		},
+		{
+			name:     "source with snippet",
+			sources:  []QuerySource{{Title: "T", URL: "https://t.com", Snippet: "s"}},
+			expected: `[{"title":"T","url":"https://t.com","snippet":"s"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Sources: tt.sources}
+			result := q.GetSourcesJSON()
+			if result != tt.expected {
+				t.Errorf("GetSourcesJSON() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuery_SetSourcesFromJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantLen int
+		wantErr bool
+	}{
+		{"empty string", "", 0, false},
+		{"null string", "null", 0, false},
+		{"empty array", "[]", 0, false},
+		{"two sources", `[{"title":"A","url":"https://a.com"},{"title":"B","url":"https://b.com"}]`, 2, false},
+		{"invalid json", "not json", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{}
+			err := q.SetSourcesFromJSON(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetSourcesFromJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(q.Sources) != tt.wantLen {
+				t.Errorf("SetSourcesFromJSON() sources length = %d, want %d", len(q.Sources), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestQuery_TotalTokens(t *testing.T) {
+	q := &Query{PromptTokens: 120, CompletionTokens: 30}
+	if q.TotalTokens() != 150 {
+		t.Errorf("TotalTokens() = %d, want 150", q.TotalTokens())
+	}
+}
+
+func TestQuery_TimeAgo(t *testing.T) {
+	q := &Query{}
+	if q.TimeAgo() != "unknown" {
+		t.Errorf("TimeAgo() with zero time = %q, want %q", q.TimeAgo(), "unknown")
+	}
+
+	q.CreatedAt = time.Now().Add(-2 * time.Hour)
+	if q.TimeAgo() != "2h ago" {
+		t.Errorf("TimeAgo() = %q, want %q", q.TimeAgo(), "2h ago")
+	}
+}
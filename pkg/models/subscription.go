@@ -3,18 +3,26 @@ package models
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/oluoyefeso/termiflow/pkg/cron"
 )
 
+// DefaultSources are used by subscriptions that didn't choose their sources.
+var DefaultSources = []string{"tavily", "rss"}
+
 type Subscription struct {
 	ID            int64      `json:"id"`
 	Topic         string     `json:"topic"`
 	Category      string     `json:"category,omitempty"`
 	Frequency     string     `json:"frequency"`
+	Cron          string     `json:"cron,omitempty"`
 	Sources       []string   `json:"sources,omitempty"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
 	IsActive      bool       `json:"is_active"`
+	// Budget caps what refreshing this subscription may spend on LLM calls
+	Budget *Budget `json:"budget,omitempty"`
 }
 
 func (s *Subscription) GetSourcesJSON() string {
@@ -33,7 +41,34 @@ func (s *Subscription) SetSourcesFromJSON(data string) error {
 	return json.Unmarshal([]byte(data), &s.Sources)
 }
 
+func (s *Subscription) GetBudgetJSON() interface{} {
+	if s.Budget == nil || s.Budget.IsZero() {
+		return nil
+	}
+	data, _ := json.Marshal(s.Budget)
+	return string(data)
+}
+
+func (s *Subscription) SetBudgetFromJSON(data string) error {
+	if data == "" || data == "null" {
+		s.Budget = nil
+		return nil
+	}
+	s.Budget = &Budget{}
+	return json.Unmarshal([]byte(data), s.Budget)
+}
+
+// GetTimeRange returns the search window that covers the gap between
+// refreshes, so no results are missed between runs.
 func (s *Subscription) GetTimeRange() string {
+	if s.Cron != "" {
+		if expr, err := cron.Parse(s.Cron); err == nil {
+			if interval, err := expr.MaxInterval(time.Now()); err == nil && interval > 0 {
+				return timeRangeFor(interval)
+			}
+		}
+	}
+
 	switch s.Frequency {
 	case "hourly":
 		return "day"
@@ -45,3 +80,16 @@ func (s *Subscription) GetTimeRange() string {
 		return "week"
 	}
 }
+
+func timeRangeFor(interval time.Duration) string {
+	switch {
+	case interval < 24*time.Hour:
+		return "day"
+	case interval < 7*24*time.Hour:
+		return "week"
+	case interval <= 31*24*time.Hour:
+		return "month"
+	default:
+		return "year"
+	}
+}
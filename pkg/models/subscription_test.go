@@ -136,6 +136,31 @@ func TestSubscription_GetTimeRange(t *testing.T) {
 	}
 }
 
+func TestSubscription_GetTimeRangeCron(t *testing.T) {
+	tests := []struct {
+		cron     string
+		expected string
+	}{
+		{"*/30 * * * *", "day"},
+		{"0 8 * * *", "week"},
+		{"0 7,18 * * 1-5", "week"},
+		{"0 8 * * 1", "month"},
+		{"0 8 1 * *", "month"},
+		{"0 8 1 1 *", "year"},
+		{"not a cron", "month"}, // falls back to frequency
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cron, func(t *testing.T) {
+			sub := &Subscription{Frequency: "weekly", Cron: tt.cron}
+			result := sub.GetTimeRange()
+			if result != tt.expected {
+				t.Errorf("GetTimeRange() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestSubscription_RoundTrip(t *testing.T) {
 	original := []string{"tavily", "rss", "scrape"}
 	sub := &Subscription{}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// UsageRecord is one LLM call as recorded in the llm_usage ledger.
+type UsageRecord struct {
+	ID       int64  `json:"id"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Purpose is what the call was for: ask, score, summarize, tags or curate
+	Purpose string `json:"purpose"`
+	// Topic is the subscription the call curated for, if any
+	Topic            string `json:"topic,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	// Estimated means the provider didn't report token counts, so they were
+	// estimated from the text
+	Estimated bool `json:"estimated,omitempty"`
+	// Cost is in US dollars, priced when the call was made
+	Cost      float64   `json:"cost"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (u *UsageRecord) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// UsageSummary totals the ledger entries sharing a key, such as a day or a
+// provider.
+type UsageSummary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (s *UsageSummary) TotalTokens() int64 {
+	return s.PromptTokens + s.CompletionTokens
+}
+
+// Budget caps what LLM calls may spend per local calendar day and month.
+// Zero fields are unlimited.
+type Budget struct {
+	DailyCost     float64 `json:"daily_cost,omitempty"`
+	MonthlyCost   float64 `json:"monthly_cost,omitempty"`
+	DailyTokens   int64   `json:"daily_tokens,omitempty"`
+	MonthlyTokens int64   `json:"monthly_tokens,omitempty"`
+}
+
+// IsZero reports whether the budget sets no limit.
+func (b Budget) IsZero() bool {
+	return b == Budget{}
+}
@@ -0,0 +1,192 @@
+// Package opml reads and writes OPML 2.0 subscription lists and maps their
+// outlines to topics with feeds.
+//
+// Feed readers nest feed outlines (those with an xmlUrl) inside folder
+// outlines. A folder holding feeds becomes one topic whose feeds are its
+// children; a feed outside any such folder becomes a topic of its own. Folders
+// holding only other folders group the topics inside them, and an empty
+// outline without an xmlUrl is a topic with no feeds.
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document is an OPML file.
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is a folder, topic or feed. Frequency, Cron and Sources are
+// termiflow's own attributes; other readers ignore them.
+type Outline struct {
+	Text      string    `xml:"text,attr"`
+	Title     string    `xml:"title,attr,omitempty"`
+	Type      string    `xml:"type,attr,omitempty"`
+	XMLURL    string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL   string    `xml:"htmlUrl,attr,omitempty"`
+	Frequency string    `xml:"frequency,attr,omitempty"`
+	Cron      string    `xml:"cron,attr,omitempty"`
+	Sources   string    `xml:"sources,attr,omitempty"`
+	Outlines  []Outline `xml:"outline"`
+}
+
+// Entry is a topic and its feeds.
+type Entry struct {
+	Topic string
+	// Group is the folder the topic was found in, or the one to export it under
+	Group     string
+	Feeds     []string
+	Frequency string
+	Cron      string
+	Sources   []string
+}
+
+// Parse reads an OPML document.
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid OPML: %w", err)
+	}
+	return &doc, nil
+}
+
+// Write encodes d with an XML header.
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Entries maps the document's outlines to topics, in document order.
+func (d *Document) Entries() []Entry {
+	var entries []Entry
+	for _, o := range d.Body.Outlines {
+		entries = collect(entries, o, "")
+	}
+	return entries
+}
+
+func collect(entries []Entry, o Outline, group string) []Entry {
+	name := o.name()
+
+	if o.XMLURL != "" {
+		return append(entries, Entry{Topic: name, Group: group, Feeds: []string{o.XMLURL}})
+	}
+
+	var feeds []string
+	var folders []Outline
+	for _, child := range o.Outlines {
+		if child.XMLURL != "" {
+			feeds = append(feeds, child.XMLURL)
+		} else {
+			folders = append(folders, child)
+		}
+	}
+
+	isTopic := len(feeds) > 0 || len(o.Outlines) == 0 || o.Frequency != "" || o.Cron != ""
+	if isTopic && name != "" {
+		entries = append(entries, Entry{
+			Topic:     name,
+			Group:     group,
+			Feeds:     feeds,
+			Frequency: o.Frequency,
+			Cron:      o.Cron,
+			Sources:   splitList(o.Sources),
+		})
+	}
+
+	// Folders inside a topic are grouped under the topic's own group
+	childGroup := name
+	if isTopic {
+		childGroup = group
+	}
+	for _, child := range folders {
+		entries = collect(entries, child, childGroup)
+	}
+
+	return entries
+}
+
+// FromEntries builds a document with a folder per group. Entries with no
+// group are placed at the top level.
+func FromEntries(title string, entries []Entry, created time.Time) *Document {
+	doc := &Document{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: created.Format(time.RFC1123Z),
+		},
+	}
+
+	groups := make(map[string]int)
+	for _, e := range entries {
+		topic := Outline{
+			Text:      e.Topic,
+			Title:     e.Topic,
+			Frequency: e.Frequency,
+			Cron:      e.Cron,
+			Sources:   strings.Join(e.Sources, ","),
+		}
+		for _, feed := range e.Feeds {
+			topic.Outlines = append(topic.Outlines, Outline{Text: feed, Type: "rss", XMLURL: feed})
+		}
+
+		if e.Group == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, topic)
+			continue
+		}
+
+		i, ok := groups[e.Group]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			groups[e.Group] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, Outline{Text: e.Group, Title: e.Group})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, topic)
+	}
+
+	return doc
+}
+
+func (o Outline) name() string {
+	if text := strings.TrimSpace(o.Text); text != "" {
+		return text
+	}
+	return strings.TrimSpace(o.Title)
+}
+
+func splitList(value string) []string {
+	var parts []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,74 @@
+package opml
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntriesFromReaderExport(t *testing.T) {
+	f, err := os.Open("testdata/reader.opml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := Parse(f)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	expected := []Entry{
+		{Topic: "Rust", Feeds: []string{"https://without.boats/index.xml", "https://tokio.rs/blog/feed.xml"}},
+		// A folder with feeds of its own is a topic, even if it also has subfolders
+		{Topic: "Hardware", Feeds: []string{"https://chipsandcheese.com/feed/"}},
+		{Topic: "Chips", Feeds: []string{"https://semianalysis.com/feed/"}},
+		{Topic: "Lobsters", Feeds: []string{"https://lobste.rs/rss"}},
+		{Topic: "Empty folder"},
+	}
+
+	got := doc.Entries()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Entries() =\n%+v\nwant\n%+v", got, expected)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Topic: "silicon-chips", Group: "Silicon & Semiconductors", Feeds: []string{"https://semianalysis.com/feed/"}, Frequency: "daily", Sources: []string{"tavily", "rss"}},
+		{Topic: "chip export controls", Frequency: "cron", Cron: "0 7,18 * * 1-5"},
+		{Topic: "rust async", Group: "Custom", Feeds: []string{"https://without.boats/index.xml"}, Frequency: "weekly", Sources: []string{"rss"}},
+	}
+
+	var buf bytes.Buffer
+	created := time.Date(2024, 6, 12, 9, 0, 0, 0, time.UTC)
+	if err := FromEntries("termiflow subscriptions", entries, created).Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), `xmlUrl="https://semianalysis.com/feed/"`) {
+		t.Errorf("Write() produced unexpected XML:\n%s", buf.String())
+	}
+
+	doc, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if doc.Head.DateCreated != "Wed, 12 Jun 2024 09:00:00 +0000" {
+		t.Errorf("DateCreated = %q", doc.Head.DateCreated)
+	}
+
+	got := doc.Entries()
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("Entries() after round trip =\n%+v\nwant\n%+v", got, entries)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<html><body>not opml")); err == nil {
+		t.Error("Parse() should reject non-OPML input")
+	}
+}